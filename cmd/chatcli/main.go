@@ -0,0 +1,251 @@
+// chatcli терминальный клиент для отладки и демонстраций: подключается к запущенному серверу,
+// показывает историю чата и новые сообщения из SSE потока, отправляет введенные строки в чат.
+//
+//	go run ./cmd/chatcli -chat 1
+//	go run ./cmd/chatcli -title "demo" -user alice
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"chat-project/pkg/client"
+)
+
+const usage = `Usage: chatcli [flags]
+
+Join chat with -chat ID or create new one with -title. Every entered line is sent to the chat.
+Commands: /history - reload history, /quit - exit (or Ctrl-D). Other /commands go to the server, see /help.
+
+Flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("chatcli", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "http://localhost:8001", "server address")
+	user := fs.String("user", "", "user id sent in X-User-Id")
+	chatID := fs.Int("chat", 0, "id of chat to join")
+	title := fs.String("title", "", "create new chat with this title")
+	history := fs.Int("history", 20, "number of messages shown on join")
+	ttl := fs.Duration("ttl", 0, "send self-destructing messages that disappear after this time, e.g. 5m")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if (*chatID == 0) == (*title == "") {
+		fmt.Fprintln(stderr, "chatcli: either -chat or -title is required")
+		fs.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	t := &terminal{
+		api:     client.New(*addr, client.WithUserID(*user)),
+		out:     stdout,
+		history: *history,
+		ttl:     *ttl,
+	}
+	if err := t.run(ctx, *chatID, *title, stdin); err != nil {
+		fmt.Fprintln(stderr, "chatcli:", err)
+		return 1
+	}
+	return 0
+}
+
+type terminal struct {
+	api     *client.Client
+	history int
+	ttl     time.Duration
+
+	// Сообщения печатаются из потока событий и из ввода, вывод общий
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (t *terminal) run(ctx context.Context, chatID int, title string, stdin io.Reader) error {
+	if chatID == 0 {
+		chat, err := t.api.CreateChat(ctx, client.ChatInput{Title: title})
+		if err != nil {
+			return fmt.Errorf("create chat: %w", err)
+		}
+		chatID = chat.ID
+		t.status("created chat #%d", chatID)
+	}
+
+	lastID, err := t.showHistory(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	// Подписка продолжается с последнего показанного сообщения, поэтому сообщения,
+	// пришедшие между загрузкой истории и подключением, не теряются
+	opts := client.SubscribeOptions{
+		OnDisconnect: func() { t.status("connection lost, reconnecting...") },
+		OnReconnect:  func() { t.status("reconnected") },
+	}
+	if lastID != 0 {
+		opts.LastEventID = strconv.Itoa(lastID)
+	}
+	sub, err := t.api.Subscribe(ctx, chatID, opts)
+	if err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+	defer sub.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		defer cancel()
+		t.stream(sub)
+	}()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Поток завершается сам при удалении чата или ошибке, которую вернет Err
+			return sub.Err()
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			if done := t.handleLine(ctx, chatID, strings.TrimSpace(line)); done {
+				return nil
+			}
+		}
+	}
+}
+
+func (t *terminal) handleLine(ctx context.Context, chatID int, line string) bool {
+	switch line {
+	case "":
+		return false
+	case "/quit":
+		return true
+	case "/history":
+		if _, err := t.showHistory(ctx, chatID); err != nil {
+			t.status("%v", err)
+		}
+		return false
+	}
+
+	// Свое сообщение не печатается сразу, оно придет из потока событий.
+	// Ответ слэш-команды только для отправителя в поток не попадает
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	msg, err := t.api.SendMessage(ctx, chatID, client.MessageInput{Text: line, TTLSeconds: int(t.ttl.Seconds())})
+	if err != nil {
+		t.status("message not sent: %v", err)
+		return false
+	}
+	if msg.Ephemeral {
+		t.message(*msg)
+	}
+	return false
+}
+
+// showHistory печатает последние сообщения чата и возвращает id последнего из них
+func (t *terminal) showHistory(ctx context.Context, chatID int) (int, error) {
+	chat, err := t.api.GetChat(ctx, chatID)
+	if err != nil {
+		return 0, fmt.Errorf("load chat #%d: %w", chatID, err)
+	}
+
+	messages := chat.Messages
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	t.status("chat #%d %q, %d messages", chat.ID, chat.Title, len(messages))
+	shown := messages
+	if t.history >= 0 && len(shown) > t.history {
+		shown = shown[len(shown)-t.history:]
+	}
+	for _, msg := range shown {
+		t.message(msg)
+	}
+
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	return messages[len(messages)-1].ID, nil
+}
+
+func (t *terminal) stream(sub *client.Subscription) {
+	for event := range sub.Events() {
+		switch event.Type {
+		case client.EventMessage:
+			if event.Message != nil {
+				t.message(*event.Message)
+			}
+		case client.EventChatUpdated:
+			if event.Chat != nil {
+				t.status("chat updated: %q", event.Chat.Title)
+			}
+		case client.EventChatDeleted:
+			t.status("chat deleted")
+		case client.EventMessageDeleted:
+			t.status("%d old messages deleted by retention policy", len(event.MessageIDs))
+		case client.EventMessageExpired:
+			t.status("%d self-destructing messages expired", len(event.MessageIDs))
+		}
+	}
+}
+
+func (t *terminal) message(msg client.Message) {
+	prefix := ""
+	switch msg.Kind {
+	case client.MessageKindSystem:
+		prefix = "* "
+	case client.MessageKindBot:
+		prefix = "[" + msg.AuthorID + "] "
+	}
+	suffix := ""
+	if msg.ExpiresAt != nil {
+		suffix = " (disappears at " + msg.ExpiresAt.Local().Format(time.TimeOnly) + ")"
+	}
+	t.print("%s %s%s%s", msg.CreatedAt.Local().Format(time.TimeOnly), prefix, msg.Text, suffix)
+}
+
+func (t *terminal) status(format string, args ...any) {
+	t.print("-- "+format, args...)
+}
+
+func (t *terminal) print(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.out, format+"\n", args...)
+}
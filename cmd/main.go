@@ -1,19 +1,12 @@
 package main
 
 import (
-	"log"
+	"os"
 
-	"chat-project/config"
-	"chat-project/internal/app"
+	"chat-project/internal/cli"
 )
 
 func main() {
-	// Configuration
-	cfg, err := config.NewConfig()
-	if err != nil {
-		log.Fatalf("Config error: %s", err)
-	}
-
-	// Run
-	app.Run(cfg)
+	// Без аргументов запускается serve
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
 }
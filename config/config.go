@@ -2,17 +2,29 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type (
 	Config struct {
-		App      App
-		HTTP     HTTP
-		Log      Log
-		Postgres Postgres
-		Redis    Redis
+		App         App
+		HTTP        HTTP
+		Log         Log
+		Postgres    Postgres
+		Redis       Redis
+		Listener    Listener
+		Tracing     Tracing
+		Health      Health
+		Admin       Admin
+		Validation  Validation
+		RateLimit   RateLimit
+		Idempotency Idempotency
+		Webhook     Webhook
+		Scheduler   Scheduler
+		Retention   Retention
+		Expiry      Expiry
 	}
 
 	App struct {
@@ -22,15 +34,23 @@ type (
 
 	HTTP struct {
 		Port string `env:"HTTP_PORT,required" env-default:"8001"`
+		// Сколько /readyz отвечает ошибкой до остановки сервера, чтобы балансировщик успел снять трафик
+		ShutdownDelay time.Duration `env:"HTTP_SHUTDOWN_DELAY" env-default:"5s"`
+		// Сколько ждать завершения активных запросов
+		ShutdownTimeout time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT" env-default:"15s"`
 	}
 
 	Log struct {
-		Level string `env:"LOG_LEVEL,required" env-default:"INFO"`
+		Level  string `env:"LOG_LEVEL,required" env-default:"INFO"`
+		Format string `env:"LOG_FORMAT" env-default:"json"`
 	}
 
 	Postgres struct {
-		Url         string `env:"POSTGRES_URL,required"`
-		MigratePath string `env:"POSTGRES_MIGRATE_PATH,required" env-default:"file://migrations"`
+		Url string `env:"POSTGRES_URL,required"`
+		// Источник миграций golang-migrate, например file://migrations. По умолчанию встроенные в бинарник
+		MigratePath string `env:"POSTGRES_MIGRATE_PATH"`
+		// Применять миграции при запуске serve
+		AutoMigrate bool `env:"POSTGRES_AUTO_MIGRATE" env-default:"false"`
 	}
 
 	Redis struct {
@@ -38,6 +58,101 @@ type (
 		Password string `env:"REDIS_PASSWORD,required"`
 		DB       int    `env:"REDIS_DB,required" env-default:"0"`
 	}
+
+	Listener struct {
+		GracePeriod time.Duration `env:"LISTENER_GRACE_PERIOD" env-default:"5s"`
+		// Сколько SSE подключений может держать открытыми один адрес клиента, 0 - без ограничения
+		MaxConnectionsPerClient int `env:"LISTENER_MAX_CONNECTIONS_PER_CLIENT" env-default:"20"`
+	}
+
+	// Exporter: none, otlp (OTLP/HTTP на Endpoint) или stdout
+	Tracing struct {
+		Exporter    string  `env:"TRACING_EXPORTER" env-default:"none"`
+		Endpoint    string  `env:"TRACING_OTLP_ENDPOINT" env-default:"localhost:4318"`
+		Insecure    bool    `env:"TRACING_OTLP_INSECURE" env-default:"true"`
+		SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	}
+
+	// Token для /admin/*, без токена admin эндпоинты не регистрируются
+	Admin struct {
+		Token string `env:"ADMIN_TOKEN"`
+	}
+
+	// Ограничения длины полей в символах. Title и AvatarURL не больше размера колонок в БД: 255 и 2048
+	Validation struct {
+		TitleMaxLength       int `env:"VALIDATION_TITLE_MAX_LENGTH" env-default:"255"`
+		DescriptionMaxLength int `env:"VALIDATION_DESCRIPTION_MAX_LENGTH" env-default:"1000"`
+		AvatarURLMaxLength   int `env:"VALIDATION_AVATAR_URL_MAX_LENGTH" env-default:"2048"`
+		MessageMaxLength     int `env:"VALIDATION_MESSAGE_MAX_LENGTH" env-default:"4000"`
+	}
+
+	// Лимиты запросов в формате "20/10s": не больше 20 запросов за 10 секунд, "0" отключает лимит.
+	// Store: redis (общие для всех инстансов) или memory. ClientKey: ip или user (X-User-Id, без заголовка ip).
+	// При ClientKey user запросы одного адреса дополнительно ограничены Address, чтобы смена заголовка не обходила лимиты
+	RateLimit struct {
+		Store             string `env:"RATE_LIMIT_STORE" env-default:"redis"`
+		ClientKey         string `env:"RATE_LIMIT_CLIENT_KEY" env-default:"ip"`
+		API               string `env:"RATE_LIMIT_API" env-default:"300/1m"`
+		MessagesPerClient string `env:"RATE_LIMIT_MESSAGES_PER_CLIENT" env-default:"20/10s"`
+		MessagesPerChat   string `env:"RATE_LIMIT_MESSAGES_PER_CHAT" env-default:"100/10s"`
+		SSEPerClient      string `env:"RATE_LIMIT_SSE_PER_CLIENT" env-default:"30/1m"`
+		IncomingWebhook   string `env:"RATE_LIMIT_INCOMING_WEBHOOK" env-default:"30/1m"`
+		Address           string `env:"RATE_LIMIT_ADDRESS" env-default:"1000/1m"`
+	}
+
+	// Ответы на запросы с Idempotency-Key хранятся TTL. LockTimeout - сколько ключ занят выполняющимся запросом,
+	// если инстанс упал, не завершив его. Store: redis или memory
+	Idempotency struct {
+		Store       string        `env:"IDEMPOTENCY_STORE" env-default:"redis"`
+		TTL         time.Duration `env:"IDEMPOTENCY_TTL" env-default:"24h"`
+		LockTimeout time.Duration `env:"IDEMPOTENCY_LOCK_TIMEOUT" env-default:"30s"`
+	}
+
+	// Доставка вебхуков. Повтор после n-й неудачной попытки через BackoffBase * 2^(n-1), но не позже BackoffMax.
+	// После MaxAttempts попыток доставка получает статус dead. Workers 0 отключает доставку на этом инстансе
+	Webhook struct {
+		Workers      int           `env:"WEBHOOK_WORKERS" env-default:"4"`
+		PollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" env-default:"1s"`
+		BatchSize    int           `env:"WEBHOOK_BATCH_SIZE" env-default:"50"`
+		Timeout      time.Duration `env:"WEBHOOK_TIMEOUT" env-default:"10s"`
+		MaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" env-default:"10"`
+		BackoffBase  time.Duration `env:"WEBHOOK_BACKOFF_BASE" env-default:"10s"`
+		BackoffMax   time.Duration `env:"WEBHOOK_BACKOFF_MAX" env-default:"1h"`
+		// Сколько хранить доставленные и dead доставки в журнале
+		Retention time.Duration `env:"WEBHOOK_RETENTION" env-default:"168h"`
+		// Разрешить адреса loopback, link-local и частных сетей, например для локальной разработки
+		AllowPrivateAddresses bool `env:"WEBHOOK_ALLOW_PRIVATE_ADDRESSES" env-default:"false"`
+	}
+
+	// Отправка запланированных сообщений. Повтор после n-й неудачной попытки через RetryDelay * n.
+	// После MaxAttempts попыток сообщение получает статус failed. BatchSize 0 отключает отправку на этом инстансе
+	Scheduler struct {
+		PollInterval time.Duration `env:"SCHEDULER_POLL_INTERVAL" env-default:"1s"`
+		BatchSize    int           `env:"SCHEDULER_BATCH_SIZE" env-default:"50"`
+		Lease        time.Duration `env:"SCHEDULER_LEASE" env-default:"1m"`
+		MaxAttempts  int           `env:"SCHEDULER_MAX_ATTEMPTS" env-default:"5"`
+		RetryDelay   time.Duration `env:"SCHEDULER_RETRY_DELAY" env-default:"30s"`
+	}
+
+	// Удаление сообщений по сроку хранения. DefaultDays действует в чатах с RetentionDays 0, 0 - хранить всегда.
+	// Сообщения удаляются пачками по BatchSize с паузой BatchPause. Interval 0 отключает удаление на этом инстансе
+	Retention struct {
+		DefaultDays int           `env:"RETENTION_DEFAULT_DAYS" env-default:"0"`
+		Interval    time.Duration `env:"RETENTION_INTERVAL" env-default:"1h"`
+		BatchSize   int           `env:"RETENTION_BATCH_SIZE" env-default:"1000"`
+		BatchPause  time.Duration `env:"RETENTION_BATCH_PAUSE" env-default:"100ms"`
+	}
+
+	// Удаление самоуничтожающихся сообщений. Истекшие сообщения скрываются сразу, а удаляются
+	// и рассылаются как message.expired раз в Interval пачками по BatchSize. Interval 0 отключает удаление на этом инстансе
+	Expiry struct {
+		Interval  time.Duration `env:"EXPIRY_INTERVAL" env-default:"1s"`
+		BatchSize int           `env:"EXPIRY_BATCH_SIZE" env-default:"500"`
+	}
+
+	Health struct {
+		CheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" env-default:"2s"`
+	}
 )
 
 // NewConfig returns app config.
@@ -16,8 +16,55 @@ const docTemplate = `{
     "basePath": "{{.BasePath}}",
     "paths": {
         "/chats": {
+            "get": {
+                "description": "Возвращает публичные групповые чаты, личные чаты не отображаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chats"
+                ],
+                "summary": "Список чатов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество чатов (по умолчанию 50, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ChatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Создает новый чат с указанным названием",
+                "description": "Создает новый чат с указанным названием. Пользователь из X-User-Id становится владельцем, для приватного чата он обязателен",
                 "consumes": [
                     "application/json"
                 ],
@@ -37,6 +84,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.ChatIn"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID создателя чата",
+                        "name": "X-User-Id",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -49,19 +108,25 @@ const docTemplate = `{
                     "400": {
                         "description": "Неверный запрос",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Для приватного чата не указан пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key использован для другого запроса",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.ProblemResponse"
                         }
                     }
                 }
@@ -69,7 +134,7 @@ const docTemplate = `{
         },
         "/chats/{chatId}": {
             "get": {
-                "description": "Получает информацию о чате со всеми сообщениями",
+                "description": "Получает информацию о чате со всеми сообщениями, кроме сообщений с истекшим TTL.\nПриватный и личный чат доступен только участникам",
                 "consumes": [
                     "application/json"
                 ],
@@ -87,6 +152,12 @@ const docTemplate = `{
                         "name": "chatId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя, нужен для приватных и личных чатов",
+                        "name": "X-User-Id",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -99,25 +170,31 @@ const docTemplate = `{
                     "400": {
                         "description": "Неверный запрос",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не участник чата",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.ProblemResponse"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Удаляет чат по указанному ID",
+                "description": "Удаляет чат по указанному ID. Групповой чат удаляет владелец или администратор, личный любой из участников.\nЧат без владельца (созданный без X-User-Id) может удалить любой, кому он доступен",
                 "consumes": [
                     "application/json"
                 ],
@@ -135,6 +212,12 @@ const docTemplate = `{
                         "name": "chatId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя, нужен для чатов с владельцем",
+                        "name": "X-User-Id",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -144,27 +227,31 @@ const docTemplate = `{
                     "400": {
                         "description": "Неверный запрос",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.ProblemResponse"
                         }
                     }
                 }
-            }
-        },
-        "/chats/{chatId}/messages": {
-            "post": {
-                "description": "Добавляет новое сообщение в указанный чат",
+            },
+            "patch": {
+                "description": "Изменяет переданные поля чата, менять чат может только его владелец или администратор.\nЧат без владельца (созданный без X-User-Id) может менять любой, кому он доступен.\nЕсли передан If-Match, изменение применяется только к указанной версии, без него применяется к последней",
                 "consumes": [
                     "application/json"
                 ],
@@ -174,7 +261,7 @@ const docTemplate = `{
                 "tags": [
                     "chats"
                 ],
-                "summary": "Добавить сообщение",
+                "summary": "Изменить чат",
                 "parameters": [
                     {
                         "type": "integer",
@@ -184,12 +271,24 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Данные сообщения",
-                        "name": "message",
+                        "type": "string",
+                        "description": "ETag версии чата",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя, нужен для чатов с владельцем",
+                        "name": "X-User-Id",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Изменяемые поля",
+                        "name": "chat",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.MessageIn"
+                            "$ref": "#/definitions/dto.ChatPatchIn"
                         }
                     }
                 ],
@@ -197,108 +296,2248 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.MessageResponse"
+                            "$ref": "#/definitions/dto.ChatResponse"
                         }
                     },
                     "400": {
                         "description": "Неверный запрос",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия чата изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.ProblemResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.ChatIn": {
-            "type": "object",
-            "properties": {
-                "Title": {
-                    "type": "string",
-                    "example": "Тестовый чат"
-                }
-            }
-        },
-        "dto.ChatResponse": {
-            "type": "object",
-            "properties": {
-                "CreatedAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
-                },
-                "Id": {
-                    "type": "integer",
-                    "example": 125216
-                },
-                "Title": {
-                    "type": "string",
-                    "example": "Тестовый чат"
-                }
-            }
         },
-        "dto.ChatWithMessagesResponse": {
-            "type": "object",
-            "properties": {
-                "CreatedAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
-                },
-                "Id": {
-                    "type": "integer",
-                    "example": 125216
-                },
-                "Title": {
-                    "type": "string",
-                    "example": "Тестовый чат"
-                },
-                "messages": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.MessageResponse"
+        "/chats/{chatId}/incoming-webhooks": {
+            "get": {
+                "description": "Возвращает входящие вебхуки чата без токенов",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "Список входящих вебхуков",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.IncomingWebhooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
                     }
                 }
-            }
-        },
-        "dto.MessageIn": {
-            "type": "object",
-            "properties": {
-                "Text": {
-                    "type": "string",
-                    "example": "Hello world!"
+            },
+            "post": {
+                "description": "Выдает токен, по которому внешняя система публикует сообщения в чат. Токен возвращается только в этом ответе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "Создать входящий вебхук",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры входящего вебхука",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.IncomingWebhookIn"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.IncomingWebhookCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
                 }
             }
         },
-        "dto.MessageResponse": {
-            "type": "object",
-            "properties": {
-                "ChatId": {
-                    "type": "integer",
-                    "example": 125216
-                },
-                "CreatedAt": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
-                },
-                "Id": {
-                    "type": "integer",
-                    "example": 125216
-                },
-                "Text": {
-                    "type": "string",
-                    "example": "Hello world!"
+        "/chats/{chatId}/incoming-webhooks/{webhookId}": {
+            "delete": {
+                "description": "Удаляет входящий вебхук, его токен сразу перестает действовать",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "Удалить входящий вебхук",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID входящего вебхука",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Входящий вебхук удален"
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат или входящий вебхук не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/invites": {
+            "get": {
+                "description": "Возвращает все приглашения чата, включая отозванные и истекшие",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Список приглашений",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.InvitesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает код приглашения в чат. Код возвращается только в этом ответе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Создать приглашение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры приглашения",
+                        "name": "invite",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.InviteIn"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.InviteCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/invites/{inviteId}": {
+            "delete": {
+                "description": "Отзывает приглашение, после этого его нельзя принять",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Отозвать приглашение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID приглашения",
+                        "name": "inviteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Приглашение отозвано"
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат или приглашение не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/messages": {
+            "post": {
+                "description": "Добавляет новое сообщение в указанный чат. Сообщение вида \"/name args\" выполняет слэш-команду, ответ с Ephemeral=true видит только отправитель.\nЕсли задан SendAt, сообщение планируется: нужен X-User-Id, ответ 202 с запланированным сообщением\nClientNonce тоже требует X-User-Id\nЕсли задан TtlSeconds, сообщение исчезает из чата через указанное время, подписчики получают message.expired",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chats"
+                ],
+                "summary": "Добавить сообщение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные сообщения",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MessageIn"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID отправителя, по нему действует медленный режим",
+                        "name": "X-User-Id",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MessageResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduledMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Для SendAt или ClientNonce не указан пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не участник приватного или личного чата или чат только для чтения",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key или ClientNonce использован для другого запроса",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов или включен медленный режим",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/scheduled-messages": {
+            "get": {
+                "description": "Возвращает еще не отправленные сообщения и напоминания пользователя в чате, ближайшие первыми",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Список запланированных сообщений",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID автора",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduledMessagesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/scheduled-messages/{scheduledId}": {
+            "delete": {
+                "description": "Удаляет сообщение, которое еще не отправлено",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Отменить запланированное сообщение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID запланированного сообщения",
+                        "name": "scheduledId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID автора",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сообщение отменено"
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сообщение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Сообщение уже отправляется",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Меняет текст или время отправки. Сообщение со статусом failed снова ставится в очередь",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Изменить запланированное сообщение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID запланированного сообщения",
+                        "name": "scheduledId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID автора",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменяемые поля",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduledMessagePatchIn"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduledMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сообщение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Сообщение уже отправляется",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/webhooks": {
+            "get": {
+                "description": "Возвращает вебхуки чата без секретов",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Список вебхуков",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Подписывает URL на события чата. Секрет подписи возвращается только в этом ответе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Создать вебхук",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры вебхука",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookIn"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/webhooks/{webhookId}": {
+            "delete": {
+                "description": "Удаляет вебхук, еще не доставленные события отменяются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Удалить вебхук",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID вебхука",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Вебхук удален"
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат или вебхук не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/webhooks/{webhookId}/deliveries": {
+            "get": {
+                "description": "Возвращает доставки событий вебхука, новые первыми",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Журнал доставок",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID вебхука",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Статус: pending, delivered или dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество доставок (по умолчанию 50, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDeliveriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат или вебхук не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{chatId}/webhooks/{webhookId}/deliveries/{deliveryId}/retry": {
+            "post": {
+                "description": "Возвращает в очередь доставку со статусом dead, счетчик попыток обнуляется",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Повторить доставку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID чата",
+                        "name": "chatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID вебхука",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID доставки",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца или администратора чата",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Чат, вебхук или доставка не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Доставка не в статусе dead",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dm/{userId}": {
+            "post": {
+                "description": "Возвращает личный чат текущего пользователя с указанным пользователем, создавая его при первом обращении",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "direct"
+                ],
+                "summary": "Личный чат",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID собеседника",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID текущего пользователя",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Чат уже существовал",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ChatResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Чат создан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ChatResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/hooks/{token}": {
+            "post": {
+                "description": "Публикует сообщение типа bot от имени вебхука. Принимает {\"text\": \"...\"} или совместимый со Slack формат с blocks и attachments, в том числе как поле payload формы. X-User-Id не нужен",
+                "consumes": [
+                    "application/json",
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "Сообщение через входящий вебхук",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен входящего вебхука",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Сообщение",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.IncomingMessageIn"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос или пустой текст",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Токен не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/invites/{code}/accept": {
+            "post": {
+                "description": "Добавляет текущего пользователя в чат с ролью из приглашения",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Принять приглашение",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Код приглашения",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID текущего пользователя",
+                        "name": "X-User-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь уже участник",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MemberResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Пользователь добавлен",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MemberResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не указан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Приглашение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Приглашение истекло, отозвано или исчерпано",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.ChatIn": {
+            "type": "object",
+            "properties": {
+                "AvatarUrl": {
+                    "type": "string",
+                    "example": "https://example.com/avatar.png"
+                },
+                "Description": {
+                    "type": "string",
+                    "example": "Обсуждение релиза"
+                },
+                "Private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Settings": {
+                    "$ref": "#/definitions/dto.ChatSettings"
+                },
+                "Title": {
+                    "type": "string",
+                    "example": "Тестовый чат"
+                }
+            }
+        },
+        "dto.ChatPatchIn": {
+            "type": "object",
+            "properties": {
+                "AvatarUrl": {
+                    "type": "string",
+                    "example": "https://example.com/avatar.png"
+                },
+                "Description": {
+                    "type": "string",
+                    "example": "Обсуждение релиза"
+                },
+                "Private": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "Settings": {
+                    "$ref": "#/definitions/dto.ChatSettingsPatch"
+                },
+                "Title": {
+                    "type": "string",
+                    "example": "Новое название"
+                }
+            }
+        },
+        "dto.ChatResponse": {
+            "type": "object",
+            "properties": {
+                "AvatarUrl": {
+                    "type": "string",
+                    "example": "https://example.com/avatar.png"
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "Description": {
+                    "type": "string",
+                    "example": "Обсуждение релиза"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "LegalHold": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Settings": {
+                    "$ref": "#/definitions/dto.ChatSettings"
+                },
+                "Title": {
+                    "type": "string",
+                    "example": "Тестовый чат"
+                },
+                "Type": {
+                    "type": "string",
+                    "example": "group"
+                },
+                "Version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.ChatSettings": {
+            "type": "object",
+            "properties": {
+                "ReadOnly": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "RetentionDays": {
+                    "description": "Срок хранения сообщений в днях: 0 - срок по умолчанию сервера, -1 - хранить всегда",
+                    "type": "integer",
+                    "minimum": -1,
+                    "example": 30
+                },
+                "SlowModeSeconds": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10
+                }
+            }
+        },
+        "dto.ChatSettingsPatch": {
+            "type": "object",
+            "properties": {
+                "ReadOnly": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "RetentionDays": {
+                    "type": "integer",
+                    "minimum": -1,
+                    "example": 30
+                },
+                "SlowModeSeconds": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10
+                }
+            }
+        },
+        "dto.ChatWithMessagesResponse": {
+            "type": "object",
+            "properties": {
+                "AvatarUrl": {
+                    "type": "string",
+                    "example": "https://example.com/avatar.png"
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "Description": {
+                    "type": "string",
+                    "example": "Обсуждение релиза"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "LegalHold": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Settings": {
+                    "$ref": "#/definitions/dto.ChatSettings"
+                },
+                "Title": {
+                    "type": "string",
+                    "example": "Тестовый чат"
+                },
+                "Type": {
+                    "type": "string",
+                    "example": "group"
+                },
+                "Version": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MessageResponse"
+                    }
+                }
+            }
+        },
+        "dto.ChatsResponse": {
+            "type": "object",
+            "properties": {
+                "chats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ChatResponse"
+                    }
+                }
+            }
+        },
+        "dto.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "too_long"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "Title"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be at most 255 characters"
+                }
+            }
+        },
+        "dto.IncomingAttachment": {
+            "type": "object",
+            "properties": {
+                "fallback": {
+                    "type": "string",
+                    "example": "Build #42 passed"
+                },
+                "pretext": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "All tests passed"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Build #42"
+                }
+            }
+        },
+        "dto.IncomingBlock": {
+            "type": "object",
+            "properties": {
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.IncomingTextObject"
+                    }
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.IncomingTextObject"
+                    }
+                },
+                "text": {
+                    "$ref": "#/definitions/dto.IncomingTextObject"
+                },
+                "type": {
+                    "description": "section, header, context и т.д. Блоки без текста пропускаются",
+                    "type": "string",
+                    "example": "section"
+                }
+            }
+        },
+        "dto.IncomingMessageIn": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.IncomingAttachment"
+                    }
+                },
+                "blocks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.IncomingBlock"
+                    }
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Build #42 passed"
+                }
+            }
+        },
+        "dto.IncomingTextObject": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "example": "*Build #42* passed"
+                },
+                "type": {
+                    "description": "plain_text или mrkdwn, разметка не преобразуется",
+                    "type": "string",
+                    "example": "mrkdwn"
+                }
+            }
+        },
+        "dto.IncomingWebhookCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "CreatedBy": {
+                    "type": "string",
+                    "example": "user-1"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "Name": {
+                    "type": "string",
+                    "example": "ci"
+                },
+                "Path": {
+                    "description": "Путь для публикации сообщений, POST без X-User-Id",
+                    "type": "string",
+                    "example": "/v1/hooks/Jk1QyQnP2hQm1oX3aE4pQm0yQm6m4bQ0y3hN2y8m2e0"
+                },
+                "Token": {
+                    "type": "string",
+                    "example": "Jk1QyQnP2hQm1oX3aE4pQm0yQm6m4bQ0y3hN2y8m2e0"
+                }
+            }
+        },
+        "dto.IncomingWebhookIn": {
+            "type": "object",
+            "properties": {
+                "Name": {
+                    "description": "Имя бота, от которого публикуются сообщения",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "ci"
+                }
+            }
+        },
+        "dto.IncomingWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "CreatedBy": {
+                    "type": "string",
+                    "example": "user-1"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "Name": {
+                    "type": "string",
+                    "example": "ci"
+                }
+            }
+        },
+        "dto.IncomingWebhooksResponse": {
+            "type": "object",
+            "properties": {
+                "webhooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.IncomingWebhookResponse"
+                    }
+                }
+            }
+        },
+        "dto.InviteCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "Code": {
+                    "type": "string",
+                    "example": "q2Lr0yQm6m4bQ0y3hN2y8m2e0Jk1QyQnP2hQm1oX3aE"
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "CreatedBy": {
+                    "type": "string",
+                    "example": "user-1"
+                },
+                "ExpiresAt": {
+                    "type": "string",
+                    "example": "2024-01-02T12:00:00Z"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "MaxUses": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "Revoked": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Role": {
+                    "type": "string",
+                    "example": "member"
+                },
+                "Uses": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "dto.InviteIn": {
+            "type": "object",
+            "properties": {
+                "ExpiresIn": {
+                    "description": "Время жизни приглашения в секундах, 0 - бессрочно",
+                    "type": "integer",
+                    "example": 86400
+                },
+                "MaxUses": {
+                    "description": "Максимальное количество использований, 0 - без ограничений",
+                    "type": "integer",
+                    "example": 10
+                },
+                "Role": {
+                    "description": "Роль, которую получит принявший приглашение: member или admin",
+                    "type": "string",
+                    "example": "member"
+                }
+            }
+        },
+        "dto.InviteResponse": {
+            "type": "object",
+            "properties": {
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "CreatedBy": {
+                    "type": "string",
+                    "example": "user-1"
+                },
+                "ExpiresAt": {
+                    "type": "string",
+                    "example": "2024-01-02T12:00:00Z"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "MaxUses": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "Revoked": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Role": {
+                    "type": "string",
+                    "example": "member"
+                },
+                "Uses": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "dto.InvitesResponse": {
+            "type": "object",
+            "properties": {
+                "invites": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.InviteResponse"
+                    }
+                }
+            }
+        },
+        "dto.MemberResponse": {
+            "type": "object",
+            "properties": {
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "JoinedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "Role": {
+                    "type": "string",
+                    "example": "member"
+                },
+                "UserId": {
+                    "type": "string",
+                    "example": "user-2"
+                }
+            }
+        },
+        "dto.MessageIn": {
+            "type": "object",
+            "properties": {
+                "ClientNonce": {
+                    "description": "Необязательный идентификатор сообщения на клиенте, возвращается в ответе и в событии SSE",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "7f9c2b1e-4d3a-4c8e-9b6f-2a1d0e5c8f3b"
+                },
+                "SendAt": {
+                    "description": "Время отправки. Если задано, сообщение планируется, а не отправляется сразу",
+                    "type": "string",
+                    "example": "2024-01-01T09:00:00Z"
+                },
+                "Text": {
+                    "type": "string",
+                    "example": "Hello world!"
+                },
+                "TtlSeconds": {
+                    "description": "Время жизни в секундах, не больше недели. После него сообщение исчезает из чата и удаляется",
+                    "type": "integer",
+                    "maximum": 604800,
+                    "minimum": 0,
+                    "example": 60
+                }
+            }
+        },
+        "dto.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "AuthorId": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "ClientNonce": {
+                    "type": "string",
+                    "example": "7f9c2b1e-4d3a-4c8e-9b6f-2a1d0e5c8f3b"
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "Ephemeral": {
+                    "description": "Ответ слэш-команды только для отправителя: не сохраняется, Id равен 0",
+                    "type": "boolean",
+                    "example": false
+                },
+                "ExpiresAt": {
+                    "description": "Время исчезновения сообщения с TtlSeconds",
+                    "type": "string",
+                    "example": "2024-01-01T12:01:00Z"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "Kind": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "Text": {
+                    "type": "string",
+                    "example": "Hello world!"
+                }
+            }
+        },
+        "dto.ProblemResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "chat_not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "chat not found"
+                },
+                "errors": {
+                    "description": "Ошибки отдельных полей для кода validation_failed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/v1/chats/125216/messages"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "traceId": {
+                    "description": "Идентификатор трассировки запроса, по нему ошибку можно найти в логах",
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "dto.ScheduledMessagePatchIn": {
+            "type": "object",
+            "properties": {
+                "SendAt": {
+                    "type": "string",
+                    "example": "2024-01-01T09:00:00Z"
+                },
+                "Text": {
+                    "type": "string",
+                    "example": "Release at 18:00"
+                }
+            }
+        },
+        "dto.ScheduledMessageResponse": {
+            "type": "object",
+            "properties": {
+                "Attempts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "AuthorId": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T08:00:00Z"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "Kind": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "LastError": {
+                    "type": "string",
+                    "example": "chat 125216 not found"
+                },
+                "SendAt": {
+                    "type": "string",
+                    "example": "2024-01-01T09:00:00Z"
+                },
+                "Status": {
+                    "description": "pending или failed. Отправленные сообщения удаляются из списка",
+                    "type": "string",
+                    "example": "pending"
+                },
+                "Text": {
+                    "type": "string",
+                    "example": "Release at 18:00"
+                }
+            }
+        },
+        "dto.ScheduledMessagesResponse": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ScheduledMessageResponse"
+                    }
+                }
+            }
+        },
+        "dto.WebhookCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "CreatedBy": {
+                    "type": "string",
+                    "example": "user-1"
+                },
+                "Events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "message.created"
+                    ]
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "Secret": {
+                    "type": "string",
+                    "example": "whsec_4pQm0yQm6m4bQ0y3hN2y8m2e0Jk1QyQnP2hQm1oX3aE"
+                },
+                "Url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/chat"
+                }
+            }
+        },
+        "dto.WebhookDeliveriesResponse": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                    }
+                }
+            }
+        },
+        "dto.WebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "Attempts": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "DeliveredAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:01Z"
+                },
+                "Event": {
+                    "type": "string",
+                    "example": "message.created"
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 1024
+                },
+                "LastError": {
+                    "type": "string",
+                    "example": "unexpected status 503"
+                },
+                "LastStatusCode": {
+                    "type": "integer",
+                    "example": 503
+                },
+                "NextAttemptAt": {
+                    "description": "Время следующей попытки, только для pending",
+                    "type": "string",
+                    "example": "2024-01-01T12:01:20Z"
+                },
+                "Status": {
+                    "description": "pending, delivered или dead",
+                    "type": "string",
+                    "example": "pending"
+                },
+                "WebhookId": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "dto.WebhookIn": {
+            "type": "object",
+            "properties": {
+                "Events": {
+                    "description": "Типы событий: message.created, message.deleted, message.expired, chat.updated, chat.deleted. Пустой список - все события",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "message.created"
+                    ]
+                },
+                "Url": {
+                    "description": "Адрес, на который отправляются события",
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://example.com/hooks/chat"
+                }
+            }
+        },
+        "dto.WebhookResponse": {
+            "type": "object",
+            "properties": {
+                "ChatId": {
+                    "type": "integer",
+                    "example": 125216
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "CreatedBy": {
+                    "type": "string",
+                    "example": "user-1"
+                },
+                "Events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "message.created"
+                    ]
+                },
+                "Id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "Url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/chat"
+                }
+            }
+        },
+        "dto.WebhooksResponse": {
+            "type": "object",
+            "properties": {
+                "webhooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.WebhookResponse"
+                    }
                 }
             }
         }
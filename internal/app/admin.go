@@ -0,0 +1,70 @@
+package app
+
+import (
+	"chat-project/config"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/services"
+	"chat-project/internal/storage/postgres"
+	redisStorage "chat-project/internal/storage/redis"
+	"chat-project/internal/validation"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewChatService создает ChatService с теми же хранилищами, что и serve, для admin команд.
+// События публикуются в redis, поэтому запущенные инстансы видят изменения.
+// Возвращаемую функцию нужно вызвать для закрытия соединений.
+func NewChatService(ctx context.Context, cfg *config.Config, l *slog.Logger) (*services.ChatService, func(), error) {
+	admin, closeAdmin, err := newAdminServices(ctx, cfg, l)
+	if err != nil {
+		return nil, nil, err
+	}
+	return admin.chats, closeAdmin, nil
+}
+
+// NewRetentionService создает RetentionService для admin команд так же, как NewChatService
+func NewRetentionService(ctx context.Context, cfg *config.Config, l *slog.Logger) (*services.RetentionService, func(), error) {
+	admin, closeAdmin, err := newAdminServices(ctx, cfg, l)
+	if err != nil {
+		return nil, nil, err
+	}
+	return admin.retention, closeAdmin, nil
+}
+
+type adminServices struct {
+	chats     *services.ChatService
+	retention *services.RetentionService
+}
+
+func newAdminServices(ctx context.Context, cfg *config.Config, l *slog.Logger) (*adminServices, func(), error) {
+	pgPool, err := pgxpool.New(ctx, cfg.Postgres.Url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create connection pool: %w", err)
+	}
+	if err := pgPool.Ping(ctx); err != nil {
+		pgPool.Close()
+		return nil, nil, fmt.Errorf("unable to connect to postgres: %w", err)
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	chatRepo := postgres.NewChatRepoPostgres(pgPool, l)
+	chatListener := redisStorage.NewListener(redisClient, l)
+	webhookRepo := postgres.NewWebhookRepoPostgres(pgPool, l)
+	scheduledRepo := postgres.NewScheduledMessageRepoPostgres(pgPool)
+	service := services.New(chatRepo, webhookRepo, scheduledRepo, chatListener, postgres.NewTxManagerPostgres(pgPool, l), nil, validation.New(cfg.Validation), ratelimit.NewRedis(redisClient), l, nil)
+	retentionService := services.NewRetentionService(chatRepo, postgres.NewRetentionRepoPostgres(pgPool), service, cfg.Retention, l, nil)
+
+	return &adminServices{chats: service, retention: retentionService}, func() {
+		redisClient.Close()
+		pgPool.Close()
+	}, nil
+}
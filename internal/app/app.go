@@ -2,30 +2,84 @@ package app
 
 import (
 	"chat-project/config"
+	adminRouter "chat-project/internal/controllers/admin"
+	healthRouter "chat-project/internal/controllers/health"
+	"chat-project/internal/controllers/middleware"
+	"chat-project/internal/controllers/problem"
 	"chat-project/internal/controllers/restapi"
 	"chat-project/internal/controllers/sse"
+	"chat-project/internal/expiry"
+	"chat-project/internal/health"
+	"chat-project/internal/logger"
+	"chat-project/internal/metrics"
+	"chat-project/internal/retention"
+	"chat-project/internal/scheduler"
 	"chat-project/internal/storage/postgres"
 	redisStorage "chat-project/internal/storage/redis"
 	"chat-project/internal/services"
+	"chat-project/internal/tracing"
+	"chat-project/internal/validation"
+	"chat-project/internal/webhook"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+// Время на отправку оставшихся спанов при остановке
+const _tracingShutdownTimeout = 5 * time.Second
+
 func Run(cfg *config.Config) {
-	r := gin.Default()
+	l, err := logger.New(cfg.Log)
+	if err != nil {
+		log.Fatalf("Logger error: %s", err)
+	}
+	// Стандартный log тоже пишет через slog
+	slog.SetDefault(l)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.App, os.Stdout)
+	if err != nil {
+		l.Error("unable to setup tracing", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), _tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			l.Error("error while shutting down tracing", slog.Any("error", err))
+		}
+	}()
+
+	if cfg.Postgres.AutoMigrate {
+		if err := migrateUp(cfg.Postgres, l); err != nil {
+			l.Error("unable to apply migrations", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
 	poolConfig, err := pgxpool.ParseConfig(cfg.Postgres.Url)
 	if err != nil {
-		log.Fatalln("Unable to parse DATABASE_URL:", err)
+		l.Error("unable to parse POSTGRES_URL", slog.Any("error", err))
+		os.Exit(1)
 	}
+	poolConfig.ConnConfig.Tracer = postgres.NewQueryTracer(l)
 
 	pgPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		log.Fatalln("Unable to create connection pool:", err)
+		l.Error("unable to create connection pool", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer pgPool.Close()
 
@@ -36,14 +90,193 @@ func Run(cfg *config.Config) {
 	})
 	defer redisClient.Close()
 
-	chatListener := redisStorage.NewListener(redisClient)
-	chatRepo := postgres.NewChatRepoPostgres(pgPool)
+	m := metrics.New()
+	m.Register(metrics.NewPgxPoolCollector(pgPool), metrics.NewRedisPoolCollector(redisClient))
+
+	chatListener := redisStorage.NewListener(redisClient, l)
+	chatRepo := postgres.NewChatRepoPostgres(pgPool, l)
+	inviteRepo := postgres.NewInviteRepoPostgres(pgPool, l)
+	webhookRepo := postgres.NewWebhookRepoPostgres(pgPool, l)
+	incomingRepo := postgres.NewIncomingWebhookRepoPostgres(pgPool)
+	scheduledRepo := postgres.NewScheduledMessageRepoPostgres(pgPool)
+	retentionRepo := postgres.NewRetentionRepoPostgres(pgPool)
+	expiredRepo := postgres.NewExpiredMessageRepoPostgres(pgPool)
+
+	limiter, err := newLimiter(cfg.RateLimit, redisClient)
+	if err != nil {
+		l.Error("unable to create rate limiter", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	registry, err := newCommands()
+	if err != nil {
+		l.Error("unable to register commands", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	validator := validation.New(cfg.Validation)
+	service := services.New(chatRepo, webhookRepo, scheduledRepo, chatListener, postgres.NewTxManagerPostgres(pgPool, l), registry, validator, limiter, l, m)
+	inviteService := services.NewInviteService(chatRepo, inviteRepo, l)
+	webhookService := services.NewWebhookService(chatRepo, webhookRepo, validator, cfg.Webhook, l)
+	incomingService := services.NewIncomingWebhookService(chatRepo, incomingRepo, service, validator, l)
+	retentionService := services.NewRetentionService(chatRepo, retentionRepo, service, cfg.Retention, l, m)
+	expiryService := services.NewExpiryService(expiredRepo, service, cfg.Expiry, l, m)
+	chatManager := services.NewChatListenerManager(chatRepo, chatListener, cfg.Listener, l, m)
+	defer chatManager.Close()
+	m.RegisterListeners(chatManager.ListenersCount)
+
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		webhook.NewDispatcher(webhookRepo, cfg.Webhook, l, m).Run(dispatcherCtx)
+	}()
+	// Начатые доставки сохраняют результат до закрытия пула postgres
+	defer func() {
+		stopDispatcher()
+		<-dispatcherDone
+	}()
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.New(scheduledRepo, service, cfg.Scheduler, l, m).Run(schedulerCtx)
+	}()
+	// Взятая пачка отправляется до закрытия пулов postgres и redis
+	defer func() {
+		stopScheduler()
+		<-schedulerDone
+	}()
+
+	retentionCtx, stopRetention := context.WithCancel(context.Background())
+	retentionDone := make(chan struct{})
+	go func() {
+		defer close(retentionDone)
+		retention.New(retentionService, cfg.Retention, l).Run(retentionCtx)
+	}()
+	// Остановка прерывает удаление между пачками, оставшиеся сообщения удалит следующий запуск
+	defer func() {
+		stopRetention()
+		<-retentionDone
+	}()
+
+	expiryCtx, stopExpiry := context.WithCancel(context.Background())
+	expiryDone := make(chan struct{})
+	go func() {
+		defer close(expiryDone)
+		expiry.New(expiryService, cfg.Expiry, l).Run(expiryCtx)
+	}()
+	defer func() {
+		stopExpiry()
+		<-expiryDone
+	}()
+
+	checker := health.New(cfg.Health.CheckTimeout)
+	checker.AddCheck("postgres", health.PostgresCheck(pgPool))
+	checker.AddCheck("redis", health.RedisCheck(redisClient))
+	checker.AddCheck("migrations", health.MigrationsCheck(pgPool))
 
-	service := services.New(chatRepo, chatListener)
-	chatManager := services.NewChatListenerManager(chatRepo, chatListener)
+	r := newEngine(l, m, cfg.App.Name)
+	rules, err := rateLimitRules(cfg.RateLimit)
+	if err != nil {
+		l.Error("invalid rate limit config", slog.Any("error", err))
+		os.Exit(1)
+	}
+	r.Use(middleware.RateLimit(limiter, l, m, rules...))
+	idempotencyStore, err := newIdempotencyStore(cfg.Idempotency, redisClient)
+	if err != nil {
+		l.Error("unable to create idempotency store", slog.Any("error", err))
+		os.Exit(1)
+	}
+	r.Use(middleware.Idempotency(idempotencyStore, cfg.Idempotency, l, idempotentRoutes...))
+	r.GET("/metrics", gin.WrapH(m.Handler()))
+	healthRouter.NewRouter(r, l, checker)
+	adminRouter.NewRouter(r, l, cfg.Admin.Token, chatManager)
+	restapi.NewRouter(r, l, service, inviteService, webhookService, incomingService)
+	sse.NewRouter(r, l, m, chatManager, service)
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("%s:%s", "0.0.0.0", cfg.HTTP.Port),
+		Handler: r,
+	}
+	// SSE соединения живут, пока открыт канал клиента: закрываем слушателей, чтобы Shutdown их дождался
+	srv.RegisterOnShutdown(chatManager.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		l.Info("starting http server", slog.String("addr", srv.Addr), slog.String("version", cfg.App.Version))
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			l.Error("http server stopped", slog.Any("error", err))
+		}
+		return
+	case <-ctx.Done():
+	}
+	// Повторный сигнал завершит процесс сразу
+	stop()
+
+	shutdown(l, srv, checker, cfg.HTTP)
+}
+
+// shutdown сначала переводит /readyz в failing, ждет, пока балансировщик снимет трафик,
+// затем останавливает сервер и дожидается активных запросов
+func shutdown(l *slog.Logger, srv *http.Server, checker *health.Health, cfg config.HTTP) {
+	l.Info("shutting down http server", slog.Duration("delay", cfg.ShutdownDelay))
+	checker.SetShuttingDown()
+	time.Sleep(cfg.ShutdownDelay)
 
-	restapi.NewRouter(r, service)
-	sse.NewRouter(r, chatManager)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		l.Error("http server shutdown error", slog.Any("error", err))
+		return
+	}
+	l.Info("http server stopped")
+}
 
-	r.Run(fmt.Sprintf("%s:%s", "0.0.0.0", cfg.HTTP.Port))
+func migrateUp(cfg config.Postgres, l *slog.Logger) error {
+	migrator, err := NewMigrator(cfg, l)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+	return migrator.Up()
+}
+
+// newEngine создает gin без стандартного логгера, логи запросов идут через slog
+func newEngine(l *slog.Logger, m *metrics.Metrics, serviceName string) *gin.Engine {
+	if l.Enabled(context.Background(), slog.LevelDebug) {
+		gin.SetMode(gin.DebugMode)
+		gin.DebugPrintFunc = func(format string, values ...any) {
+			l.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)), slog.String("component", "gin"))
+		}
+	} else {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	r := gin.New()
+	// Контекст запроса (отмена, request_id) доступен через *gin.Context в сервисах
+	r.ContextWithFallback = true
+	r.Use(
+		otelgin.Middleware(serviceName),
+		middleware.RedactSpanPath(),
+		middleware.RequestID(),
+		middleware.AccessLog(l),
+		middleware.Metrics(m),
+		gin.CustomRecoveryWithWriter(slog.NewLogLogger(l.Handler(), slog.LevelError).Writer(), func(c *gin.Context, _ any) {
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "internal server error")
+		}),
+	)
+	r.NoRoute(func(c *gin.Context) {
+		problem.Write(c, http.StatusNotFound, problem.CodeRouteNotFound, "route not found")
+	})
+	return r
 }
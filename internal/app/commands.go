@@ -0,0 +1,17 @@
+package app
+
+import (
+	"chat-project/internal/commands"
+)
+
+// newCommands регистрирует встроенные слэш-команды
+func newCommands() (*commands.Registry, error) {
+	registry := commands.NewRegistry()
+	err := registry.Register(
+		commands.NewHelp(registry),
+		commands.Topic{},
+		commands.Poll{},
+		commands.NewRemind(),
+	)
+	return registry, err
+}
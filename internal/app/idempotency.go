@@ -0,0 +1,27 @@
+package app
+
+import (
+	"chat-project/config"
+	"chat-project/internal/controllers/middleware"
+	"chat-project/internal/idempotency"
+	"fmt"
+	"net/http"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Маршруты, которые учитывают Idempotency-Key
+var idempotentRoutes = []middleware.Route{
+	{Method: http.MethodPost, Path: "/v1/chats/"},
+	{Method: http.MethodPost, Path: "/v1/chats/:chatId/messages"},
+}
+
+func newIdempotencyStore(cfg config.Idempotency, client *redis.Client) (idempotency.Store, error) {
+	switch cfg.Store {
+	case "redis":
+		return idempotency.NewRedis(client), nil
+	case "memory":
+		return idempotency.NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown idempotency store %q", cfg.Store)
+}
@@ -2,14 +2,18 @@ package app
 
 import (
 	"chat-project/config"
+	"chat-project/migrations"
 	"errors"
-	"log"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	// migrate tools
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
 const (
@@ -17,42 +21,131 @@ const (
 	_defaultTimeout  = time.Second
 )
 
-func init() {
-	cfg, err := config.NewConfig()
-	if err != nil {
-		log.Fatalf("Config error: %s", err)
-	}
+var NoMigrationsError = errors.New("no migrations applied")
 
+// Migrator применяет миграции из встроенного migrations.FS
+// или из POSTGRES_MIGRATE_PATH, если он задан
+type Migrator struct {
+	m   *migrate.Migrate
+	log *slog.Logger
+}
+
+func NewMigrator(cfg config.Postgres, log *slog.Logger) (*Migrator, error) {
 	var (
 		attempts = _defaultAttempts
 		m        *migrate.Migrate
+		err      error
 	)
 
 	for attempts > 0 {
-		m, err = migrate.New(cfg.Postgres.MigratePath, cfg.Postgres.Url)
+		m, err = newMigrate(cfg)
 		if err == nil {
 			break
 		}
 
-		log.Printf("Migrate: postgres is trying to connect, err %s, attempts left: %d", err, attempts)
+		log.Warn("migrate: postgres is trying to connect", slog.Any("error", err), slog.Int("attempts_left", attempts))
 		time.Sleep(_defaultTimeout)
 		attempts--
 	}
 
 	if err != nil {
-		log.Fatalf("Migrate: postgres connect error: %s", err)
+		return nil, fmt.Errorf("migrate: postgres connect error: %w", err)
+	}
+
+	return &Migrator{m: m, log: log}, nil
+}
+
+func newMigrate(cfg config.Postgres) (*migrate.Migrate, error) {
+	if cfg.MigratePath != "" {
+		return migrate.New(cfg.MigratePath, cfg.Url)
+	}
+
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithSourceInstance("iofs", source, cfg.Url)
+}
+
+func (m *Migrator) Close() {
+	if srcErr, dbErr := m.m.Close(); srcErr != nil || dbErr != nil {
+		m.log.Warn("migrate: close error", slog.Any("source_error", srcErr), slog.Any("database_error", dbErr))
+	}
+}
+
+// Up применяет все новые миграции
+func (m *Migrator) Up() error {
+	return m.done("up", m.m.Up())
+}
+
+// Down откатывает steps последних миграций
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("migrate: steps must be positive, got %d", steps)
+	}
+	return m.done("down", m.m.Steps(-steps))
+}
+
+// To мигрирует вверх или вниз до указанной версии
+func (m *Migrator) To(version uint) error {
+	return m.done("to", m.m.Migrate(version))
+}
+
+// Force устанавливает версию без применения миграций, чтобы снять dirty после ручного исправления
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("migrate: force error: %w", err)
 	}
+	m.log.Info("migrate: force success", slog.Int("version", version))
+	return nil
+}
 
-	err = m.Up()
-	defer m.Close()
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		log.Fatalf("Migrate: up error: %s", err)
+// Status возвращает текущую версию схемы
+func (m *Migrator) Status() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, NoMigrationsError
 	}
+	if err != nil {
+		return 0, false, fmt.Errorf("migrate: version error: %w", err)
+	}
+	return version, dirty, nil
+}
 
+func (m *Migrator) done(command string, err error) error {
 	if errors.Is(err, migrate.ErrNoChange) {
-		log.Printf("Migrate: no change")
-		return
+		m.log.Info("migrate: no change", slog.String("command", command))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: %s error: %w", command, err)
 	}
 
-	log.Printf("Migrate: up success")
+	version, dirty, _ := m.m.Version()
+	m.log.Info("migrate: success", slog.String("command", command), slog.Uint64("version", uint64(version)), slog.Bool("dirty", dirty))
+	return nil
+}
+
+// LatestMigration возвращает версию последней встроенной миграции
+func LatestMigration() (uint, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	version, err := source.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
 }
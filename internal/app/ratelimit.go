@@ -0,0 +1,63 @@
+package app
+
+import (
+	"chat-project/config"
+	"chat-project/internal/controllers/middleware"
+	"chat-project/internal/ratelimit"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func newLimiter(cfg config.RateLimit, client *redis.Client) (ratelimit.Limiter, error) {
+	switch cfg.Store {
+	case "redis":
+		return ratelimit.NewRedis(client), nil
+	case "memory":
+		return ratelimit.NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+}
+
+// rateLimitRules правила лимитов для /v1 и /sse. Admin, health и метрики не ограничиваются
+func rateLimitRules(cfg config.RateLimit) ([]middleware.RateLimitRule, error) {
+	var clientKey middleware.KeyFunc
+	switch cfg.ClientKey {
+	case "ip":
+		clientKey = middleware.ByIP
+	case "user":
+		clientKey = middleware.ByUser
+	default:
+		return nil, fmt.Errorf("unknown rate limit client key %q", cfg.ClientKey)
+	}
+
+	var errs []error
+	parse := func(env, value string) ratelimit.Rate {
+		rate, err := ratelimit.ParseRate(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", env, err))
+		}
+		return rate
+	}
+
+	const messagesRoute = "/v1/chats/:chatId/messages"
+	rules := []middleware.RateLimitRule{
+		{Name: "api", Route: "/v1/", Rate: parse("RATE_LIMIT_API", cfg.API), Key: clientKey},
+		{Name: "messages", Method: http.MethodPost, Route: messagesRoute, Rate: parse("RATE_LIMIT_MESSAGES_PER_CLIENT", cfg.MessagesPerClient), Key: clientKey},
+		{Name: "chat_messages", Method: http.MethodPost, Route: messagesRoute, Rate: parse("RATE_LIMIT_MESSAGES_PER_CHAT", cfg.MessagesPerChat), Key: middleware.ByChat},
+		{Name: "incoming_webhook", Method: http.MethodPost, Route: "/v1/hooks/", Rate: parse("RATE_LIMIT_INCOMING_WEBHOOK", cfg.IncomingWebhook), Key: middleware.ByHookToken},
+		{Name: "sse", Method: http.MethodGet, Route: "/sse/", Rate: parse("RATE_LIMIT_SSE_PER_CLIENT", cfg.SSEPerClient), Key: clientKey},
+	}
+	// X-User-Id задает клиент: без общего лимита на адрес новый заголовок давал бы новые корзины.
+	// Оба правила называются address, поэтому /v1 и /sse расходуют одну корзину адреса
+	if cfg.ClientKey == "user" {
+		address := parse("RATE_LIMIT_ADDRESS", cfg.Address)
+		rules = append(rules,
+			middleware.RateLimitRule{Name: "address", Route: "/v1/", Rate: address, Key: middleware.ByIP},
+			middleware.RateLimitRule{Name: "address", Route: "/sse/", Rate: address, Key: middleware.ByIP},
+		)
+	}
+	return rules, errors.Join(errs...)
+}
@@ -0,0 +1,450 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"chat-project/internal/app"
+	"chat-project/internal/dto"
+	"chat-project/internal/services"
+)
+
+const _adminRequestTimeout = 10 * time.Second
+
+// Сервисы admin команд создаются через эти функции, тесты подменяют их сервисами поверх хранилищ в памяти
+var (
+	newChatService      = app.NewChatService
+	newRetentionService = app.NewRetentionService
+)
+
+func adminCommand(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected chats, messages, retention or listeners")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch args[0] {
+	case "chats":
+		return adminChats(ctx, args[1:], stdout)
+	case "messages":
+		return adminMessages(ctx, args[1:], stdout)
+	case "retention":
+		return adminRetention(ctx, args[1:], stdout)
+	case "listeners":
+		return adminListeners(ctx, args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown admin command %q", args[0])
+	}
+}
+
+// withService загружает конфиг и создает ChatService на время выполнения команды
+func withService(ctx context.Context, fn func(service *services.ChatService) error) error {
+	cfg, l, err := load()
+	if err != nil {
+		return err
+	}
+
+	service, closeService, err := newChatService(ctx, cfg, l)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	return fn(service)
+}
+
+// withRetention как withService, но создает RetentionService
+func withRetention(ctx context.Context, fn func(service *services.RetentionService) error) error {
+	cfg, l, err := load()
+	if err != nil {
+		return err
+	}
+
+	service, closeService, err := newRetentionService(ctx, cfg, l)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	return fn(service)
+}
+
+// parseFlags разбирает флаги и проверяет количество позиционных аргументов,
+// maxArgs -1 - без ограничения
+func parseFlags(fs *flag.FlagSet, output *string, args []string, minArgs, maxArgs int, usage string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := checkOutput(*output); err != nil {
+		return nil, err
+	}
+	if fs.NArg() < minArgs || maxArgs >= 0 && fs.NArg() > maxArgs {
+		return nil, fmt.Errorf("usage: %s", usage)
+	}
+	return fs.Args(), nil
+}
+
+func parseChatId(s string) (int, error) {
+	chatId, err := strconv.Atoi(s)
+	if err != nil || chatId <= 0 {
+		return 0, fmt.Errorf("invalid chat id %q", s)
+	}
+	return chatId, nil
+}
+
+func adminChats(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected list, show or delete")
+	}
+
+	switch args[0] {
+	case "list":
+		fs, output := newFlagSet("admin chats list")
+		limit := fs.Int("limit", dto.DefaultPageLimit, "max chats to show")
+		offset := fs.Int("offset", 0, "chats to skip")
+		if _, err := parseFlags(fs, output, args[1:], 0, 0, "admin chats list [-limit N] [-offset N] [-o table|json]"); err != nil {
+			return err
+		}
+		if *limit <= 0 || *offset < 0 {
+			return errors.New("limit must be positive and offset non-negative")
+		}
+		return withService(ctx, func(service *services.ChatService) error {
+			chats, err := service.ListAllChats(ctx, *limit, *offset)
+			if err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, chats)
+			}
+			rows := make([][]string, 0, len(chats.Chats))
+			for _, chat := range chats.Chats {
+				rows = append(rows, []string{
+					strconv.Itoa(chat.ID), chat.Type, chat.Title, strconv.FormatBool(chat.Private), strconv.Itoa(chat.Version), chat.CreatedAt,
+				})
+			}
+			return writeTable(stdout, []string{"ID", "TYPE", "TITLE", "PRIVATE", "VERSION", "CREATED"}, rows)
+		})
+
+	case "show":
+		fs, output := newFlagSet("admin chats show")
+		rest, err := parseFlags(fs, output, args[1:], 1, 1, "admin chats show [-o table|json] CHAT_ID")
+		if err != nil {
+			return err
+		}
+		chatId, err := parseChatId(rest[0])
+		if err != nil {
+			return err
+		}
+		return withService(ctx, func(service *services.ChatService) error {
+			chat, err := service.AdminGetWithMessages(ctx, chatId)
+			if err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, chat)
+			}
+			err = writeTable(stdout, []string{"FIELD", "VALUE"}, [][]string{
+				{"ID", strconv.Itoa(chat.ID)},
+				{"TYPE", chat.Type},
+				{"TITLE", chat.Title},
+				{"PRIVATE", strconv.FormatBool(chat.Private)},
+				{"DESCRIPTION", chat.Description},
+				{"VERSION", strconv.Itoa(chat.Version)},
+				{"CREATED", chat.CreatedAt},
+				{"MESSAGES", strconv.Itoa(len(chat.Messages))},
+			})
+			if err != nil || len(chat.Messages) == 0 {
+				return err
+			}
+			fmt.Fprintln(stdout)
+			return writeMessages(stdout, chat.Messages)
+		})
+
+	case "delete":
+		fs, output := newFlagSet("admin chats delete")
+		rest, err := parseFlags(fs, output, args[1:], 1, 1, "admin chats delete CHAT_ID")
+		if err != nil {
+			return err
+		}
+		chatId, err := parseChatId(rest[0])
+		if err != nil {
+			return err
+		}
+		return withService(ctx, func(service *services.ChatService) error {
+			if err := service.AdminDeleteChat(ctx, chatId); err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, map[string]int{"deleted": chatId})
+			}
+			fmt.Fprintf(stdout, "chat %d deleted\n", chatId)
+			return nil
+		})
+
+	default:
+		return fmt.Errorf("unknown admin chats command %q", args[0])
+	}
+}
+
+func writeMessages(w io.Writer, messages []dto.MessageResponse) error {
+	rows := make([][]string, 0, len(messages))
+	for _, msg := range messages {
+		rows = append(rows, []string{strconv.Itoa(msg.ID), msg.Kind, msg.CreatedAt, msg.Text})
+	}
+	return writeTable(w, []string{"ID", "KIND", "CREATED", "TEXT"}, rows)
+}
+
+func adminMessages(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected post or purge")
+	}
+
+	switch args[0] {
+	case "post":
+		fs, output := newFlagSet("admin messages post")
+		rest, err := parseFlags(fs, output, args[1:], 2, -1, "admin messages post [-o table|json] CHAT_ID TEXT...")
+		if err != nil {
+			return err
+		}
+		chatId, err := parseChatId(rest[0])
+		if err != nil {
+			return err
+		}
+		text := strings.Join(rest[1:], " ")
+		return withService(ctx, func(service *services.ChatService) error {
+			msg, err := service.AddSystemMessage(ctx, chatId, text)
+			if err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, msg)
+			}
+			return writeMessages(stdout, []dto.MessageResponse{*msg})
+		})
+
+	case "purge":
+		fs, output := newFlagSet("admin messages purge")
+		before := fs.String("before", "", "purge messages created before this date (2006-01-02 or RFC3339)")
+		chat := fs.Int("chat", 0, "purge only this chat")
+		all := fs.Bool("all", false, "purge in all chats, required without -chat")
+		if _, err := parseFlags(fs, output, args[1:], 0, 0, "admin messages purge -before DATE (-chat CHAT_ID | -all) [-o table|json]"); err != nil {
+			return err
+		}
+		cutoff, err := parseDate(*before)
+		if err != nil {
+			return err
+		}
+		if *chat == 0 && !*all {
+			return errors.New("pass -chat CHAT_ID or -all to purge messages in all chats")
+		}
+		return withRetention(ctx, func(service *services.RetentionService) error {
+			purged, err := service.PurgeMessages(ctx, *chat, cutoff)
+			if err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, map[string]any{"purged": purged, "before": cutoff.Format(time.RFC3339)})
+			}
+			fmt.Fprintf(stdout, "%d messages purged\n", purged)
+			return nil
+		})
+
+	default:
+		return fmt.Errorf("unknown admin messages command %q", args[0])
+	}
+}
+
+func adminRetention(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected report, purge, set, hold or release")
+	}
+
+	switch args[0] {
+	case "report":
+		fs, output := newFlagSet("admin retention report")
+		if _, err := parseFlags(fs, output, args[1:], 0, 0, "admin retention report [-o table|json]"); err != nil {
+			return err
+		}
+		return withRetention(ctx, func(service *services.RetentionService) error {
+			report, err := service.Report(ctx)
+			if err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, report)
+			}
+			rows := make([][]string, 0, len(report.Chats))
+			for _, item := range report.Chats {
+				days := "forever"
+				if item.RetentionDays > 0 {
+					days = strconv.Itoa(item.RetentionDays)
+				}
+				rows = append(rows, []string{
+					strconv.Itoa(item.ChatId), item.Title, days, item.Source, strconv.FormatBool(item.LegalHold), item.Before, strconv.FormatInt(item.Expired, 10),
+				})
+			}
+			if err := writeTable(stdout, []string{"ID", "TITLE", "DAYS", "SOURCE", "HOLD", "BEFORE", "EXPIRED"}, rows); err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "\n%d messages would be deleted\n", report.Expired)
+			return nil
+		})
+
+	case "purge":
+		fs, output := newFlagSet("admin retention purge")
+		if _, err := parseFlags(fs, output, args[1:], 0, 0, "admin retention purge [-o table|json]"); err != nil {
+			return err
+		}
+		return withRetention(ctx, func(service *services.RetentionService) error {
+			purged, err := service.PurgeExpired(ctx)
+			if err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, map[string]int64{"purged": purged})
+			}
+			fmt.Fprintf(stdout, "%d messages purged\n", purged)
+			return nil
+		})
+
+	case "set":
+		fs, output := newFlagSet("admin retention set")
+		rest, err := parseFlags(fs, output, args[1:], 2, 2, "admin retention set [-o table|json] CHAT_ID DAYS")
+		if err != nil {
+			return err
+		}
+		chatId, err := parseChatId(rest[0])
+		if err != nil {
+			return err
+		}
+		days, err := strconv.Atoi(rest[1])
+		if err != nil {
+			return fmt.Errorf("invalid DAYS %q: 0 - server default, -1 - keep forever", rest[1])
+		}
+		return withRetention(ctx, func(service *services.RetentionService) error {
+			chat, err := service.SetRetentionDays(ctx, chatId, days)
+			if err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, chat)
+			}
+			fmt.Fprintf(stdout, "retention of chat %d set to %d days\n", chatId, days)
+			return nil
+		})
+
+	case "hold", "release":
+		hold := args[0] == "hold"
+		fs, output := newFlagSet("admin retention " + args[0])
+		rest, err := parseFlags(fs, output, args[1:], 1, 1, "admin retention "+args[0]+" [-o table|json] CHAT_ID")
+		if err != nil {
+			return err
+		}
+		chatId, err := parseChatId(rest[0])
+		if err != nil {
+			return err
+		}
+		return withRetention(ctx, func(service *services.RetentionService) error {
+			chat, err := service.SetLegalHold(ctx, chatId, hold)
+			if err != nil {
+				return err
+			}
+			if *output == outputJSON {
+				return writeJSON(stdout, chat)
+			}
+			if hold {
+				fmt.Fprintf(stdout, "chat %d is on legal hold, its messages are not purged\n", chatId)
+			} else {
+				fmt.Fprintf(stdout, "legal hold of chat %d released\n", chatId)
+			}
+			return nil
+		})
+
+	default:
+		return fmt.Errorf("unknown admin retention command %q", args[0])
+	}
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, errors.New("-before is required")
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected 2006-01-02 or RFC3339", s)
+	}
+	return t, nil
+}
+
+// adminListeners запрашивает статистику слушателей у запущенного инстанса
+func adminListeners(ctx context.Context, args []string, stdout io.Writer) error {
+	fs, output := newFlagSet("admin listeners")
+	addr := fs.String("addr", "", "base url of running instance, default http://localhost:$HTTP_PORT")
+	if _, err := parseFlags(fs, output, args, 0, 0, "admin listeners [-addr URL] [-o table|json]"); err != nil {
+		return err
+	}
+
+	cfg, _, err := load()
+	if err != nil {
+		return err
+	}
+	if cfg.Admin.Token == "" {
+		return errors.New("ADMIN_TOKEN is not set")
+	}
+	baseURL := *addr
+	if baseURL == "" {
+		baseURL = "http://localhost:" + cfg.HTTP.Port
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, _adminRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/admin/listeners", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.Admin.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error while requesting listeners: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var listeners dto.ListenersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listeners); err != nil {
+		return fmt.Errorf("error while decoding listeners: %w", err)
+	}
+
+	if *output == outputJSON {
+		return writeJSON(stdout, listeners)
+	}
+	rows := make([][]string, 0, len(listeners.Chats))
+	for _, chat := range listeners.Chats {
+		rows = append(rows, []string{strconv.Itoa(chat.ChatId), strconv.Itoa(chat.Clients), strconv.Itoa(chat.Refs)})
+	}
+	if err := writeTable(stdout, []string{"CHAT", "CLIENTS", "REFS"}, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "\nlisteners: %d, clients: %d\n", listeners.Listeners, listeners.Clients)
+	return nil
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/config"
+	"chat-project/internal/controllers/admin"
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/services"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+// useMemoryServices подменяет сервисы admin команд сервисами поверх хранилищ в памяти
+func useMemoryServices(t *testing.T) (*services.ChatService, *memory.ChatRepoMemory) {
+	t.Helper()
+	// Конфиг читается так же, как в serve, поэтому нужен обязательный POSTGRES_URL
+	t.Setenv("POSTGRES_URL", "postgres://unused")
+
+	log := logger.Discard()
+	repo := memory.NewUserRepoMemory()
+	chats := services.New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), log, nil)
+	retention := services.NewRetentionService(repo, memory.NewRetentionRepoMemory(repo), chats, config.Retention{BatchSize: 100}, log, nil)
+
+	prevChats, prevRetention := newChatService, newRetentionService
+	t.Cleanup(func() { newChatService, newRetentionService = prevChats, prevRetention })
+	newChatService = func(context.Context, *config.Config, *slog.Logger) (*services.ChatService, func(), error) {
+		return chats, func() {}, nil
+	}
+	newRetentionService = func(context.Context, *config.Config, *slog.Logger) (*services.RetentionService, func(), error) {
+		return retention, func() {}, nil
+	}
+	return chats, repo
+}
+
+func TestAdminCommands(t *testing.T) {
+	chats, _ := useMemoryServices(t)
+	ctx := context.Background()
+
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "general"}, "alice")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if _, err := chats.AddMessage(ctx, chat.ID, services.Sender{UserId: "alice"}, dto.MessageIn{Text: "hello"}); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	id := strconv.Itoa(chat.ID)
+
+	// Шаги выполняются по порядку и видят изменения предыдущих
+	tests := []struct {
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{args: []string{}, wantErr: "expected chats, messages, retention or listeners"},
+		{args: []string{"users"}, wantErr: `unknown admin command "users"`},
+		{args: []string{"chats", "list"}, want: []string{"ID", "TITLE", id, "general"}},
+		{args: []string{"chats", "list", "-o", "json"}, want: []string{`"Title": "general"`}},
+		{args: []string{"chats", "list", "-limit", "0"}, wantErr: "limit must be positive"},
+		{args: []string{"chats", "list", "extra"}, wantErr: "usage: admin chats list"},
+		{args: []string{"chats", "list", "-o", "xml"}, wantErr: `invalid output "xml"`},
+		{args: []string{"chats", "show", id}, want: []string{"TITLE", "general", "MESSAGES", "hello"}},
+		{args: []string{"chats", "show", "abc"}, wantErr: `invalid chat id "abc"`},
+		{args: []string{"chats", "show"}, wantErr: "usage: admin chats show"},
+		{args: []string{"chats", "rename", id}, wantErr: `unknown admin chats command "rename"`},
+		{args: []string{"messages", "post", id, "maintenance", "tonight"}, want: []string{"system", "maintenance tonight"}},
+		{args: []string{"messages", "post", id}, wantErr: "usage: admin messages post"},
+		{args: []string{"messages", "purge", "-chat", id}, wantErr: "-before is required"},
+		{args: []string{"messages", "purge", "-before", "2999-01-01"}, wantErr: "pass -chat CHAT_ID or -all"},
+		{args: []string{"messages", "purge", "-before", "yesterday", "-all"}, wantErr: `invalid date "yesterday"`},
+		{args: []string{"messages", "purge", "-before", "2999-01-01", "-chat", id}, want: []string{"2 messages purged"}},
+		{args: []string{"messages", "purge", "-before", "2999-01-01T00:00:00Z", "-all", "-o", "json"}, want: []string{`"purged": 0`, `"before": "2999-01-01T00:00:00Z"`}},
+		{args: []string{"chats", "delete", id, "extra"}, wantErr: "usage: admin chats delete"},
+		{args: []string{"chats", "delete", "-o", "json", id}, want: []string{`"deleted": ` + id}},
+		{args: []string{"chats", "show", id}, wantErr: "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := adminCommand(tt.args, &stdout)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Fatalf("expected %q in output:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestAdminListeners(t *testing.T) {
+	_, repo := useMemoryServices(t)
+	gin.SetMode(gin.TestMode)
+
+	manager := services.NewChatListenerManager(repo, memory.NewListenerMemory(), config.Listener{GracePeriod: time.Minute}, logger.Discard(), nil)
+	t.Cleanup(manager.Close)
+	r := gin.New()
+	admin.NewRouter(r, logger.Discard(), "secret", manager)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name    string
+		token   string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "table", token: "secret", args: []string{"listeners", "-addr", server.URL}, want: "listeners: 0, clients: 0"},
+		{name: "json", token: "secret", args: []string{"listeners", "-addr", server.URL, "-o", "json"}, want: `"listeners": 0`},
+		{name: "wrong token", token: "guess", args: []string{"listeners", "-addr", server.URL}, wantErr: "unexpected status 401"},
+		{name: "no token", args: []string{"listeners", "-addr", server.URL}, wantErr: "ADMIN_TOKEN is not set"},
+		{name: "arguments", token: "secret", args: []string{"listeners", "extra"}, wantErr: "usage: admin listeners"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_TOKEN", tt.token)
+			var stdout bytes.Buffer
+			err := adminCommand(tt.args, &stdout)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Fatalf("expected %q in output:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+}
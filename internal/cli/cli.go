@@ -0,0 +1,93 @@
+// Package cli разбирает подкоманды бинарника: serve, migrate, admin и version
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"chat-project/config"
+	"chat-project/internal/logger"
+)
+
+// Version задается при сборке: -ldflags "-X chat-project/internal/cli.Version=1.2.3"
+var Version = "dev"
+
+const usage = `Usage: app <command> [arguments]
+
+Commands:
+  serve                 start http server (default)
+  migrate up            apply all new migrations
+  migrate down [N]      roll back N migrations (default 1)
+  migrate to N          migrate up or down to version N
+  migrate status        show current schema version
+  migrate force N       set version N without running migrations and clear dirty flag
+  admin chats list      list all chats [-limit N] [-offset N]
+  admin chats show ID   show chat with last messages
+  admin chats delete ID delete chat and disconnect its subscribers
+  admin messages post ID TEXT...
+                        post a system message
+  admin messages purge -before DATE (-chat ID | -all)
+                        delete messages created before DATE
+  admin retention report
+                        dry run: messages the retention policy would delete
+  admin retention purge delete messages older than the retention policy now
+  admin retention set ID DAYS
+                        set retention of chat: 0 server default, -1 forever
+  admin retention hold ID
+                        put chat on legal hold, its messages are never purged
+  admin retention release ID
+                        release legal hold of chat
+  admin listeners       listener and SSE client counts of a running instance [-addr URL]
+  version               show build and schema version
+
+Admin commands accept -o table|json before positional arguments.
+`
+
+type command func(args []string, stdout io.Writer) error
+
+var commands = map[string]command{
+	"serve":   serve,
+	"migrate": migrateCommand,
+	"admin":   adminCommand,
+	"version": version,
+}
+
+// Run выполняет подкоманду и возвращает код выхода процесса
+func Run(args []string, stdout, stderr io.Writer) int {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" || name == "-h" || name == "--help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+
+	if err := cmd(args, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// load читает конфиг так же, как serve, и создает логгер
+func load() (*config.Config, *slog.Logger, error) {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("config error: %w", err)
+	}
+
+	l, err := logger.New(cfg.Log)
+	if err != nil {
+		return nil, nil, fmt.Errorf("logger error: %w", err)
+	}
+	return cfg, l, nil
+}
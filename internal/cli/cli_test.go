@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"chat-project/config"
+	"chat-project/internal/app"
+)
+
+// fakeMigrator записывает вызванные операции и возвращает заданное состояние схемы
+type fakeMigrator struct {
+	calls   []string
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (m *fakeMigrator) record(format string, args ...any) error {
+	m.calls = append(m.calls, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (m *fakeMigrator) Up() error               { return m.record("up") }
+func (m *fakeMigrator) Down(steps int) error    { return m.record("down %d", steps) }
+func (m *fakeMigrator) To(version uint) error   { return m.record("to %d", version) }
+func (m *fakeMigrator) Force(version int) error { return m.record("force %d", version) }
+func (m *fakeMigrator) Close()                  {}
+
+func (m *fakeMigrator) Status() (uint, bool, error) {
+	m.calls = append(m.calls, "status")
+	return m.version, m.dirty, m.err
+}
+
+func useFakeMigrator(t *testing.T, m *fakeMigrator) *bool {
+	t.Helper()
+	t.Setenv("POSTGRES_URL", "postgres://unused")
+	connected := false
+	prev := newMigrator
+	t.Cleanup(func() { newMigrator = prev })
+	newMigrator = func(config.Postgres, *slog.Logger) (migrator, error) {
+		connected = true
+		return m, nil
+	}
+	return &connected
+}
+
+func TestRunDispatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{name: "help", args: []string{"help"}, code: 0, stdout: "Usage: app <command>"},
+		{name: "help flag", args: []string{"--help"}, code: 0, stdout: "admin retention set ID DAYS"},
+		{name: "unknown command", args: []string{"frobnicate"}, code: 2, stderr: `unknown command "frobnicate"`},
+		{name: "usage after unknown command", args: []string{"frobnicate"}, code: 2, stderr: "Usage: app <command>"},
+		{name: "serve arguments", args: []string{"serve", "now"}, code: 1, stderr: "unexpected arguments [now]"},
+		{name: "migrate without subcommand", args: []string{"migrate"}, code: 1, stderr: "expected up, down, to, status or force"},
+		{name: "admin without subcommand", args: []string{"admin"}, code: 1, stderr: "expected chats, messages, retention or listeners"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := Run(tt.args, &stdout, &stderr); code != tt.code {
+				t.Fatalf("expected exit code %d, got %d, stderr: %s", tt.code, code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) || !strings.Contains(stderr.String(), tt.stderr) {
+				t.Fatalf("unexpected output\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+			}
+		})
+	}
+}
+
+func TestMigrateArguments(t *testing.T) {
+	tests := []struct {
+		args    []string
+		call    string
+		wantErr string
+	}{
+		{args: []string{"up"}, call: "up"},
+		{args: []string{"up", "2"}, wantErr: "usage: migrate up"},
+		{args: []string{"down"}, call: "down 1"},
+		{args: []string{"down", "3"}, call: "down 3"},
+		{args: []string{"down", "abc"}, wantErr: `invalid steps "abc"`},
+		{args: []string{"down", "0"}, wantErr: `invalid steps "0"`},
+		{args: []string{"down", "-1"}, wantErr: `invalid steps "-1"`},
+		{args: []string{"down", "1", "2"}, wantErr: "usage: migrate down [N]"},
+		{args: []string{"to", "5"}, call: "to 5"},
+		{args: []string{"to"}, wantErr: "usage: migrate to N"},
+		{args: []string{"to", "-5"}, wantErr: `invalid version "-5"`},
+		{args: []string{"force", "4"}, call: "force 4"},
+		{args: []string{"force", "x"}, wantErr: `invalid version "x"`},
+		{args: []string{"status", "now"}, wantErr: "usage: migrate status"},
+		{args: []string{"sideways"}, wantErr: `unknown migrate command "sideways"`},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			m := &fakeMigrator{}
+			connected := useFakeMigrator(t, m)
+
+			err := migrateCommand(tt.args, &bytes.Buffer{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				// Неверные аргументы отклоняются до подключения к базе
+				if *connected {
+					t.Fatal("migrator must not be created for invalid arguments")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(m.calls) != 1 || m.calls[0] != tt.call {
+				t.Fatalf("expected %q, got %v", tt.call, m.calls)
+			}
+		})
+	}
+}
+
+func TestMigrateStatus(t *testing.T) {
+	latest, err := app.LatestMigration()
+	if err != nil {
+		t.Fatalf("latest migration: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		migrator *fakeMigrator
+		want     string
+	}{
+		{"empty schema", &fakeMigrator{err: app.NoMigrationsError}, fmt.Sprintf("version: none\nlatest: %d\n", latest)},
+		{"up to date", &fakeMigrator{version: latest}, fmt.Sprintf("version: %d\ndirty: false\nlatest: %d\n", latest, latest)},
+		{"pending", &fakeMigrator{version: latest - 1, dirty: true}, fmt.Sprintf("version: %d\ndirty: true\nlatest: %d\npending: 1\n", latest-1, latest)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeMigrator(t, tt.migrator)
+			var stdout bytes.Buffer
+			if err := migrateCommand([]string{"status"}, &stdout); err != nil {
+				t.Fatalf("status: %v", err)
+			}
+			if stdout.String() != tt.want {
+				t.Fatalf("expected output\n%s\ngot\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	var stdout bytes.Buffer
+	if code := Run([]string{"version"}, &stdout, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	latest, err := app.LatestMigration()
+	if err != nil {
+		t.Fatalf("latest migration: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "version: "+Version) || !strings.Contains(stdout.String(), fmt.Sprintf("schema: %d", latest)) {
+		t.Fatalf("unexpected version output: %s", stdout.String())
+	}
+}
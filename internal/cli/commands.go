@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"strconv"
+
+	"chat-project/config"
+	"chat-project/internal/app"
+)
+
+func serve(args []string, stdout io.Writer) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return fmt.Errorf("config error: %w", err)
+	}
+
+	app.Run(cfg)
+	return nil
+}
+
+// migrator операции со схемой, которые выполняют подкоманды migrate
+type migrator interface {
+	Up() error
+	Down(steps int) error
+	To(version uint) error
+	Force(version int) error
+	Status() (uint, bool, error)
+	Close()
+}
+
+// newMigrator подключается к базе, тесты подменяют его
+var newMigrator = func(cfg config.Postgres, l *slog.Logger) (migrator, error) {
+	m, err := app.NewMigrator(cfg, l)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func migrateCommand(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected up, down, to, status or force")
+	}
+
+	// Аргументы проверяются до подключения к базе
+	run, err := migrateAction(args[0], args[1:], stdout)
+	if err != nil {
+		return err
+	}
+
+	cfg, l, err := load()
+	if err != nil {
+		return err
+	}
+
+	m, err := newMigrator(cfg.Postgres, l)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return run(m)
+}
+
+// migrateAction разбирает аргументы подкоманды migrate и возвращает действие над схемой
+func migrateAction(sub string, args []string, stdout io.Writer) (func(m migrator) error, error) {
+	switch sub {
+	case "up":
+		if len(args) != 0 {
+			return nil, errors.New("usage: migrate up")
+		}
+		return migrator.Up, nil
+	case "down":
+		if len(args) > 1 {
+			return nil, errors.New("usage: migrate down [N]")
+		}
+		steps := 1
+		if len(args) == 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps <= 0 {
+				return nil, fmt.Errorf("invalid steps %q, expected a positive number", args[0])
+			}
+		}
+		return func(m migrator) error { return m.Down(steps) }, nil
+	case "to":
+		if len(args) != 1 {
+			return nil, errors.New("usage: migrate to N")
+		}
+		version, err := strconv.ParseUint(args[0], 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", args[0])
+		}
+		return func(m migrator) error { return m.To(uint(version)) }, nil
+	case "force":
+		if len(args) != 1 {
+			return nil, errors.New("usage: migrate force N")
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", args[0])
+		}
+		return func(m migrator) error { return m.Force(version) }, nil
+	case "status":
+		if len(args) != 0 {
+			return nil, errors.New("usage: migrate status")
+		}
+		return func(m migrator) error { return migrateStatus(m, stdout) }, nil
+	default:
+		return nil, fmt.Errorf("unknown migrate command %q", sub)
+	}
+}
+
+func migrateStatus(m migrator, stdout io.Writer) error {
+	latest, err := app.LatestMigration()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Status()
+	if errors.Is(err, app.NoMigrationsError) {
+		fmt.Fprintf(stdout, "version: none\nlatest: %d\n", latest)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "version: %d\ndirty: %t\nlatest: %d\n", version, dirty, latest)
+	if version < latest {
+		fmt.Fprintf(stdout, "pending: %d\n", latest-version)
+	}
+	return nil
+}
+
+func version(args []string, stdout io.Writer) error {
+	fmt.Fprintf(stdout, "version: %s\n", Version)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(stdout, "go: %s\n", info.GoVersion)
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				fmt.Fprintf(stdout, "commit: %s\n", setting.Value)
+			}
+		}
+	}
+
+	latest, err := app.LatestMigration()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "schema: %d\n", latest)
+	return nil
+}
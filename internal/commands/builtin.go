@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Ограничения встроенных команд
+const (
+	pollMinOptions = 2
+	pollMaxOptions = 10
+	remindMinDelay = time.Second
+	remindMaxDelay = 7 * 24 * time.Hour
+)
+
+// Help выводит список команд
+type Help struct {
+	registry *Registry
+}
+
+func NewHelp(registry *Registry) *Help {
+	return &Help{registry: registry}
+}
+
+func (h *Help) Name() string  { return "help" }
+func (h *Help) Usage() string { return "/help - list commands" }
+
+func (h *Help) Run(ctx context.Context, req Request) (Reply, error) {
+	lines := make([]string, 0)
+	for _, cmd := range h.registry.Commands() {
+		lines = append(lines, cmd.Usage())
+	}
+	lines = append(lines, "Start a message with // to send it as text")
+	return Ephemeral("%s", strings.Join(lines, "\n")), nil
+}
+
+// Topic показывает или меняет описание чата
+type Topic struct{}
+
+func (Topic) Name() string  { return "topic" }
+func (Topic) Usage() string { return "/topic [text] - show or set the chat topic" }
+
+func (Topic) Run(ctx context.Context, req Request) (Reply, error) {
+	if req.Args == "" {
+		if req.Chat.Description == "" {
+			return Ephemeral("No topic is set"), nil
+		}
+		return Ephemeral("Topic: %s", req.Chat.Description), nil
+	}
+	if err := req.Env.SetTopic(ctx, req.Args); err != nil {
+		return Reply{}, err
+	}
+	return Public("%s set the topic: %s", displayName(req.UserId), req.Args), nil
+}
+
+// Poll публикует опрос с пронумерованными вариантами
+type Poll struct{}
+
+func (Poll) Name() string  { return "poll" }
+func (Poll) Usage() string { return "/poll question | option | option - start a poll" }
+
+func (p Poll) Run(ctx context.Context, req Request) (Reply, error) {
+	parts := strings.Split(req.Args, "|")
+	question := strings.TrimSpace(parts[0])
+	options := make([]string, 0, len(parts))
+	for _, option := range parts[1:] {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	if question == "" || len(options) < pollMinOptions || len(options) > pollMaxOptions {
+		return Ephemeral("A poll needs a question and %d to %d options. Usage: %s", pollMinOptions, pollMaxOptions, p.Usage()), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Poll from %s: %s", displayName(req.UserId), question)
+	for i, option := range options {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, option)
+	}
+	b.WriteString("\nReply with the option number")
+	return Public("%s", b.String()), nil
+}
+
+// Remind планирует напоминание в чат через заданное время.
+// Напоминание сохраняется как запланированное сообщение и переживает перезапуск сервиса
+type Remind struct {
+	now func() time.Time
+}
+
+func NewRemind() *Remind {
+	return &Remind{now: time.Now}
+}
+
+func (r *Remind) Name() string  { return "remind" }
+func (r *Remind) Usage() string { return "/remind 10m text - post a reminder after a delay" }
+
+func (r *Remind) Run(ctx context.Context, req Request) (Reply, error) {
+	delayArg, text, _ := strings.Cut(req.Args, " ")
+	text = strings.TrimSpace(text)
+	delay, err := time.ParseDuration(delayArg)
+	if err != nil || text == "" {
+		return Ephemeral("Usage: %s", r.Usage()), nil
+	}
+	if delay < remindMinDelay || delay > remindMaxDelay {
+		return Ephemeral("The delay must be from %s to %s", remindMinDelay, remindMaxDelay), nil
+	}
+
+	reminder := fmt.Sprintf("Reminder for %s: %s", displayName(req.UserId), text)
+	if err = req.Env.Schedule(ctx, r.now().Add(delay), reminder); err != nil {
+		return Reply{}, err
+	}
+	return Ephemeral("I will post the reminder in %s", delay), nil
+}
+
+func displayName(userId string) string {
+	if userId == "" {
+		return "someone"
+	}
+	return userId
+}
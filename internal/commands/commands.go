@@ -0,0 +1,134 @@
+// Package commands разбирает слэш-команды в сообщениях и вызывает зарегистрированные обработчики
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"chat-project/internal/domain"
+)
+
+var (
+	DuplicateCommandError   = errors.New("command is already registered")
+	InvalidCommandNameError = errors.New("command name must be 1-32 lowercase letters, digits, _ or -")
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Command обработчик слэш-команды. Реализации регистрируются в Registry
+type Command interface {
+	// Name имя команды без слэша
+	Name() string
+	// Usage строка для /help, например "/poll question | option | option"
+	Usage() string
+	// Run выполняет команду. Ошибки ввода пользователя возвращаются ответом Ephemeral, а не ошибкой
+	Run(ctx context.Context, req Request) (Reply, error)
+}
+
+// Env действия над чатом, в котором вызвана команда
+type Env interface {
+	// Post публикует сообщение бота от имени команды
+	Post(ctx context.Context, text string) error
+	// SetTopic меняет описание чата
+	SetTopic(ctx context.Context, topic string) error
+	// Schedule планирует сообщение бота от имени команды на время at
+	Schedule(ctx context.Context, at time.Time, text string) error
+}
+
+type Request struct {
+	Name string
+	Args string
+	// UserId пустой у анонимного отправителя
+	UserId string
+	Chat   domain.Chat
+	Env    Env
+}
+
+// Reply ответ команды. Public ответ сохраняется как сообщение бота и рассылается всем подписчикам чата,
+// остальные ответы получает только отправитель
+type Reply struct {
+	Text   string
+	Public bool
+}
+
+func Ephemeral(format string, args ...any) Reply {
+	return Reply{Text: fmt.Sprintf(format, args...)}
+}
+
+func Public(format string, args ...any) Reply {
+	return Reply{Text: fmt.Sprintf(format, args...), Public: true}
+}
+
+// Parse разбирает сообщение вида "/name args". Сообщения, начинающиеся с "//" или "/ ", командами не считаются
+func Parse(text string) (name, args string, ok bool) {
+	rest, found := strings.CutPrefix(text, "/")
+	if !found {
+		return "", "", false
+	}
+	name = rest
+	if i := strings.IndexAny(rest, " \t\n"); i >= 0 {
+		name, args = rest[:i], rest[i+1:]
+	}
+	name = strings.ToLower(name)
+	if !namePattern.MatchString(name) {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(args), true
+}
+
+// Registry набор команд. Безопасен для конкурентного использования
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]Command
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		commands: make(map[string]Command),
+	}
+}
+
+// Register добавляет команды. Имя должно быть уникальным
+func (r *Registry) Register(commands ...Command) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cmd := range commands {
+		name := cmd.Name()
+		if !namePattern.MatchString(name) {
+			return fmt.Errorf("%w: %q", InvalidCommandNameError, name)
+		}
+		if _, exists := r.commands[name]; exists {
+			return fmt.Errorf("%w: /%s", DuplicateCommandError, name)
+		}
+		r.commands[name] = cmd
+	}
+	return nil
+}
+
+func (r *Registry) Lookup(name string) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+// Commands возвращает команды в порядке имен
+func (r *Registry) Commands() []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	commands := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name() < commands[j].Name() })
+	return commands
+}
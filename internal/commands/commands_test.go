@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text, name, args string
+		ok               bool
+	}{
+		{"/help", "help", "", true},
+		{"/Poll  Lunch? | Pizza | Sushi ", "poll", "Lunch? | Pizza | Sushi", true},
+		{"/remind\n10m standup", "remind", "10m standup", true},
+		{"hello /help", "", "", false},
+		{"//help", "", "", false},
+		{"/ help", "", "", false},
+		{"/usr/bin is full", "", "", false},
+		{"/", "", "", false},
+	}
+	for _, tt := range tests {
+		name, args, ok := Parse(tt.text)
+		if name != tt.name || args != tt.args || ok != tt.ok {
+			t.Errorf("Parse(%q) = %q, %q, %v, want %q, %q, %v", tt.text, name, args, ok, tt.name, tt.args, tt.ok)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(Poll{}, NewHelp(registry), Topic{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := registry.Register(Poll{}); !errors.Is(err, DuplicateCommandError) {
+		t.Fatalf("expected duplicate error, got %v", err)
+	}
+
+	names := make([]string, 0)
+	for _, cmd := range registry.Commands() {
+		names = append(names, cmd.Name())
+	}
+	if strings.Join(names, ",") != "help,poll,topic" {
+		t.Fatalf("unexpected commands: %v", names)
+	}
+
+	reply, err := NewHelp(registry).Run(context.Background(), Request{})
+	if err != nil || reply.Public || !strings.Contains(reply.Text, Poll{}.Usage()) {
+		t.Fatalf("unexpected help reply: %+v, %v", reply, err)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	reply, err := Poll{}.Run(context.Background(), Request{UserId: "alice", Args: "Lunch? | Pizza | | Sushi"})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := "Poll from alice: Lunch?\n1. Pizza\n2. Sushi\nReply with the option number"
+	if !reply.Public || reply.Text != want {
+		t.Fatalf("unexpected reply: %+v", reply)
+	}
+
+	reply, err = Poll{}.Run(context.Background(), Request{UserId: "alice", Args: "Lunch? | Pizza"})
+	if err != nil || reply.Public {
+		t.Fatalf("expected ephemeral usage for one option, got %+v, %v", reply, err)
+	}
+}
+
+type scheduledPost struct {
+	at   time.Time
+	text string
+}
+
+type fakeEnv struct {
+	posted    []string
+	topic     string
+	scheduled []scheduledPost
+}
+
+func (e *fakeEnv) Post(ctx context.Context, text string) error {
+	e.posted = append(e.posted, text)
+	return nil
+}
+
+func (e *fakeEnv) SetTopic(ctx context.Context, topic string) error {
+	e.topic = topic
+	return nil
+}
+
+func (e *fakeEnv) Schedule(ctx context.Context, at time.Time, text string) error {
+	e.scheduled = append(e.scheduled, scheduledPost{at: at, text: text})
+	return nil
+}
+
+func TestTopic(t *testing.T) {
+	env := &fakeEnv{}
+	req := Request{UserId: "alice", Chat: domain.Chat{Description: "release"}, Env: env}
+
+	reply, err := Topic{}.Run(context.Background(), req)
+	if err != nil || reply.Public || reply.Text != "Topic: release" {
+		t.Fatalf("unexpected reply: %+v, %v", reply, err)
+	}
+
+	req.Args = "v2 planning"
+	reply, err = Topic{}.Run(context.Background(), req)
+	if err != nil || !reply.Public || env.topic != "v2 planning" {
+		t.Fatalf("topic not set: %+v, %v, %q", reply, err, env.topic)
+	}
+}
+
+func TestRemind(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	remind := NewRemind()
+	remind.now = func() time.Time { return now }
+	env := &fakeEnv{}
+
+	reply, err := remind.Run(context.Background(), Request{UserId: "bob", Args: "10m", Env: env})
+	if err != nil || reply.Public || len(env.scheduled) != 0 {
+		t.Fatalf("expected usage without text, got %+v, %v", reply, err)
+	}
+	reply, err = remind.Run(context.Background(), Request{UserId: "bob", Args: "30d standup", Env: env})
+	if err != nil || len(env.scheduled) != 0 {
+		t.Fatalf("expected delay error, got %+v, %v", reply, err)
+	}
+
+	reply, err = remind.Run(context.Background(), Request{UserId: "bob", Args: "10m standup", Env: env})
+	if err != nil || reply.Public || len(env.scheduled) != 1 {
+		t.Fatalf("reminder not scheduled: %+v, %v", reply, err)
+	}
+	want := scheduledPost{at: now.Add(10 * time.Minute), text: "Reminder for bob: standup"}
+	if env.scheduled[0] != want || len(env.posted) != 0 {
+		t.Fatalf("unexpected reminder: %+v, posts %v", env.scheduled, env.posted)
+	}
+}
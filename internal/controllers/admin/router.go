@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/dto"
+	"chat-project/internal/services"
+)
+
+// NewRouter регистрирует служебные эндпоинты для admin CLI.
+// Без токена эндпоинты не регистрируются, чтобы не открыть их случайно.
+func NewRouter(app *gin.Engine, log *slog.Logger, token string, chatManager *services.ChatListenerManager) {
+	if token == "" {
+		log.Info("admin endpoints disabled, ADMIN_TOKEN is empty")
+		return
+	}
+
+	controller := &AdminController{
+		chatManager: chatManager,
+		log:         log,
+	}
+
+	router := app.Group("/admin", BearerAuth(token))
+	router.GET("/listeners", controller.Listeners)
+}
+
+// BearerAuth пропускает только запросы с заголовком Authorization: Bearer <token>
+func BearerAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "invalid admin token")
+			return
+		}
+		c.Next()
+	}
+}
+
+type AdminController struct {
+	chatManager *services.ChatListenerManager
+	log         *slog.Logger
+}
+
+// Listeners возвращает слушателей чатов этого инстанса и количество SSE клиентов
+func (a *AdminController) Listeners(ctx *gin.Context) {
+	stats := a.chatManager.Stats()
+	resp := dto.ListenersResponse{Listeners: len(stats), Chats: make([]dto.ListenerStatsResponse, 0, len(stats))}
+	for _, s := range stats {
+		resp.Clients += s.Clients
+		resp.Chats = append(resp.Chats, dto.ListenerStatsResponse(s))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/logger"
+	"chat-project/internal/services"
+	"chat-project/internal/storage/memory"
+)
+
+func newAdminEngine(t *testing.T, token string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := memory.NewUserRepoMemory()
+	chat, err := repo.CreateChat(context.Background(), domain.Chat{Title: "watched", CreatedAt: time.Now()}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	manager := services.NewChatListenerManager(repo, memory.NewListenerMemory(), config.Listener{GracePeriod: time.Minute}, logger.Discard(), nil)
+	t.Cleanup(manager.Close)
+	if _, err := manager.Acquire(context.Background(), chat.ID); err != nil {
+		t.Fatalf("acquire listener: %v", err)
+	}
+
+	r := gin.New()
+	NewRouter(r, logger.Discard(), token, manager)
+	return r
+}
+
+func TestListenersAuth(t *testing.T) {
+	r := newAdminEngine(t, "secret")
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"token prefix", "Bearer secre", http.StatusUnauthorized},
+		{"other scheme", "Basic secret", http.StatusUnauthorized},
+		{"valid token", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/admin/listeners", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusOK && !strings.Contains(w.Body.String(), `"listeners":1`) {
+				t.Fatalf("expected one listener, got %s", w.Body.String())
+			}
+			if tt.status == http.StatusUnauthorized && !strings.Contains(w.Body.String(), `"code":"unauthorized"`) {
+				t.Fatalf("expected unauthorized problem, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListenersDisabledWithoutToken(t *testing.T) {
+	r := newAdminEngine(t, "")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/listeners", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected admin endpoints to be disabled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,41 @@
+package health
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/health"
+)
+
+// NewRouter регистрирует пробы для оркестратора:
+// /healthz - процесс жив, /readyz - зависимости доступны и инстанс принимает трафик
+func NewRouter(app *gin.Engine, log *slog.Logger, checker *health.Health) {
+	controller := &HealthController{
+		checker: checker,
+		log:     log,
+	}
+
+	app.GET("/healthz", controller.Liveness)
+	app.GET("/readyz", controller.Readiness)
+}
+
+type HealthController struct {
+	checker *health.Health
+	log     *slog.Logger
+}
+
+func (h *HealthController) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+func (h *HealthController) Readiness(ctx *gin.Context) {
+	report := h.checker.Ready(ctx)
+	if !report.Ready() {
+		h.log.WarnContext(ctx, "readiness check failed", slog.Any("report", report))
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
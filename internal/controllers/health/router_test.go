@@ -0,0 +1,42 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/health"
+	"chat-project/internal/logger"
+)
+
+func TestReadinessShuttingDown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checker := health.New(time.Second)
+	checker.AddCheck("postgres", func(ctx context.Context) (map[string]any, error) { return nil, nil })
+	r := gin.New()
+	NewRouter(r, logger.Discard(), checker)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := get("/readyz"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"postgres":{"status":"up"`) {
+		t.Fatalf("expected ready, got %d: %s", w.Code, w.Body.String())
+	}
+
+	checker.SetShuttingDown()
+	if w := get("/readyz"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), health.ShuttingDownError.Error()) {
+		t.Fatalf("expected 503 while shutting down, got %d: %s", w.Code, w.Body.String())
+	}
+	// Процесс жив, пока дорабатывает текущие запросы
+	if w := get("/healthz"); w.Code != http.StatusOK {
+		t.Fatalf("expected liveness to pass while shutting down, got %d", w.Code)
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/config"
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/idempotency"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// Выставляется в ответе, повторенном из сохраненного
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// Заголовки ответа, которые сохраняются вместе с телом
+var _idempotentHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Route маршрут по методу и шаблону gin
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Idempotency повторяет сохраненный ответ на запрос с тем же Idempotency-Key и телом.
+// Ключ с другим телом или маршрутом отклоняется с 409, как и повтор, пока первый запрос еще выполняется.
+// Сохраняются только успешные ответы: после ошибки запрос с тем же ключом можно повторить.
+func Idempotency(store idempotency.Store, cfg config.Idempotency, log *slog.Logger, routes ...Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !matchRoute(c, routes) {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidIdempotencyKey, "Idempotency-Key must be 1-255 printable ASCII characters")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidBody, "unable to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := idempotencyScope(c) + ":" + key
+		fingerprint := idempotency.Fingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		record, started, err := store.Start(c, storeKey, fingerprint, cfg.LockTimeout)
+		if err != nil {
+			log.ErrorContext(c, "idempotency store failed", slog.Any("error", err))
+			problem.Write(c, http.StatusServiceUnavailable, problem.CodeUnavailable, "idempotency store is unavailable")
+			return
+		}
+		if !started {
+			replay(c, record, fingerprint)
+			return
+		}
+
+		// Ответ сохраняется, даже если клиент отключился по таймауту: его повтор получит этот ответ
+		ctx := context.WithoutCancel(c)
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			// Запрос упал или завершился ошибкой: освобождаем ключ для повтора
+			if err := store.Release(ctx, storeKey); err != nil {
+				log.WarnContext(c, "unable to release idempotency key", slog.Any("error", err))
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < 200 || status >= 300 {
+			return
+		}
+		record = idempotency.Record{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      status,
+			Header:      make(map[string]string),
+			Body:        recorder.body.Bytes(),
+		}
+		for _, name := range _idempotentHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				record.Header[name] = value
+			}
+		}
+		if err := store.Complete(ctx, storeKey, record, cfg.TTL); err != nil {
+			log.ErrorContext(c, "unable to save idempotent response", slog.Any("error", err))
+			return
+		}
+		completed = true
+	}
+}
+
+func replay(c *gin.Context, record idempotency.Record, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		problem.Write(c, http.StatusConflict, problem.CodeIdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+	case !record.Done:
+		c.Header("Retry-After", strconv.Itoa(1))
+		problem.Write(c, http.StatusConflict, problem.CodeIdempotencyInProgress, "request with this Idempotency-Key is still in progress")
+	default:
+		for name, value := range record.Header {
+			c.Header(name, value)
+		}
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(record.Status, record.Header["Content-Type"], record.Body)
+		c.Abort()
+	}
+}
+
+func matchRoute(c *gin.Context, routes []Route) bool {
+	for _, route := range routes {
+		if route.Method == c.Request.Method && route.Path == c.FullPath() {
+			return true
+		}
+	}
+	return false
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > 255 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyScope разделяет ключи разных пользователей, а без X-User-Id ключи разных адресов
+func idempotencyScope(c *gin.Context) string {
+	if userId := c.GetHeader("X-User-Id"); userId != "" {
+		return "user:" + userId
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// responseRecorder копирует тело ответа, чтобы сохранить его для повторов
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/config"
+	"chat-project/internal/idempotency"
+	"chat-project/internal/logger"
+)
+
+var _testIdempotency = config.Idempotency{TTL: time.Hour, LockTimeout: time.Minute}
+
+// newIdempotentEngine считает вызовы обработчика. Тело "fail" завершается ошибкой
+func newIdempotentEngine(calls *atomic.Int32, delay time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Idempotency(idempotency.NewMemory(), _testIdempotency, logger.Discard(),
+		Route{Method: http.MethodPost, Path: "/v1/chats/:chatId/messages"}))
+	r.POST("/v1/chats/:chatId/messages", func(c *gin.Context) {
+		n := calls.Add(1)
+		time.Sleep(delay)
+		data, _ := c.GetRawData()
+		if string(data) == "fail" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "fail"})
+			return
+		}
+		c.Header("ETag", `"1"`)
+		c.JSON(http.StatusOK, gin.H{"call": n})
+	})
+	return r
+}
+
+func post(r *gin.Engine, path, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	var calls atomic.Int32
+	r := newIdempotentEngine(&calls, 0)
+
+	first := post(r, "/v1/chats/1/messages", "k1", "hello")
+	second := post(r, "/v1/chats/1/messages", "k1", "hello")
+	if calls.Load() != 1 {
+		t.Fatalf("expected handler to run once, got %d", calls.Load())
+	}
+	if second.Code != http.StatusOK || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected replayed response %q, got %d %q", first.Body.String(), second.Code, second.Body.String())
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" || second.Header().Get("ETag") != `"1"` {
+		t.Fatalf("expected replay headers, got %v", second.Header())
+	}
+
+	// Без ключа запросы не дедуплицируются
+	post(r, "/v1/chats/1/messages", "", "hello")
+	if calls.Load() != 2 {
+		t.Fatalf("expected request without key to run, got %d calls", calls.Load())
+	}
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	var calls atomic.Int32
+	r := newIdempotentEngine(&calls, 0)
+
+	post(r, "/v1/chats/1/messages", "k1", "hello")
+	for _, tt := range []struct{ path, body string }{
+		{"/v1/chats/1/messages", "other"},
+		{"/v1/chats/2/messages", "hello"},
+	} {
+		w := post(r, tt.path, "k1", tt.body)
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "idempotency_key_reused") {
+			t.Fatalf("%s %q: expected idempotency_key_reused, got %d %s", tt.path, tt.body, w.Code, w.Body.String())
+		}
+	}
+
+	if w := post(r, "/v1/chats/1/messages", "bad\nkey", "hello"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid key to be rejected, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyErrorNotStored(t *testing.T) {
+	var calls atomic.Int32
+	r := newIdempotentEngine(&calls, 0)
+
+	post(r, "/v1/chats/1/messages", "k1", "fail")
+	if w := post(r, "/v1/chats/1/messages", "k1", "fail"); w.Code != http.StatusInternalServerError || calls.Load() != 2 {
+		t.Fatalf("expected failed request to be retried, got %d after %d calls", w.Code, calls.Load())
+	}
+}
+
+func TestIdempotencyConcurrentDuplicates(t *testing.T) {
+	var calls atomic.Int32
+	r := newIdempotentEngine(&calls, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 10)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = post(r, "/v1/chats/1/messages", "k1", "hello").Code
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected handler to run once, got %d", calls.Load())
+	}
+	for _, code := range codes {
+		if code != http.StatusOK && code != http.StatusConflict {
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if w := post(r, "/v1/chats/1/messages", "k1", "hello"); w.Code != http.StatusOK {
+		t.Fatalf("expected replay after completion, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	var calls atomic.Int32
+	r := newIdempotentEngine(&calls, 0)
+
+	send := func(addr, userId string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/chats/1/messages", strings.NewReader("hello"))
+		req.RemoteAddr = addr + ":1234"
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		if userId != "" {
+			req.Header.Set("X-User-Id", userId)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		addr     string
+		userId   string
+		replayed bool
+	}{
+		{"anonymous", "10.0.0.1", "", false},
+		{"anonymous from another address", "10.0.0.2", "", false},
+		{"anonymous repeat", "10.0.0.1", "", true},
+		{"user", "10.0.0.1", "alice", false},
+		{"user from another address", "10.0.0.3", "alice", true},
+		{"another user", "10.0.0.3", "bob", false},
+	}
+	for _, tt := range tests {
+		w := send(tt.addr, tt.userId)
+		if replayed := w.Header().Get(IdempotentReplayedHeader) == "true"; w.Code != http.StatusOK || replayed != tt.replayed {
+			t.Fatalf("%s: expected replayed=%v, got %d %v", tt.name, tt.replayed, w.Code, w.Header())
+		}
+	}
+	if calls.Load() != 4 {
+		t.Fatalf("expected 4 handler calls, got %d", calls.Load())
+	}
+}
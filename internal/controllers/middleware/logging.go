@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"chat-project/internal/logger"
+)
+
+const RequestIDHeader = "X-Request-Id"
+
+// Параметры маршрутов, в которых передаются секреты: токен входящего вебхука и код приглашения
+var secretParams = map[string]bool{"token": true, "code": true}
+
+// RequestID берет идентификатор запроса из заголовка или генерирует новый,
+// возвращает его клиенту и добавляет в контекст логгера
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIDHeader)
+		if requestId == "" || len(requestId) > 128 {
+			requestId = uuid.NewString()
+		}
+
+		c.Header(RequestIDHeader, requestId)
+		ctx := logger.WithAttrs(c.Request.Context(), slog.String("request_id", requestId))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// AccessLog пишет строку лога на каждый обработанный запрос вместо стандартного логгера gin
+func AccessLog(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := RedactedPath(c)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("size", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		log.LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// RedactedPath возвращает путь запроса, в котором значения секретных параметров заменены их шаблоном, например /v1/hooks/:token.
+// Для запроса без маршрута путь пустой: без шаблона нельзя понять, где в нем секрет
+func RedactedPath(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		return ""
+	}
+	segments := strings.Split(c.Request.URL.Path, "/")
+	templates := strings.Split(route, "/")
+	if len(segments) != len(templates) {
+		return route
+	}
+	for i, template := range templates {
+		if strings.HasPrefix(template, ":") && secretParams[template[1:]] {
+			segments[i] = template
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// RedactSpanPath заменяет url.path в спане запроса на RedactedPath, подключается сразу после otelgin.Middleware
+func RedactSpanPath() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("url.path", RedactedPath(c)))
+		c.Next()
+	}
+}
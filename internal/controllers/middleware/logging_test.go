@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"chat-project/config"
+	"chat-project/internal/logger"
+)
+
+func TestSecretsNotLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	log, err := logger.NewWithWriter(config.Log{Level: "info", Format: logger.FormatJSON}, &buf)
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	r := gin.New()
+	r.Use(otelgin.Middleware("test", otelgin.WithTracerProvider(provider)), RedactSpanPath(), AccessLog(log))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.POST("/v1/hooks/:token", ok)
+	r.POST("/v1/invites/:code/accept", ok)
+	r.GET("/v1/chats/:chatId", ok)
+
+	tests := []struct {
+		method string
+		path   string
+		secret string
+		want   string
+	}{
+		{http.MethodPost, "/v1/hooks/hook-secret-1", "hook-secret-1", `"path":"/v1/hooks/:token"`},
+		{http.MethodPost, "/v1/invites/invite-secret-2/accept", "invite-secret-2", `"path":"/v1/invites/:code/accept"`},
+		// Без маршрута нельзя понять, где в пути секрет, поэтому путь не пишется
+		{http.MethodGet, "/v1/hooks/hook-secret-3", "hook-secret-3", `"path":""`},
+		{http.MethodGet, "/v1/chats/42", "", `"path":"/v1/chats/42"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			buf.Reset()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			out := buf.String()
+			if !strings.Contains(out, tt.want) {
+				t.Fatalf("expected %s in log: %s", tt.want, out)
+			}
+			if tt.secret != "" && strings.Contains(out, tt.secret) {
+				t.Fatalf("secret leaked into log: %s", out)
+			}
+
+			spans := recorder.Ended()
+			span := spans[len(spans)-1]
+			if tt.secret != "" && strings.Contains(span.Name(), tt.secret) {
+				t.Fatalf("secret leaked into span name %q", span.Name())
+			}
+			var path string
+			for _, attr := range span.Attributes() {
+				if tt.secret != "" && strings.Contains(attr.Value.Emit(), tt.secret) {
+					t.Fatalf("secret leaked into span attribute %s", attr.Key)
+				}
+				if attr.Key == "url.path" {
+					path = attr.Value.AsString()
+				}
+			}
+			if tt.secret == "" && path != tt.path {
+				t.Fatalf("expected url.path %s, got %q", tt.path, path)
+			}
+		})
+	}
+}
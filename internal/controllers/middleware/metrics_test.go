@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/metrics"
+)
+
+func TestMetricsRouteLabel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New()
+	r := gin.New()
+	r.Use(Metrics(m))
+	r.GET("/v1/chats/:chatId", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/v1/chats/1", "/v1/chats/2", "/v1/unknown/42"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := w.Body.String()
+	for _, want := range []string{
+		`chat_http_requests_total{method="GET",route="/v1/chats/:chatId",status="204"} 2`,
+		`chat_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`chat_http_request_duration_seconds_count{method="GET",route="/v1/chats/:chatId"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in:\n%s", want, out)
+		}
+	}
+	// Фактические пути с идентификаторами не попадают в метки
+	if strings.Contains(out, `route="/v1/chats/1"`) || strings.Contains(out, "/v1/unknown/42") {
+		t.Fatalf("request paths leaked into labels:\n%s", out)
+	}
+}
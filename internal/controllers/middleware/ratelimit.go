@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/domain"
+	"chat-project/internal/metrics"
+	"chat-project/internal/ratelimit"
+)
+
+// KeyFunc возвращает ключ корзины для запроса. Пустой ключ означает, что правило к запросу не применяется
+type KeyFunc func(c *gin.Context) string
+
+// RateLimitRule лимит для маршрутов, шаблон которых начинается с Route.
+// Пустой Method подходит для любого метода
+type RateLimitRule struct {
+	Name   string
+	Method string
+	Route  string
+	Rate   ratelimit.Rate
+	Key    KeyFunc
+}
+
+func (r RateLimitRule) matches(c *gin.Context) bool {
+	if !r.Rate.Enabled() || c.FullPath() == "" {
+		return false
+	}
+	if r.Method != "" && r.Method != c.Request.Method {
+		return false
+	}
+	return strings.HasPrefix(c.FullPath(), r.Route)
+}
+
+// ByIP ключ по адресу клиента
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser ключ по X-User-Id, который выставляет шлюз авторизации. Без заголовка ключ по адресу.
+// Клиент может менять заголовок сам, поэтому правила с ByUser дополняются правилом с ByIP
+func ByUser(c *gin.Context) string {
+	if userId := c.GetHeader("X-User-Id"); userId != "" {
+		return "user:" + userId
+	}
+	return ByIP(c)
+}
+
+// ByChat ключ по чату из пути или параметра chatId
+func ByChat(c *gin.Context) string {
+	chatId := c.Param("chatId")
+	if chatId == "" {
+		chatId = c.Query("chatId")
+	}
+	if chatId == "" {
+		return ""
+	}
+	return "chat:" + chatId
+}
+
+// ByHookToken ключ по токену входящего вебхука из пути. В хранилище лимитов попадает только хэш токена
+func ByHookToken(c *gin.Context) string {
+	token := c.Param("token")
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "hook:" + hex.EncodeToString(sum[:16])
+}
+
+// RateLimit применяет к запросу все подходящие правила. Если хотя бы одно исчерпано, отвечает 429.
+// Заголовки RateLimit-* описывают правило с наименьшим остатком. Ошибка хранилища лимитов
+// не блокирует запрос, а только логируется.
+func RateLimit(limiter ratelimit.Limiter, log *slog.Logger, m *metrics.Metrics, rules ...RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			tightest *ratelimit.Result
+			rejected string
+		)
+		for _, rule := range rules {
+			if !rule.matches(c) {
+				continue
+			}
+			key := rule.Key(c)
+			if key == "" {
+				continue
+			}
+
+			res, err := limiter.Allow(c, rule.Name+":"+key, rule.Rate)
+			if err != nil {
+				log.WarnContext(c, "rate limit check failed", slog.String("rule", rule.Name), slog.Any("error", err))
+				continue
+			}
+			if !res.Allowed {
+				tightest, rejected = &res, rule.Name
+				break
+			}
+			if tightest == nil || res.Remaining < tightest.Remaining {
+				tightest = &res
+			}
+		}
+
+		if rejected != "" {
+			m.RateLimited(rejected)
+			problem.Error(c, log, domain.RateLimited("", nil, &ratelimit.LimitError{Reason: ratelimit.ExceededError, Result: *tightest}))
+			return
+		}
+		if tightest != nil {
+			ratelimit.SetHeaders(c.Writer.Header(), *tightest)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+)
+
+func newRateLimitedEngine(rules ...RateLimitRule) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RateLimit(ratelimit.NewMemory(), logger.Discard(), nil, rules...))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.POST("/v1/chats/:chatId/messages", ok)
+	r.GET("/healthz", ok)
+	return r
+}
+
+func send(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRejects(t *testing.T) {
+	r := newRateLimitedEngine(RateLimitRule{
+		Name:   "messages",
+		Method: http.MethodPost,
+		Route:  "/v1/chats/:chatId/messages",
+		Rate:   ratelimit.Rate{Limit: 2, Period: time.Minute},
+		Key:    ByIP,
+	})
+
+	w := send(r, http.MethodPost, "/v1/chats/1/messages")
+	if w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("unexpected first response: %d %v", w.Code, w.Header())
+	}
+	send(r, http.MethodPost, "/v1/chats/1/messages")
+
+	w = send(r, http.MethodPost, "/v1/chats/1/messages")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected 429 with Retry-After, got %d %v", w.Code, w.Header())
+	}
+	var body dto.ProblemResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "rate_limited" {
+		t.Fatalf("expected rate_limited problem, got %s", w.Body.String())
+	}
+
+	// Маршруты вне правила не ограничиваются
+	if w := send(r, http.MethodGet, "/healthz"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("expected unlimited route, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimitPerChat(t *testing.T) {
+	r := newRateLimitedEngine(RateLimitRule{
+		Name:  "chat_messages",
+		Route: "/v1/chats/",
+		Rate:  ratelimit.Rate{Limit: 1, Period: time.Minute},
+		Key:   ByChat,
+	})
+
+	if w := send(r, http.MethodPost, "/v1/chats/1/messages"); w.Code != http.StatusNoContent {
+		t.Fatalf("expected first message to pass, got %d", w.Code)
+	}
+	if w := send(r, http.MethodPost, "/v1/chats/2/messages"); w.Code != http.StatusNoContent {
+		t.Fatalf("expected another chat to have its own limit, got %d", w.Code)
+	}
+	if w := send(r, http.MethodPost, "/v1/chats/1/messages"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected chat limit, got %d", w.Code)
+	}
+}
+
+func TestRateLimitUserRotation(t *testing.T) {
+	rate := ratelimit.Rate{Limit: 2, Period: time.Minute}
+	r := newRateLimitedEngine(
+		RateLimitRule{Name: "messages", Route: "/v1/", Rate: rate, Key: ByUser},
+		RateLimitRule{Name: "address", Route: "/v1/", Rate: rate, Key: ByIP},
+	)
+
+	// Каждый запрос с новым X-User-Id получает свою корзину пользователя, но корзина адреса общая
+	for i, userId := range []string{"alice", "bob", "carol"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/chats/1/messages", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-User-Id", userId)
+		r.ServeHTTP(w, req)
+
+		want := http.StatusNoContent
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Fatalf("request as %s: expected %d, got %d", userId, want, w.Code)
+		}
+	}
+}
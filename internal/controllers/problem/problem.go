@@ -0,0 +1,193 @@
+// Package problem формирует ответы с ошибками в формате RFC 7807 и сопоставляет
+// доменные ошибки со статусами и стабильными кодами.
+package problem
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/services"
+	"chat-project/internal/storage"
+	"chat-project/internal/validation"
+	"chat-project/internal/webhook"
+)
+
+const ContentType = "application/problem+json"
+
+// Коды ошибок входят в контракт API и не меняются
+const (
+	CodeInvalidBody        = "invalid_body"
+	CodeInvalidChatID      = "invalid_chat_id"
+	CodeInvalidInviteID    = "invalid_invite_id"
+	CodeInvalidWebhookID   = "invalid_webhook_id"
+	CodeInvalidDeliveryID  = "invalid_delivery_id"
+	CodeInvalidScheduledID = "invalid_scheduled_message_id"
+	CodeInvalidPage        = "invalid_page"
+	CodeInvalidIfMatch     = "invalid_if_match"
+	CodeInvalidUserID      = "invalid_user_id"
+	CodeInvalidEventID     = "invalid_last_event_id"
+	CodeUserIDRequired     = "user_id_required"
+	CodeUnauthorized       = "unauthorized"
+	CodeRouteNotFound      = "route_not_found"
+
+	CodeInvalidIdempotencyKey = "invalid_idempotency_key"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+
+	// Коды NotFound строятся из сущности: <entity>_not_found
+	CodeChatNotFound   = "chat_not_found"
+	CodeMemberNotFound = "member_not_found"
+	CodeInviteNotFound = "invite_not_found"
+
+	CodeInviteUnavailable   = "invite_unavailable"
+	CodeNotChatManager      = "not_chat_manager"
+	CodeNotChatMember       = "not_chat_member"
+	CodeReadOnlyChat        = "read_only_chat"
+	CodeChatVersionConflict = "chat_version_conflict"
+	CodeDirectChatInvite    = "direct_chat_invite"
+	CodeDirectChatPatch     = "direct_chat_patch"
+	CodeInvalidInviteRole   = "invalid_invite_role"
+	CodeInvalidInvite       = "invalid_invite"
+	CodeSelfDirectChat      = "self_direct_chat"
+	CodeSlowMode            = "slow_mode"
+	CodeDuplicateNonce      = "duplicate_client_nonce"
+	CodeInvalidWebhookEvent = "invalid_webhook_event"
+	CodePrivateWebhookURL   = "private_webhook_url"
+	CodeUnresolvableHost    = "unresolvable_webhook_host"
+	CodeInvalidStatus       = "invalid_delivery_status"
+	CodeDeliveryNotDead     = "delivery_not_dead"
+	CodeInvalidSendAt       = "invalid_send_at"
+	CodeScheduledCommand    = "scheduled_command"
+	CodeScheduledTTL        = "scheduled_ttl"
+	CodeScheduledSending    = "scheduled_message_sending"
+	CodeLegalHold           = "legal_hold"
+	CodeTooManyConnections  = "too_many_connections"
+
+	// Общие коды классов доменных ошибок, если у причины нет своего кода
+	CodeNotFound    = "not_found"
+	CodeConflict    = "conflict"
+	CodeValidation  = "validation_failed"
+	CodeForbidden   = "forbidden"
+	CodeUnavailable = "service_unavailable"
+	CodeRateLimited = "rate_limited"
+	CodeInternal    = "internal_error"
+)
+
+type mapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// Причины со своим статусом и кодом, проверяются раньше классов ошибок
+var reasons = []mapping{
+	{storage.ChatVersionConflictError, http.StatusPreconditionFailed, CodeChatVersionConflict},
+	{storage.InviteUnavailableError, http.StatusGone, CodeInviteUnavailable},
+	{services.NotChatManagerError, http.StatusForbidden, CodeNotChatManager},
+	{services.NotChatMemberError, http.StatusForbidden, CodeNotChatMember},
+	{services.ReadOnlyChatError, http.StatusForbidden, CodeReadOnlyChat},
+	{services.DirectChatInviteError, http.StatusBadRequest, CodeDirectChatInvite},
+	{services.DirectChatPatchError, http.StatusBadRequest, CodeDirectChatPatch},
+	{services.InvalidInviteRoleError, http.StatusBadRequest, CodeInvalidInviteRole},
+	{services.InvalidInviteError, http.StatusBadRequest, CodeInvalidInvite},
+	{services.SelfDirectChatError, http.StatusBadRequest, CodeSelfDirectChat},
+	{services.SlowModeError, http.StatusTooManyRequests, CodeSlowMode},
+	{storage.MessageNonceConflictError, http.StatusConflict, CodeDuplicateNonce},
+	{storage.DeliveryNotDeadError, http.StatusConflict, CodeDeliveryNotDead},
+	{services.InvalidWebhookEventError, http.StatusBadRequest, CodeInvalidWebhookEvent},
+	{webhook.PrivateAddressError, http.StatusBadRequest, CodePrivateWebhookURL},
+	{webhook.UnresolvableHostError, http.StatusBadRequest, CodeUnresolvableHost},
+	{services.InvalidDeliveryStatusError, http.StatusBadRequest, CodeInvalidStatus},
+	{services.InvalidSendAtError, http.StatusBadRequest, CodeInvalidSendAt},
+	{services.ScheduledCommandError, http.StatusBadRequest, CodeScheduledCommand},
+	{services.ScheduledTTLError, http.StatusBadRequest, CodeScheduledTTL},
+	{storage.ScheduledMessageSendingError, http.StatusConflict, CodeScheduledSending},
+	{services.LegalHoldError, http.StatusConflict, CodeLegalHold},
+	{services.TooManyConnectionsError, http.StatusTooManyRequests, CodeTooManyConnections},
+}
+
+// Статусы и коды по классу доменной ошибки
+var kinds = []mapping{
+	{domain.NotFoundError, http.StatusNotFound, CodeNotFound},
+	{domain.ConflictError, http.StatusConflict, CodeConflict},
+	{domain.ValidationError, http.StatusBadRequest, CodeValidation},
+	{domain.ForbiddenError, http.StatusForbidden, CodeForbidden},
+	{domain.UnavailableError, http.StatusServiceUnavailable, CodeUnavailable},
+	{domain.RateLimitedError, http.StatusTooManyRequests, CodeRateLimited},
+}
+
+// Write прерывает обработку запроса и отвечает ошибкой с кодом code
+func Write(ctx *gin.Context, status int, code, detail string) {
+	write(ctx, newProblem(ctx, status, code, detail))
+}
+
+func newProblem(ctx *gin.Context, status int, code, detail string) dto.ProblemResponse {
+	problem := dto.ProblemResponse{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.Request.URL.Path,
+		Code:     code,
+	}
+	if spanCtx := trace.SpanContextFromContext(ctx.Request.Context()); spanCtx.HasTraceID() {
+		problem.TraceId = spanCtx.TraceID().String()
+	}
+	return problem
+}
+
+func write(ctx *gin.Context, problem dto.ProblemResponse) {
+	// Рендер JSON не перезаписывает уже выставленный Content-Type
+	ctx.Header("Content-Type", ContentType)
+	ctx.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// Error отвечает на ошибку сервиса. Доменные ошибки переводятся в свой статус и код,
+// в detail попадает только текст доменной ошибки, а не всей цепочки, поэтому детали вроде текста SQL
+// наружу не уходят. Остальные ошибки логируются и возвращаются клиенту как 500 без подробностей.
+func Error(ctx *gin.Context, log *slog.Logger, err error) {
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		log.ErrorContext(ctx, "request failed", slog.String("route", ctx.FullPath()), slog.Any("error", err))
+		Write(ctx, http.StatusInternalServerError, CodeInternal, "internal server error")
+		return
+	}
+
+	status, code := classify(domainErr)
+	problem := newProblem(ctx, status, code, domainErr.Error())
+	// Ошибки проверки полей отдаются списком, чтобы клиент мог показать их у каждого поля
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		problem.Errors = fieldErrs
+	}
+	var limitErr *ratelimit.LimitError
+	if errors.As(err, &limitErr) {
+		ratelimit.SetHeaders(ctx.Writer.Header(), limitErr.Result)
+	}
+	write(ctx, problem)
+}
+
+func classify(err *domain.Error) (int, string) {
+	for _, m := range reasons {
+		if errors.Is(err, m.err) {
+			return m.status, m.code
+		}
+	}
+	for _, m := range kinds {
+		if err.Kind != m.err {
+			continue
+		}
+		if m.err == domain.NotFoundError && err.Entity != "" {
+			return m.status, string(err.Entity) + "_not_found"
+		}
+		return m.status, m.code
+	}
+	return http.StatusInternalServerError, CodeInternal
+}
@@ -1,6 +1,8 @@
 package restapi
 
 import (
+	"log/slog"
+
 	"github.com/gin-gonic/gin"
 
 	docs "chat-project/docs"
@@ -12,19 +14,46 @@ import (
 )
 
 // NewRouter создает и настраивает роутер для API
-func NewRouter(app *gin.Engine, service *services.ChatService) {
-	chatController := v1.NewChatController(service)
+func NewRouter(app *gin.Engine, log *slog.Logger, service *services.ChatService, inviteService *services.InviteService, webhookService *services.WebhookService, incomingService *services.IncomingWebhookService) {
+	chatController := v1.NewChatController(service, log)
+	directController := v1.NewDirectController(service, log)
+	inviteController := v1.NewInviteController(inviteService, log)
+	webhookController := v1.NewWebhookController(webhookService, log)
+	incomingController := v1.NewIncomingWebhookController(incomingService, log)
 	docs.SwaggerInfo.BasePath = "/v1"
 	// Routers
 	apiV1Group := app.Group("/v1")
 	{
 		chats := apiV1Group.Group("/chats")
 		{
+			chats.GET("/", chatController.ListChats)
 			chats.POST("/", chatController.CreateChat)
 			chats.GET("/:chatId", chatController.GetChat)
+			chats.PATCH("/:chatId", chatController.UpdateChat)
 			chats.POST("/:chatId/messages", chatController.AddMessage)
+			chats.GET("/:chatId/scheduled-messages", chatController.ListScheduledMessages)
+			chats.PATCH("/:chatId/scheduled-messages/:scheduledId", chatController.UpdateScheduledMessage)
+			chats.DELETE("/:chatId/scheduled-messages/:scheduledId", chatController.CancelScheduledMessage)
 			chats.DELETE("/:chatId", chatController.DeleteChat)
+
+			chats.POST("/:chatId/invites", inviteController.CreateInvite)
+			chats.GET("/:chatId/invites", inviteController.ListInvites)
+			chats.DELETE("/:chatId/invites/:inviteId", inviteController.RevokeInvite)
+
+			chats.POST("/:chatId/webhooks", webhookController.CreateWebhook)
+			chats.GET("/:chatId/webhooks", webhookController.ListWebhooks)
+			chats.DELETE("/:chatId/webhooks/:webhookId", webhookController.DeleteWebhook)
+			chats.GET("/:chatId/webhooks/:webhookId/deliveries", webhookController.ListDeliveries)
+			chats.POST("/:chatId/webhooks/:webhookId/deliveries/:deliveryId/retry", webhookController.RetryDelivery)
+
+			chats.POST("/:chatId/incoming-webhooks", incomingController.CreateIncomingWebhook)
+			chats.GET("/:chatId/incoming-webhooks", incomingController.ListIncomingWebhooks)
+			chats.DELETE("/:chatId/incoming-webhooks/:webhookId", incomingController.DeleteIncomingWebhook)
 		}
+
+		apiV1Group.POST("/dm/:userId", directController.GetOrCreateDirectChat)
+		apiV1Group.POST("/invites/:code/accept", inviteController.AcceptInvite)
+		apiV1Group.POST("/hooks/:token", incomingController.PostMessage)
 	}
 
 	apiV1Group.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
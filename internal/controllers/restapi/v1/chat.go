@@ -2,91 +2,219 @@ package v1
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"chat-project/internal/controllers/problem"
 	"chat-project/internal/dto"
 	"chat-project/internal/services"
-	"chat-project/internal/storage"
 )
 
 type ChatController struct {
 	service *services.ChatService
+	log     *slog.Logger
 }
 
-func NewChatController(service *services.ChatService) *ChatController {
+func NewChatController(service *services.ChatService, log *slog.Logger) *ChatController {
 	return &ChatController{
 		service: service,
+		log:     log,
 	}
 }
 
 // CreateChat создает новый чат
 //
 //	@Summary      Создать чат
-//	@Description  Создает новый чат с указанным названием
+//	@Description  Создает новый чат с указанным названием. Пользователь из X-User-Id становится владельцем, для приватного чата он обязателен
 //	@Tags         chats
 //	@Accept       json
 //	@Produce      json
-//	@Param        chat  body      dto.ChatIn  true  "Данные чата"
+//	@Param        chat       body      dto.ChatIn  true   "Данные чата"
+//	@Param        X-User-Id  header    string      false  "ID создателя чата"
+//	@Param        Idempotency-Key  header  string  false  "Ключ для безопасного повтора запроса"
 //	@Success      200   {object}  dto.ChatResponse
-//	@Failure      400   {object}  map[string]string  "Неверный запрос"
-//	@Failure      500   {object}  map[string]string  "Внутренняя ошибка сервера"
+//	@Failure      400   {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401   {object}  dto.ProblemResponse  "Для приватного чата не указан пользователь"
+//	@Failure      409   {object}  dto.ProblemResponse  "Idempotency-Key использован для другого запроса"
+//	@Failure      500   {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
 //	@Router       /chats [post]
 func (c *ChatController) CreateChat(ctx *gin.Context) {
 	var chat dto.ChatIn
 	if err := ctx.ShouldBindJSON(&chat); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
 		return
 	}
 
-	if chat.Title == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+	userId, err := currentUserID(ctx)
+	if err != nil && !errors.Is(err, missingUserIDError) {
+		userIDError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	// Приватный чат без владельца был бы недоступен никому
+	if chat.Private && userId == "" {
+		userIDError(ctx, http.StatusUnauthorized, missingUserIDError)
 		return
 	}
 
 	chatResponse, err := c.service.Create(
 		ctx,
 		chat,
+		userId,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Error(ctx, c.log, err)
 		return 
 	}
 
+	ctx.Header("ETag", chatETag(chatResponse.Version))
+	ctx.JSON(http.StatusOK, chatResponse)
+}
+
+// UpdateChat изменяет метаданные чата
+//
+//	@Summary      Изменить чат
+//	@Description  Изменяет переданные поля чата, менять чат может только его владелец или администратор.
+//	@Description  Чат без владельца (созданный без X-User-Id) может менять любой, кому он доступен.
+//	@Description  Если передан If-Match, изменение применяется только к указанной версии, без него применяется к последней
+//	@Tags         chats
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId    path      int              true   "ID чата"
+//	@Param        If-Match  header    string           false  "ETag версии чата"
+//	@Param        X-User-Id  header   string           false  "ID пользователя, нужен для чатов с владельцем"
+//	@Param        chat      body      dto.ChatPatchIn  true   "Изменяемые поля"
+//	@Success      200       {object}  dto.ChatResponse
+//	@Failure      400       {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      403       {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404       {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      412       {object}  dto.ProblemResponse  "Версия чата изменилась"
+//	@Failure      500       {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId} [patch]
+func (c ChatController) UpdateChat(ctx *gin.Context) {
+	chatIdParam := ctx.Param("chatId")
+	chatId, err := dto.ParseID(chatIdParam)
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(ctx.GetHeader("If-Match"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidIfMatch, err.Error())
+		return
+	}
+
+	var patch dto.ChatPatchIn
+	if err := ctx.ShouldBindJSON(&patch); err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
+		return
+	}
+
+	userId, err := currentUserID(ctx)
+	if err != nil && !errors.Is(err, missingUserIDError) {
+		userIDError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	chatResponse, err := c.service.UpdateChat(ctx, chatId, userId, patch, expectedVersion)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.Header("ETag", chatETag(chatResponse.Version))
 	ctx.JSON(http.StatusOK, chatResponse)
 }
 
+// ListChats возвращает публичные чаты
+//
+//	@Summary      Список чатов
+//	@Description  Возвращает публичные групповые чаты, личные чаты не отображаются
+//	@Tags         chats
+//	@Accept       json
+//	@Produce      json
+//	@Param        limit   query     int  false  "Количество чатов (по умолчанию 50, максимум 100)"
+//	@Param        offset  query     int  false  "Смещение"
+//	@Success      200     {object}  dto.ChatsResponse
+//	@Failure      400     {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      500     {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats [get]
+func (c ChatController) ListChats(ctx *gin.Context) {
+	limit, offset, err := dto.ParsePage(ctx.Query("limit"), ctx.Query("offset"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidPage, err.Error())
+		return
+	}
+
+	chatsResp, err := c.service.ListChats(ctx, limit, offset)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatsResp)
+}
+
 // AddMessage добавляет сообщение в чат
 //
 //	@Summary      Добавить сообщение
-//	@Description  Добавляет новое сообщение в указанный чат
+//	@Description  Добавляет новое сообщение в указанный чат. Сообщение вида "/name args" выполняет слэш-команду, ответ с Ephemeral=true видит только отправитель.
+//	@Description  Если задан SendAt, сообщение планируется: нужен X-User-Id, ответ 202 с запланированным сообщением
+//	@Description  ClientNonce тоже требует X-User-Id
+//	@Description  Если задан TtlSeconds, сообщение исчезает из чата через указанное время, подписчики получают message.expired
 //	@Tags         chats
 //	@Accept       json
 //	@Produce      json
 //	@Param        chatId   path      int            true   "ID чата"
 //	@Param        message  body      dto.MessageIn  true   "Данные сообщения"
+//	@Param        X-User-Id  header  string         false  "ID отправителя, по нему действует медленный режим"
+//	@Param        Idempotency-Key  header  string  false  "Ключ для безопасного повтора запроса"
 //	@Success      200      {object}  dto.MessageResponse
-//	@Failure      400      {object}  map[string]string  "Неверный запрос"
-//	@Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+//	@Success      202      {object}  dto.ScheduledMessageResponse
+//	@Failure      400      {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401      {object}  dto.ProblemResponse  "Для SendAt или ClientNonce не указан пользователь"
+//	@Failure      403      {object}  dto.ProblemResponse  "Пользователь не участник приватного или личного чата или чат только для чтения"
+//	@Failure      404      {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      409      {object}  dto.ProblemResponse  "Idempotency-Key или ClientNonce использован для другого запроса"
+//	@Failure      429      {object}  dto.ProblemResponse  "Превышен лимит запросов или включен медленный режим"
+//	@Failure      500      {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
 //	@Router       /chats/{chatId}/messages [post]
 func (c ChatController) AddMessage(ctx *gin.Context) {
 	var message dto.MessageIn
 	if err := ctx.ShouldBindJSON(&message); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
 		return
 	}
 
 	chatIdParam := ctx.Param("chatId")
 	chatId, err := dto.ParseID(chatIdParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat ID"})
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
 		return
 	}
 
-	msg_resp, err := c.service.AddMessage(ctx, chatId, message)
+	sender, err := currentSender(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		userIDError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	// ClientNonce уникален для автора, а анонимные отправители делили бы одного автора и его nonce
+	if message.ClientNonce != "" && sender.UserId == "" {
+		userIDError(ctx, http.StatusUnauthorized, missingUserIDError)
+		return
+	}
+
+	if message.SendAt != nil {
+		c.scheduleMessage(ctx, chatId, sender, message)
+		return
+	}
+
+	msg_resp, err := c.service.AddMessage(ctx, chatId, sender, message)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, msg_resp)
@@ -96,59 +224,76 @@ func (c ChatController) AddMessage(ctx *gin.Context) {
 // GetChat получает чат с сообщениями
 //
 //	@Summary      Получить чат
-//	@Description  Получает информацию о чате со всеми сообщениями
+//	@Description  Получает информацию о чате со всеми сообщениями, кроме сообщений с истекшим TTL.
+//	@Description  Приватный и личный чат доступен только участникам
 //	@Tags         chats
 //	@Accept       json
 //	@Produce      json
 //	@Param        chatId  path      int  true  "ID чата"https://github.com/Ownax-vit
+//	@Param        X-User-Id  header  string  false  "ID пользователя, нужен для приватных и личных чатов"
 //	@Success      200     {object}  dto.ChatWithMessagesResponse
-//	@Failure      400     {object}  map[string]string  "Неверный запрос"
-//	@Failure      500     {object}  map[string]string  "Внутренняя ошибка сервера"
+//	@Failure      400     {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      403     {object}  dto.ProblemResponse  "Пользователь не участник чата"
+//	@Failure      404     {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500     {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
 //	@Router       /chats/{chatId} [get]
 func (c ChatController) GetChat(ctx *gin.Context) {
 	chatIdParam := ctx.Param("chatId")
 	chatId, err := dto.ParseID(chatIdParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat ID"})
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
+		return
+	}
+
+	userId, err := currentUserID(ctx)
+	if err != nil && !errors.Is(err, missingUserIDError) {
+		userIDError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	chatResp, err := c.service.GetWithMessages(ctx, chatId)
+	chatResp, err := c.service.GetWithMessages(ctx, chatId, userId)
 	if err != nil {
-		if errors.Is(err, storage.ChatNotFoundError) {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "chat not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Error(ctx, c.log, err)
 		return
 	}
 
+	ctx.Header("ETag", chatETag(chatResp.Version))
 	ctx.JSON(http.StatusOK, chatResp)
 }
 
 // DeleteChat удаляет чат
 //
 //	@Summary      Удалить чат
-//	@Description  Удаляет чат по указанному ID
+//	@Description  Удаляет чат по указанному ID. Групповой чат удаляет владелец или администратор, личный любой из участников.
+//	@Description  Чат без владельца (созданный без X-User-Id) может удалить любой, кому он доступен
 //	@Tags         chats
 //	@Accept       json
 //	@Produce      json
 //	@Param        chatId  path      int  true  "ID чата"
+//	@Param        X-User-Id  header  string  false  "ID пользователя, нужен для чатов с владельцем"
 //	@Success      204     "Чат успешно удален"
-//	@Failure      400     {object}  map[string]string  "Неверный запрос"
-//	@Failure      500     {object}  map[string]string  "Внутренняя ошибка сервера"
+//	@Failure      400     {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      403     {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404     {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500     {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
 //	@Router       /chats/{chatId} [delete]
 func (c ChatController) DeleteChat(ctx *gin.Context) {
 	chatIdParam := ctx.Param("chatId")
 	chatId, err := dto.ParseID(chatIdParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat ID"})
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
+		return
+	}
+
+	userId, err := currentUserID(ctx)
+	if err != nil && !errors.Is(err, missingUserIDError) {
+		userIDError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = c.service.DeleteChat(ctx, chatId)
+	err = c.service.DeleteChat(ctx, chatId, userId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Error(ctx, c.log, err)
 		return
 	}
 
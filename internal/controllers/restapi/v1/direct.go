@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/services"
+)
+
+type DirectController struct {
+	service *services.ChatService
+	log     *slog.Logger
+}
+
+func NewDirectController(service *services.ChatService, log *slog.Logger) *DirectController {
+	return &DirectController{
+		service: service,
+		log:     log,
+	}
+}
+
+// GetOrCreateDirectChat возвращает личный чат с пользователем
+//
+//	@Summary      Личный чат
+//	@Description  Возвращает личный чат текущего пользователя с указанным пользователем, создавая его при первом обращении
+//	@Tags         direct
+//	@Accept       json
+//	@Produce      json
+//	@Param        userId     path      string  true  "ID собеседника"
+//	@Param        X-User-Id  header    string  true  "ID текущего пользователя"
+//	@Success      200        {object}  dto.ChatResponse  "Чат уже существовал"
+//	@Success      201        {object}  dto.ChatResponse  "Чат создан"
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /dm/{userId} [post]
+func (c *DirectController) GetOrCreateDirectChat(ctx *gin.Context) {
+	userId, err := currentUserID(ctx)
+	if err != nil {
+		userIDError(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	peerId := ctx.Param("userId")
+	if !validUserID(peerId) {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidUserID, invalidUserIDError.Error())
+		return
+	}
+
+	chatResponse, created, err := c.service.GetOrCreateDirectChat(ctx, userId, peerId)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	ctx.Header("ETag", chatETag(chatResponse.Version))
+	ctx.JSON(status, chatResponse)
+}
@@ -0,0 +1,18 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/controllers/problem"
+)
+
+// userIDError отвечает на отсутствующий или некорректный заголовок X-User-Id
+func userIDError(ctx *gin.Context, status int, err error) {
+	code := problem.CodeInvalidUserID
+	if errors.Is(err, missingUserIDError) {
+		code = problem.CodeUserIDRequired
+	}
+	problem.Write(ctx, status, code, err.Error())
+}
@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var invalidIfMatchError = errors.New("invalid If-Match header")
+
+// ETag чата строится из его версии
+func chatETag(version int) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// parseIfMatch возвращает ожидаемую версию чата из заголовка If-Match.
+// Пустой заголовок и "*" означают отсутствие условия и возвращают 0.
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, invalidIfMatchError
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, invalidIfMatchError
+	}
+	return version, nil
+}
@@ -0,0 +1,165 @@
+package v1
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/dto"
+	"chat-project/internal/services"
+)
+
+type IncomingWebhookController struct {
+	service *services.IncomingWebhookService
+	log     *slog.Logger
+}
+
+func NewIncomingWebhookController(service *services.IncomingWebhookService, log *slog.Logger) *IncomingWebhookController {
+	return &IncomingWebhookController{
+		service: service,
+		log:     log,
+	}
+}
+
+// CreateIncomingWebhook создает входящий вебхук чата
+//
+//	@Summary      Создать входящий вебхук
+//	@Description  Выдает токен, по которому внешняя система публикует сообщения в чат. Токен возвращается только в этом ответе
+//	@Tags         incoming-webhooks
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int                    true  "ID чата"
+//	@Param        X-User-Id  header    string                 true  "ID владельца или администратора чата"
+//	@Param        webhook    body      dto.IncomingWebhookIn  true  "Параметры входящего вебхука"
+//	@Success      201        {object}  dto.IncomingWebhookCreatedResponse
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/incoming-webhooks [post]
+func (c *IncomingWebhookController) CreateIncomingWebhook(ctx *gin.Context) {
+	userId, chatId, _, ok := webhookParams(ctx, false)
+	if !ok {
+		return
+	}
+
+	var webhook dto.IncomingWebhookIn
+	if err := ctx.ShouldBindJSON(&webhook); err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
+		return
+	}
+
+	webhookResp, err := c.service.CreateIncomingWebhook(ctx, chatId, userId, webhook)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, webhookResp)
+}
+
+// ListIncomingWebhooks возвращает входящие вебхуки чата
+//
+//	@Summary      Список входящих вебхуков
+//	@Description  Возвращает входящие вебхуки чата без токенов
+//	@Tags         incoming-webhooks
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int     true  "ID чата"
+//	@Param        X-User-Id  header    string  true  "ID владельца или администратора чата"
+//	@Success      200        {object}  dto.IncomingWebhooksResponse
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/incoming-webhooks [get]
+func (c *IncomingWebhookController) ListIncomingWebhooks(ctx *gin.Context) {
+	userId, chatId, _, ok := webhookParams(ctx, false)
+	if !ok {
+		return
+	}
+
+	webhooksResp, err := c.service.ListIncomingWebhooks(ctx, chatId, userId)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhooksResp)
+}
+
+// DeleteIncomingWebhook удаляет входящий вебхук
+//
+//	@Summary      Удалить входящий вебхук
+//	@Description  Удаляет входящий вебхук, его токен сразу перестает действовать
+//	@Tags         incoming-webhooks
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int     true  "ID чата"
+//	@Param        webhookId  path      int     true  "ID входящего вебхука"
+//	@Param        X-User-Id  header    string  true  "ID владельца или администратора чата"
+//	@Success      204        "Входящий вебхук удален"
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат или входящий вебхук не найдены"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/incoming-webhooks/{webhookId} [delete]
+func (c *IncomingWebhookController) DeleteIncomingWebhook(ctx *gin.Context) {
+	userId, chatId, webhookId, ok := webhookParams(ctx, true)
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteIncomingWebhook(ctx, chatId, webhookId, userId); err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// PostMessage публикует сообщение по токену входящего вебхука
+//
+//	@Summary      Сообщение через входящий вебхук
+//	@Description  Публикует сообщение типа bot от имени вебхука. Принимает {"text": "..."} или совместимый со Slack формат с blocks и attachments, в том числе как поле payload формы. X-User-Id не нужен
+//	@Tags         incoming-webhooks
+//	@Accept       json
+//	@Accept       x-www-form-urlencoded
+//	@Produce      json
+//	@Param        token    path      string                 true  "Токен входящего вебхука"
+//	@Param        message  body      dto.IncomingMessageIn  true  "Сообщение"
+//	@Success      200      {object}  dto.MessageResponse
+//	@Failure      400      {object}  dto.ProblemResponse  "Неверный запрос или пустой текст"
+//	@Failure      404      {object}  dto.ProblemResponse  "Токен не найден"
+//	@Failure      429      {object}  dto.ProblemResponse  "Превышен лимит запросов"
+//	@Failure      500      {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /hooks/{token} [post]
+func (c *IncomingWebhookController) PostMessage(ctx *gin.Context) {
+	var message dto.IncomingMessageIn
+	var err error
+	// Slack-совместимые клиенты могут отправлять JSON в поле payload формы
+	if ctx.ContentType() == binding.MIMEPOSTForm {
+		err = json.Unmarshal([]byte(ctx.PostForm("payload")), &message)
+	} else {
+		err = ctx.ShouldBindJSON(&message)
+	}
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
+		return
+	}
+
+	msgResp, err := c.service.PostMessage(ctx, ctx.Param("token"), message)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, msgResp)
+}
@@ -0,0 +1,186 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/dto"
+	"chat-project/internal/services"
+)
+
+type InviteController struct {
+	service *services.InviteService
+	log     *slog.Logger
+}
+
+func NewInviteController(service *services.InviteService, log *slog.Logger) *InviteController {
+	return &InviteController{
+		service: service,
+		log:     log,
+	}
+}
+
+// CreateInvite создает приглашение в чат
+//
+//	@Summary      Создать приглашение
+//	@Description  Создает код приглашения в чат. Код возвращается только в этом ответе
+//	@Tags         invites
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int           true  "ID чата"
+//	@Param        X-User-Id  header    string        true  "ID владельца или администратора чата"
+//	@Param        invite     body      dto.InviteIn  true  "Параметры приглашения"
+//	@Success      201        {object}  dto.InviteCreatedResponse
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/invites [post]
+func (c *InviteController) CreateInvite(ctx *gin.Context) {
+	userId, err := currentUserID(ctx)
+	if err != nil {
+		userIDError(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	chatId, err := dto.ParseID(ctx.Param("chatId"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
+		return
+	}
+
+	var invite dto.InviteIn
+	if err := ctx.ShouldBindJSON(&invite); err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
+		return
+	}
+
+	inviteResp, err := c.service.CreateInvite(ctx, chatId, userId, invite)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, inviteResp)
+}
+
+// ListInvites возвращает приглашения чата
+//
+//	@Summary      Список приглашений
+//	@Description  Возвращает все приглашения чата, включая отозванные и истекшие
+//	@Tags         invites
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int     true  "ID чата"
+//	@Param        X-User-Id  header    string  true  "ID владельца или администратора чата"
+//	@Success      200        {object}  dto.InvitesResponse
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/invites [get]
+func (c *InviteController) ListInvites(ctx *gin.Context) {
+	userId, err := currentUserID(ctx)
+	if err != nil {
+		userIDError(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	chatId, err := dto.ParseID(ctx.Param("chatId"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
+		return
+	}
+
+	invitesResp, err := c.service.ListInvites(ctx, chatId, userId)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invitesResp)
+}
+
+// RevokeInvite отзывает приглашение
+//
+//	@Summary      Отозвать приглашение
+//	@Description  Отзывает приглашение, после этого его нельзя принять
+//	@Tags         invites
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int     true  "ID чата"
+//	@Param        inviteId   path      int     true  "ID приглашения"
+//	@Param        X-User-Id  header    string  true  "ID владельца или администратора чата"
+//	@Success      204        "Приглашение отозвано"
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат или приглашение не найдены"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/invites/{inviteId} [delete]
+func (c *InviteController) RevokeInvite(ctx *gin.Context) {
+	userId, err := currentUserID(ctx)
+	if err != nil {
+		userIDError(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	chatId, err := dto.ParseID(ctx.Param("chatId"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
+		return
+	}
+	inviteId, err := dto.ParseID(ctx.Param("inviteId"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidInviteID, "invalid invite ID")
+		return
+	}
+
+	if err := c.service.RevokeInvite(ctx, chatId, inviteId, userId); err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// AcceptInvite принимает приглашение
+//
+//	@Summary      Принять приглашение
+//	@Description  Добавляет текущего пользователя в чат с ролью из приглашения
+//	@Tags         invites
+//	@Accept       json
+//	@Produce      json
+//	@Param        code       path      string  true  "Код приглашения"
+//	@Param        X-User-Id  header    string  true  "ID текущего пользователя"
+//	@Success      200        {object}  dto.MemberResponse  "Пользователь уже участник"
+//	@Success      201        {object}  dto.MemberResponse  "Пользователь добавлен"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      404        {object}  dto.ProblemResponse  "Приглашение не найдено"
+//	@Failure      410        {object}  dto.ProblemResponse  "Приглашение истекло, отозвано или исчерпано"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /invites/{code}/accept [post]
+func (c *InviteController) AcceptInvite(ctx *gin.Context) {
+	userId, err := currentUserID(ctx)
+	if err != nil {
+		userIDError(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	memberResp, joined, err := c.service.AcceptInvite(ctx, ctx.Param("code"), userId)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	status := http.StatusOK
+	if joined {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, memberResp)
+}
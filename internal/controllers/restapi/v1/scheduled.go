@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/dto"
+	"chat-project/internal/services"
+)
+
+// scheduleMessage планирует сообщение из AddMessage. Запланированные сообщения есть только у пользователей
+func (c ChatController) scheduleMessage(ctx *gin.Context, chatId int, sender services.Sender, message dto.MessageIn) {
+	if sender.UserId == "" {
+		userIDError(ctx, http.StatusUnauthorized, missingUserIDError)
+		return
+	}
+
+	scheduledResp, err := c.service.ScheduleMessage(ctx, chatId, sender, message)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, scheduledResp)
+}
+
+// scheduledParams разбирает пользователя и идентификаторы из пути. false - ответ с ошибкой уже отправлен
+func scheduledParams(ctx *gin.Context, withScheduled bool) (userId string, chatId, scheduledId int, ok bool) {
+	userId, err := currentUserID(ctx)
+	if err != nil {
+		userIDError(ctx, http.StatusUnauthorized, err)
+		return "", 0, 0, false
+	}
+	chatId, err = dto.ParseID(ctx.Param("chatId"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
+		return "", 0, 0, false
+	}
+	if withScheduled {
+		scheduledId, err = dto.ParseID(ctx.Param("scheduledId"))
+		if err != nil {
+			problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidScheduledID, "invalid scheduled message ID")
+			return "", 0, 0, false
+		}
+	}
+	return userId, chatId, scheduledId, true
+}
+
+// ListScheduledMessages возвращает запланированные сообщения пользователя
+//
+//	@Summary      Список запланированных сообщений
+//	@Description  Возвращает еще не отправленные сообщения и напоминания пользователя в чате, ближайшие первыми
+//	@Tags         scheduled
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int     true  "ID чата"
+//	@Param        X-User-Id  header    string  true  "ID автора"
+//	@Success      200        {object}  dto.ScheduledMessagesResponse
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/scheduled-messages [get]
+func (c ChatController) ListScheduledMessages(ctx *gin.Context) {
+	userId, chatId, _, ok := scheduledParams(ctx, false)
+	if !ok {
+		return
+	}
+
+	scheduledResp, err := c.service.ListScheduledMessages(ctx, chatId, userId)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduledResp)
+}
+
+// UpdateScheduledMessage меняет запланированное сообщение
+//
+//	@Summary      Изменить запланированное сообщение
+//	@Description  Меняет текст или время отправки. Сообщение со статусом failed снова ставится в очередь
+//	@Tags         scheduled
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId       path      int                          true  "ID чата"
+//	@Param        scheduledId  path      int                          true  "ID запланированного сообщения"
+//	@Param        X-User-Id    header    string                       true  "ID автора"
+//	@Param        message      body      dto.ScheduledMessagePatchIn  true  "Изменяемые поля"
+//	@Success      200          {object}  dto.ScheduledMessageResponse
+//	@Failure      400          {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401          {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      404          {object}  dto.ProblemResponse  "Сообщение не найдено"
+//	@Failure      409          {object}  dto.ProblemResponse  "Сообщение уже отправляется"
+//	@Failure      500          {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/scheduled-messages/{scheduledId} [patch]
+func (c ChatController) UpdateScheduledMessage(ctx *gin.Context) {
+	userId, chatId, scheduledId, ok := scheduledParams(ctx, true)
+	if !ok {
+		return
+	}
+
+	var patch dto.ScheduledMessagePatchIn
+	if err := ctx.ShouldBindJSON(&patch); err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
+		return
+	}
+
+	scheduledResp, err := c.service.UpdateScheduledMessage(ctx, chatId, scheduledId, userId, patch)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduledResp)
+}
+
+// CancelScheduledMessage отменяет запланированное сообщение
+//
+//	@Summary      Отменить запланированное сообщение
+//	@Description  Удаляет сообщение, которое еще не отправлено
+//	@Tags         scheduled
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId       path      int     true  "ID чата"
+//	@Param        scheduledId  path      int     true  "ID запланированного сообщения"
+//	@Param        X-User-Id    header    string  true  "ID автора"
+//	@Success      204          "Сообщение отменено"
+//	@Failure      400          {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401          {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      404          {object}  dto.ProblemResponse  "Сообщение не найдено"
+//	@Failure      409          {object}  dto.ProblemResponse  "Сообщение уже отправляется"
+//	@Failure      500          {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/scheduled-messages/{scheduledId} [delete]
+func (c ChatController) CancelScheduledMessage(ctx *gin.Context) {
+	userId, chatId, scheduledId, ok := scheduledParams(ctx, true)
+	if !ok {
+		return
+	}
+
+	if err := c.service.CancelScheduledMessage(ctx, chatId, scheduledId, userId); err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/services"
+)
+
+// Идентификатор пользователя передает шлюз авторизации перед сервисом
+const UserIDHeader = "X-User-Id"
+
+var (
+	userIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]{1,64}$`)
+
+	missingUserIDError = errors.New(UserIDHeader + " header is required")
+	invalidUserIDError = errors.New("invalid user ID")
+)
+
+func validUserID(userId string) bool {
+	return userIDPattern.MatchString(userId)
+}
+
+// currentUserID возвращает идентификатор пользователя, выполняющего запрос
+func currentUserID(ctx *gin.Context) (string, error) {
+	userId := ctx.GetHeader(UserIDHeader)
+	if userId == "" {
+		return "", missingUserIDError
+	}
+	if !validUserID(userId) {
+		return "", invalidUserIDError
+	}
+	return userId, nil
+}
+
+// currentSender отправитель сообщения: пользователь, если есть заголовок, и адрес клиента
+func currentSender(ctx *gin.Context) (services.Sender, error) {
+	sender := services.Sender{Addr: ctx.ClientIP()}
+	userId, err := currentUserID(ctx)
+	if errors.Is(err, missingUserIDError) {
+		return sender, nil
+	}
+	if err != nil {
+		return services.Sender{}, err
+	}
+	sender.UserId = userId
+	return sender, nil
+}
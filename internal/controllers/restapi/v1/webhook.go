@@ -0,0 +1,226 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/dto"
+	"chat-project/internal/services"
+)
+
+type WebhookController struct {
+	service *services.WebhookService
+	log     *slog.Logger
+}
+
+func NewWebhookController(service *services.WebhookService, log *slog.Logger) *WebhookController {
+	return &WebhookController{
+		service: service,
+		log:     log,
+	}
+}
+
+// webhookParams разбирает пользователя и идентификаторы из пути. false - ответ с ошибкой уже отправлен
+func webhookParams(ctx *gin.Context, withWebhook bool) (userId string, chatId, webhookId int, ok bool) {
+	userId, err := currentUserID(ctx)
+	if err != nil {
+		userIDError(ctx, http.StatusUnauthorized, err)
+		return "", 0, 0, false
+	}
+	chatId, err = dto.ParseID(ctx.Param("chatId"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
+		return "", 0, 0, false
+	}
+	if withWebhook {
+		webhookId, err = dto.ParseID(ctx.Param("webhookId"))
+		if err != nil {
+			problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidWebhookID, "invalid webhook ID")
+			return "", 0, 0, false
+		}
+	}
+	return userId, chatId, webhookId, true
+}
+
+// CreateWebhook создает вебхук чата
+//
+//	@Summary      Создать вебхук
+//	@Description  Подписывает URL на события чата. Секрет подписи возвращается только в этом ответе
+//	@Tags         webhooks
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int            true  "ID чата"
+//	@Param        X-User-Id  header    string         true  "ID владельца или администратора чата"
+//	@Param        webhook    body      dto.WebhookIn  true  "Параметры вебхука"
+//	@Success      201        {object}  dto.WebhookCreatedResponse
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/webhooks [post]
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	userId, chatId, _, ok := webhookParams(ctx, false)
+	if !ok {
+		return
+	}
+
+	var webhook dto.WebhookIn
+	if err := ctx.ShouldBindJSON(&webhook); err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
+		return
+	}
+
+	webhookResp, err := c.service.CreateWebhook(ctx, chatId, userId, webhook)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, webhookResp)
+}
+
+// ListWebhooks возвращает вебхуки чата
+//
+//	@Summary      Список вебхуков
+//	@Description  Возвращает вебхуки чата без секретов
+//	@Tags         webhooks
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int     true  "ID чата"
+//	@Param        X-User-Id  header    string  true  "ID владельца или администратора чата"
+//	@Success      200        {object}  dto.WebhooksResponse
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат не найден"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/webhooks [get]
+func (c *WebhookController) ListWebhooks(ctx *gin.Context) {
+	userId, chatId, _, ok := webhookParams(ctx, false)
+	if !ok {
+		return
+	}
+
+	webhooksResp, err := c.service.ListWebhooks(ctx, chatId, userId)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhooksResp)
+}
+
+// DeleteWebhook удаляет вебхук
+//
+//	@Summary      Удалить вебхук
+//	@Description  Удаляет вебхук, еще не доставленные события отменяются
+//	@Tags         webhooks
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int     true  "ID чата"
+//	@Param        webhookId  path      int     true  "ID вебхука"
+//	@Param        X-User-Id  header    string  true  "ID владельца или администратора чата"
+//	@Success      204        "Вебхук удален"
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат или вебхук не найдены"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/webhooks/{webhookId} [delete]
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	userId, chatId, webhookId, ok := webhookParams(ctx, true)
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteWebhook(ctx, chatId, webhookId, userId); err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListDeliveries возвращает журнал доставок вебхука
+//
+//	@Summary      Журнал доставок
+//	@Description  Возвращает доставки событий вебхука, новые первыми
+//	@Tags         webhooks
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId     path      int     true   "ID чата"
+//	@Param        webhookId  path      int     true   "ID вебхука"
+//	@Param        X-User-Id  header    string  true   "ID владельца или администратора чата"
+//	@Param        status     query     string  false  "Статус: pending, delivered или dead"
+//	@Param        limit      query     int     false  "Количество доставок (по умолчанию 50, максимум 100)"
+//	@Param        offset     query     int     false  "Смещение"
+//	@Success      200        {object}  dto.WebhookDeliveriesResponse
+//	@Failure      400        {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401        {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403        {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404        {object}  dto.ProblemResponse  "Чат или вебхук не найдены"
+//	@Failure      500        {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/webhooks/{webhookId}/deliveries [get]
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	userId, chatId, webhookId, ok := webhookParams(ctx, true)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := dto.ParsePage(ctx.Query("limit"), ctx.Query("offset"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidPage, err.Error())
+		return
+	}
+
+	deliveriesResp, err := c.service.ListDeliveries(ctx, chatId, webhookId, userId, ctx.Query("status"), limit, offset)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveriesResp)
+}
+
+// RetryDelivery возвращает доставку в очередь
+//
+//	@Summary      Повторить доставку
+//	@Description  Возвращает в очередь доставку со статусом dead, счетчик попыток обнуляется
+//	@Tags         webhooks
+//	@Accept       json
+//	@Produce      json
+//	@Param        chatId      path      int     true  "ID чата"
+//	@Param        webhookId   path      int     true  "ID вебхука"
+//	@Param        deliveryId  path      int     true  "ID доставки"
+//	@Param        X-User-Id   header    string  true  "ID владельца или администратора чата"
+//	@Success      200         {object}  dto.WebhookDeliveryResponse
+//	@Failure      400         {object}  dto.ProblemResponse  "Неверный запрос"
+//	@Failure      401         {object}  dto.ProblemResponse  "Пользователь не указан"
+//	@Failure      403         {object}  dto.ProblemResponse  "Недостаточно прав"
+//	@Failure      404         {object}  dto.ProblemResponse  "Чат, вебхук или доставка не найдены"
+//	@Failure      409         {object}  dto.ProblemResponse  "Доставка не в статусе dead"
+//	@Failure      500         {object}  dto.ProblemResponse  "Внутренняя ошибка сервера"
+//	@Router       /chats/{chatId}/webhooks/{webhookId}/deliveries/{deliveryId}/retry [post]
+func (c *WebhookController) RetryDelivery(ctx *gin.Context) {
+	userId, chatId, webhookId, ok := webhookParams(ctx, true)
+	if !ok {
+		return
+	}
+	deliveryId, err := dto.ParseID(ctx.Param("deliveryId"))
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidDeliveryID, "invalid delivery ID")
+		return
+	}
+
+	deliveryResp, err := c.service.RetryDelivery(ctx, chatId, webhookId, deliveryId, userId)
+	if err != nil {
+		problem.Error(ctx, c.log, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveryResp)
+}
@@ -1,41 +1,105 @@
 package sse
 
 import (
+	"context"
 	"io"
+	"log/slog"
+	"net/http"
 	"strconv"
 
+	ginsse "github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 
+	"chat-project/internal/controllers/problem"
+	"chat-project/internal/domain"
+	"chat-project/internal/logger"
+	"chat-project/internal/metrics"
 	"chat-project/internal/services"
 )
 
-func NewRouter(app *gin.Engine, chatManager *services.ChatListenerManager) {
+// Сколько пропущенных сообщений досылается клиенту, переподключившемуся с Last-Event-ID
+const _replayLimit = 100
+
+const LastEventIDHeader = "Last-Event-ID"
+
+func NewRouter(app *gin.Engine, log *slog.Logger, metrics *metrics.Metrics, chatManager *services.ChatListenerManager, service *services.ChatService) {
 	router := app.Group("/sse")
 
 	sseController := &SSEController{
 		chatManager: chatManager,
+		service:     service,
+		log:         log,
+		metrics:     metrics,
 	}
 
-	router.GET("/sse", HeadersMiddleware(), sseController.serveHTTP(), func(c *gin.Context) {
-		v, ok := c.Get("clientChan")
-		if !ok {
-			return
-		}
-		clientChan, ok := v.(services.ClientConn)
-		if !ok {
-			return
+	router.GET("/sse", HeadersMiddleware(), sseController.serveHTTP(), sseController.stream)
+}
+
+func (sse *SSEController) stream(c *gin.Context) {
+	v, ok := c.Get("clientChan")
+	if !ok {
+		return
+	}
+	clientChan, ok := v.(services.ClientConn)
+	if !ok {
+		return
+	}
+
+	// Сначала досылаем пропущенные сообщения, затем живые события без повторов
+	replayed := make(map[int]bool)
+	if v, ok := c.Get("replay"); ok {
+		for _, msg := range v.([]domain.Message) {
+			c.Render(-1, newEvent(domain.NewMessageCreatedEvent(msg)))
+			replayed[msg.ID] = true
 		}
-		c.Stream(func(w io.Writer) bool {
-			// Stream message to client from message channel
-			if msg, ok := <-clientChan; ok {
-				c.SSEvent("message", msg)
+	}
+	// Заголовки отправляются сразу, чтобы клиент знал, что подписка установлена, до первого события
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		// Stream message to client from message channel
+		if event, ok := <-clientChan; ok {
+			if event.Type == domain.EventMessageCreated && event.Message != nil && replayed[event.Message.ID] {
 				return true
 			}
-			return false
-		})
+			c.Render(-1, newEvent(event))
+			return true
+		}
+		return false
 	})
 }
 
+// newEvent формирует SSE событие. Новые сообщения получают id, равный id сообщения,
+// чтобы клиент мог переподключиться с Last-Event-ID
+func newEvent(event domain.Event) ginsse.Event {
+	sseEvent := ginsse.Event{
+		Event: eventName(event),
+		Data:  eventData(event),
+	}
+	if event.Type == domain.EventMessageCreated && event.Message != nil {
+		sseEvent.Id = strconv.Itoa(event.Message.ID)
+	}
+	return sseEvent
+}
+
+// Новые сообщения отправляются событием "message" с сообщением в данных, как и раньше,
+// остальные события чата отправляются под своим типом
+func eventName(event domain.Event) string {
+	if event.Type == domain.EventMessageCreated {
+		return "message"
+	}
+	return string(event.Type)
+}
+
+func eventData(event domain.Event) any {
+	if event.Type == domain.EventMessageCreated && event.Message != nil {
+		return event.Message
+	}
+	// Контекст трассировки внутренний и клиентам не отправляется
+	event.Trace = nil
+	return event
+}
+
 func HeadersMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Content-Type", "text/event-stream")
@@ -48,6 +112,9 @@ func HeadersMiddleware() gin.HandlerFunc {
 
 type SSEController struct {
 	chatManager *services.ChatListenerManager
+	service     *services.ChatService
+	log         *slog.Logger
+	metrics     *metrics.Metrics
 }
 
 func (sse *SSEController) serveHTTP() gin.HandlerFunc {
@@ -56,34 +123,90 @@ func (sse *SSEController) serveHTTP() gin.HandlerFunc {
 
 		chatIdStr := c.Query("chatId")
 		if chatIdStr == "" {
-			c.AbortWithStatus(400)
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidChatID, "chatId is required")
 			return
 		}
 
 		chatId, err := strconv.Atoi(chatIdStr)
 		if err != nil {
-			c.AbortWithStatus(400)
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidChatID, "invalid chat ID")
 			return
 		}
 
-		clientChan := make(services.ClientConn)
+		// Браузерный EventSource передает Last-Event-ID заголовком, остальные клиенты могут передать параметром
+		lastEventIdStr := c.GetHeader(LastEventIDHeader)
+		if lastEventIdStr == "" {
+			lastEventIdStr = c.Query("lastEventId")
+		}
+		lastEventId := 0
+		if lastEventIdStr != "" {
+			lastEventId, err = strconv.Atoi(lastEventIdStr)
+			if err != nil {
+				problem.Write(c, http.StatusBadRequest, problem.CodeInvalidEventID, "invalid Last-Event-ID")
+				return
+			}
+		}
+
+		clientChan := services.NewClientConn()
+
+		ctx := logger.WithAttrs(c.Request.Context(), slog.Int("chat_id", chatId))
+		c.Request = c.Request.WithContext(ctx)
+
+		// Подписка на приватный или личный чат доступна только участникам
+		if err := sse.service.CheckAccess(ctx, chatId, c.GetHeader("X-User-Id")); err != nil {
+			sse.log.WarnContext(ctx, "sse subscription rejected", slog.Any("error", err))
+			problem.Error(c, sse.log, err)
+			return
+		}
+
+		// Лимит частоты sse ограничивает только новые подключения, открытые подключения адреса считает менеджер
+		client := c.ClientIP()
+		if err := sse.chatManager.Connect(client); err != nil {
+			sse.log.WarnContext(ctx, "sse subscription rejected", slog.Any("error", err))
+			problem.Error(c, sse.log, err)
+			return
+		}
 
 		// Send new connection to event server
-		chatListener, err := sse.chatManager.GetChatListener(chatId)
+		chatListener, err := sse.chatManager.Acquire(ctx, chatId)
 		if err != nil {
-			c.AbortWithStatus(404)
+			sse.chatManager.Disconnect(client)
+			sse.log.WarnContext(ctx, "sse subscription rejected", slog.Any("error", err))
+			problem.Error(c, sse.log, err)
 			return
 		}
 
-		chatListener.AddClient(c, clientChan)
+		if err := chatListener.AddClient(ctx, clientChan); err != nil {
+			sse.chatManager.Release(chatListener)
+			sse.chatManager.Disconnect(client)
+			sse.log.WarnContext(ctx, "sse subscription rejected", slog.Any("error", err))
+			problem.Error(c, sse.log, err)
+			return
+		}
+		sse.log.InfoContext(ctx, "sse client connected", slog.Int("last_event_id", lastEventId))
+		sse.metrics.SSEConnected(chatId)
 
 		go func() {
-			<-c.Request.Context().Done()
-			chatListener.RemoveClient(c, clientChan)
+			<-ctx.Done()
+			chatListener.RemoveClient(context.Background(), clientChan)
+			sse.chatManager.Release(chatListener)
+			sse.chatManager.Disconnect(client)
+			sse.log.InfoContext(ctx, "sse client disconnected")
+			sse.metrics.SSEDisconnected(chatId)
 		}()
 
 		c.Set("clientChan", clientChan)
 
+		// Клиент уже подписан, поэтому сообщения, пришедшие во время выборки, не потеряются
+		if lastEventId != 0 {
+			replay, err := sse.service.MessagesAfter(ctx, chatId, lastEventId, _replayLimit)
+			if err != nil {
+				sse.log.WarnContext(ctx, "unable to replay missed messages", slog.Int("last_event_id", lastEventId), slog.Any("error", err))
+			} else {
+				c.Set("replay", replay)
+			}
+		}
+
 		c.Next()
 	}
 }
@@ -2,10 +2,76 @@ package domain
 
 import "time"
 
+type ChatType string
 
-type Chat struct{
-	ID 		  int 			`json:"Id"       example:"125216"`
-	Title 	  string		`json:"Title"    example:"Тестовый чат"`
-	CreatedAt time.Time		`json:"source"`
-	Messages []Message		`json:"messages"`
+const (
+	ChatTypeGroup  ChatType = "group"
+	ChatTypeDirect ChatType = "direct"
+)
+
+type Chat struct {
+	ID          int          `json:"Id"       example:"125216"`
+	Type        ChatType     `json:"Type"`
+	Title       string       `json:"Title"    example:"Тестовый чат"`
+	Private     bool         `json:"Private"`
+	Description string       `json:"Description"`
+	AvatarURL   string       `json:"AvatarUrl"`
+	Settings    ChatSettings `json:"Settings"`
+	Version     int          `json:"Version"`
+	CreatedAt   time.Time    `json:"source"`
+	Messages    []Message    `json:"messages"`
+	// Удержание по требованию юристов: сообщения не удаляются по сроку хранения
+	LegalHold bool `json:"LegalHold"`
+}
+
+// Настройки чата, хранятся в jsonb
+type ChatSettings struct {
+	// Минимальный интервал между сообщениями одного клиента, 0 - без ограничений
+	SlowModeSeconds int  `json:"SlowModeSeconds"`
+	ReadOnly        bool `json:"ReadOnly"`
+	// Срок хранения сообщений в днях, 0 - срок по умолчанию из конфигурации, RetentionForever - хранить всегда
+	RetentionDays int `json:"RetentionDays"`
+}
+
+const RetentionForever = -1
+
+// Retention срок хранения сообщений чата в днях с учетом срока по умолчанию, 0 - хранить всегда
+func (s ChatSettings) Retention(defaultDays int) int {
+	switch {
+	case s.RetentionDays == RetentionForever:
+		return 0
+	case s.RetentionDays > 0:
+		return s.RetentionDays
+	case defaultDays > 0:
+		return defaultDays
+	default:
+		return 0
+	}
+}
+
+// Участник чата
+type ChatMember struct {
+	ChatId   int       `json:"ChatId"`
+	UserId   string    `json:"UserId"`
+	Role     string    `json:"Role"`
+	JoinedAt time.Time `json:"JoinedAt"`
+}
+
+const (
+	ChatRoleOwner  = "owner"
+	ChatRoleAdmin  = "admin"
+	ChatRoleMember = "member"
+)
+
+// CanManage сообщает, может ли участник управлять чатом и его приглашениями
+func (m ChatMember) CanManage() bool {
+	return m.Role == ChatRoleOwner || m.Role == ChatRoleAdmin
+}
+
+// DirectChatKey однозначно определяет личный чат пары пользователей независимо от порядка
+func DirectChatKey(userA, userB string) string {
+	if userA > userB {
+		userA, userB = userB, userA
+	}
+	return userA + ":" + userB
 }
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Классы доменных ошибок, проверяются через errors.Is
+var (
+	NotFoundError    = errors.New("not found")
+	ConflictError    = errors.New("conflict")
+	ValidationError  = errors.New("validation failed")
+	ForbiddenError   = errors.New("forbidden")
+	UnavailableError = errors.New("unavailable")
+	RateLimitedError = errors.New("rate limited")
+)
+
+// Entity сущность, к которой относится ошибка
+type Entity string
+
+const (
+	EntityChat            Entity = "chat"
+	EntityMessage         Entity = "message"
+	EntityMember          Entity = "member"
+	EntityInvite          Entity = "invite"
+	EntityListener        Entity = "listener"
+	EntityWebhook         Entity = "webhook"
+	EntityDelivery        Entity = "webhook_delivery"
+	EntityIncomingWebhook Entity = "incoming_webhook"
+	EntityScheduled       Entity = "scheduled_message"
+)
+
+// Error доменная ошибка: класс Kind, сущность и ее идентификатор.
+// Reason уточняет причину, например конфликт версий, и тоже находится через errors.Is.
+// Текст ошибки строится только из этих полей, поэтому его можно показывать клиенту.
+type Error struct {
+	Kind   error
+	Entity Entity
+	ID     string
+	Reason error
+}
+
+func (e *Error) Error() string {
+	subject := string(e.Entity)
+	if e.ID != "" {
+		subject += " " + e.ID
+	}
+	if e.Reason != nil {
+		if subject == "" {
+			return e.Reason.Error()
+		}
+		return subject + ": " + e.Reason.Error()
+	}
+	if subject == "" {
+		return e.Kind.Error()
+	}
+	return subject + " " + e.Kind.Error()
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Reason
+}
+
+func newError(kind error, entity Entity, id any, reason error) *Error {
+	e := &Error{Kind: kind, Entity: entity, Reason: reason}
+	if id != nil {
+		e.ID = fmt.Sprint(id)
+	}
+	return e
+}
+
+// NotFound сущность с идентификатором id не существует. id может быть nil
+func NotFound(entity Entity, id any) *Error {
+	return newError(NotFoundError, entity, id, nil)
+}
+
+// Conflict состояние сущности не позволяет выполнить операцию
+func Conflict(entity Entity, id any, reason error) *Error {
+	return newError(ConflictError, entity, id, reason)
+}
+
+// Validation входные данные для сущности некорректны
+func Validation(entity Entity, reason error) *Error {
+	return newError(ValidationError, entity, nil, reason)
+}
+
+// Forbidden у пользователя нет прав на операцию с сущностью
+func Forbidden(entity Entity, id any, reason error) *Error {
+	return newError(ForbiddenError, entity, id, reason)
+}
+
+// Unavailable операция временно невозможна, например сервис останавливается
+func Unavailable(entity Entity, reason error) *Error {
+	return newError(UnavailableError, entity, nil, reason)
+}
+
+// RateLimited операция отклонена лимитом частоты запросов
+func RateLimited(entity Entity, id any, reason error) *Error {
+	return newError(RateLimitedError, entity, id, reason)
+}
+
+// IsNotFound сообщает, что err означает отсутствие сущности entity
+func IsNotFound(err error, entity Entity) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Kind == NotFoundError && e.Entity == entity
+}
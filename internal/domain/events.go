@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+type EventType string
+
+const (
+	EventMessageCreated EventType = "message.created"
+	EventChatUpdated    EventType = "chat.updated"
+	EventChatDeleted    EventType = "chat.deleted"
+	// Сообщения удалены по сроку хранения, их id в MessageIds
+	EventMessageDeleted EventType = "message.deleted"
+	// Истек TTL самоуничтожающихся сообщений, их id в MessageIds
+	EventMessageExpired EventType = "message.expired"
+)
+
+// Событие чата, которое рассылается через ChatListener всем подписчикам
+type Event struct {
+	Type       EventType `json:"type"`
+	ChatId     int       `json:"chatId"`
+	Message    *Message  `json:"message,omitempty"`
+	Chat       *Chat     `json:"chat,omitempty"`
+	MessageIds []int     `json:"messageIds,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	// Контекст трассировки запроса, опубликовавшего событие (W3C traceparent)
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+func NewMessageCreatedEvent(message Message) Event {
+	return Event{
+		Type:      EventMessageCreated,
+		ChatId:    message.ChatId,
+		Message:   &message,
+		CreatedAt: time.Now(),
+	}
+}
+
+func NewChatUpdatedEvent(chat Chat) Event {
+	return Event{
+		Type:      EventChatUpdated,
+		ChatId:    chat.ID,
+		Chat:      &chat,
+		CreatedAt: time.Now(),
+	}
+}
+
+func NewChatDeletedEvent(chatId int) Event {
+	return Event{
+		Type:      EventChatDeleted,
+		ChatId:    chatId,
+		CreatedAt: time.Now(),
+	}
+}
+
+func NewMessagesDeletedEvent(chatId int, messageIds []int) Event {
+	return Event{
+		Type:       EventMessageDeleted,
+		ChatId:     chatId,
+		MessageIds: messageIds,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func NewMessagesExpiredEvent(chatId int, messageIds []int) Event {
+	return Event{
+		Type:       EventMessageExpired,
+		ChatId:     chatId,
+		MessageIds: messageIds,
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// Приглашение в чат. Сам код приглашения не хранится, только его хэш
+type Invite struct {
+	ID        int
+	ChatId    int
+	TokenHash []byte
+	Role      string
+	// Максимальное количество использований, 0 - без ограничений
+	MaxUses   int
+	Uses      int
+	ExpiresAt *time.Time
+	CreatedBy string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
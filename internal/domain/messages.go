@@ -2,9 +2,35 @@ package domain
 
 import "time"
 
+type MessageKind string
+
+const (
+	MessageKindUser MessageKind = "user"
+	// Служебное сообщение, например объявление оператора
+	MessageKindSystem MessageKind = "system"
+	// Сообщение внешней системы через входящий вебхук, AuthorId - имя бота
+	MessageKindBot MessageKind = "bot"
+)
+
 type Message struct {
-	ID        int       `json:"Id"        example:"125216"`
-	ChatId    int       `json:"ChatId"    example:"125216"`
-	Text      string    `json:"Text"      example:"Hello world!"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID     int         `json:"Id"        example:"125216"`
+	ChatId int         `json:"ChatId"    example:"125216"`
+	Kind   MessageKind `json:"Kind"      example:"user"`
+	Text   string      `json:"Text"      example:"Hello world!"`
+	// Автор из X-User-Id, пустой у системных и анонимных сообщений
+	AuthorId string `json:"AuthorId,omitempty" example:"alice"`
+	// Идентификатор, который клиент присвоил сообщению до отправки, уникален для автора в чате
+	ClientNonce string `json:"ClientNonce,omitempty" example:"7f9c2b1e-4d3a-4c8e-9b6f-2a1d0e5c8f3b"`
+	// Пользователь, вызвавший слэш-команду, у ответа бота. ClientNonce ответа уникален для него, а не для бота
+	InvokedBy string `json:"-"`
+	// Запланированное сообщение, из которого отправлено это, nil у остальных сообщений
+	ScheduledId *int      `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Время исчезновения самоуничтожающегося сообщения, nil - хранится как обычно
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Expired сообщение с TTL, время которого наступило к now
+func (m Message) Expired(now time.Time) bool {
+	return m.ExpiresAt != nil && !m.ExpiresAt.After(now)
 }
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+type ScheduledStatus string
+
+const (
+	ScheduledPending ScheduledStatus = "pending"
+	// Попытки отправки исчерпаны или ошибка не исправится повтором. Автор может изменить сообщение или отменить его
+	ScheduledFailed ScheduledStatus = "failed"
+)
+
+// Запланированное сообщение хранится отдельно от сообщений чата и удаляется после отправки.
+// CreatedBy - пользователь, который может изменить или отменить сообщение. У напоминаний он отличается от AuthorId
+type ScheduledMessage struct {
+	ID            int
+	ChatId        int
+	Kind          MessageKind
+	AuthorId      string
+	CreatedBy     string
+	Text          string
+	SendAt        time.Time
+	Status        ScheduledStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+// Вебхук чата: события чата отправляются POST запросом на URL с подписью HMAC-SHA256 по Secret
+type Webhook struct {
+	ID     int
+	ChatId int
+	URL    string
+	Secret string
+	// Типы событий, на которые подписан вебхук. Пустой список - все события
+	Events    []EventType
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// Subscribed сообщает, нужно ли отправлять вебхуку событие этого типа
+func (w Webhook) Subscribed(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, t := range w.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvents типы событий, на которые можно подписать вебхук
+var WebhookEvents = []EventType{EventMessageCreated, EventMessageDeleted, EventMessageExpired, EventChatUpdated, EventChatDeleted}
+
+// Входящий вебхук: внешняя система публикует сообщения в чат по токену, от имени бота Name.
+// Сам токен не хранится, только его хэш
+type IncomingWebhook struct {
+	ID        int
+	ChatId    int
+	Name      string
+	TokenHash []byte
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+type DeliveryStatus string
+
+const (
+	// Ожидает первой попытки или повтора в NextAttemptAt
+	DeliveryPending DeliveryStatus = "pending"
+	// Получатель ответил 2xx
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// Попытки исчерпаны, доставка больше не повторяется без ручного повтора
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Доставка одного события одному вебхуку. URL и Secret копируются из вебхука при постановке в очередь
+type WebhookDelivery struct {
+	ID int
+	// 0, если вебхук уже удален вместе с чатом
+	WebhookId      int
+	ChatId         int
+	URL            string
+	Secret         string
+	EventType      EventType
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
@@ -0,0 +1,13 @@
+package dto
+
+type ListenerStatsResponse struct {
+	ChatId  int `json:"chatId"`
+	Clients int `json:"clients"`
+	Refs    int `json:"refs"`
+}
+
+type ListenersResponse struct {
+	Listeners int                     `json:"listeners"`
+	Clients   int                     `json:"clients"`
+	Chats     []ListenerStatsResponse `json:"chats"`
+}
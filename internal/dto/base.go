@@ -12,3 +12,30 @@ func ParseID(idParam string) (int, error) {
 	}
 	return id, nil
 }
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+// ParsePage разбирает параметры пагинации limit и offset
+func ParsePage(limitParam, offsetParam string) (int, int, error) {
+	limit, offset := DefaultPageLimit, 0
+
+	if limitParam != "" {
+		l, err := ParseID(limitParam)
+		if err != nil || l <= 0 || l > MaxPageLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+		}
+		limit = l
+	}
+	if offsetParam != "" {
+		o, err := ParseID(offsetParam)
+		if err != nil || o < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative number")
+		}
+		offset = o
+	}
+
+	return limit, offset, nil
+}
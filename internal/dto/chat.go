@@ -1,20 +1,61 @@
 package dto
 
+// ChatIn данные нового чата. Строки нормализуются и проверяются пакетом validation по тегам normalize и validate
 type ChatIn struct {
-	Title string `json:"Title"    example:"Тестовый чат"`
+	Title       string        `json:"Title"    example:"Тестовый чат" normalize:"" validate:"notempty,maxlen=title,singleline"`
+	Private     bool          `json:"Private"  example:"false"`
+	Description string        `json:"Description" example:"Обсуждение релиза" normalize:"" validate:"maxlen=description,nocontrol"`
+	AvatarURL   string        `json:"AvatarUrl" example:"https://example.com/avatar.png" normalize:"" validate:"omitempty,maxlen=avatar_url,http_url"`
+	Settings    *ChatSettings `json:"Settings" validate:"omitnil"`
+}
+
+// ChatPatchIn частичное обновление чата, изменяются только переданные поля
+type ChatPatchIn struct {
+	Title       *string            `json:"Title"    example:"Новое название" normalize:"" validate:"omitnil,notempty,maxlen=title,singleline"`
+	Private     *bool              `json:"Private"  example:"true"`
+	Description *string            `json:"Description" example:"Обсуждение релиза" normalize:"" validate:"omitnil,maxlen=description,nocontrol"`
+	AvatarURL   *string            `json:"AvatarUrl" example:"https://example.com/avatar.png" normalize:"" validate:"omitnil,omitempty,maxlen=avatar_url,http_url"`
+	Settings    *ChatSettingsPatch `json:"Settings" validate:"omitnil"`
+}
+
+type ChatSettings struct {
+	SlowModeSeconds int  `json:"SlowModeSeconds" example:"10" validate:"gte=0"`
+	ReadOnly        bool `json:"ReadOnly"        example:"false"`
+	// Срок хранения сообщений в днях: 0 - срок по умолчанию сервера, -1 - хранить всегда
+	RetentionDays int `json:"RetentionDays" example:"30" validate:"gte=-1"`
+}
+
+type ChatSettingsPatch struct {
+	SlowModeSeconds *int  `json:"SlowModeSeconds" example:"10" validate:"omitnil,gte=0"`
+	ReadOnly        *bool `json:"ReadOnly"        example:"false"`
+	RetentionDays   *int  `json:"RetentionDays"   example:"30" validate:"omitnil,gte=-1"`
 }
 
 type ChatResponse struct {
-	Title     string `json:"Title"    example:"Тестовый чат"`
-	ID        int    `json:"Id"       example:"125216"`
-	CreatedAt string `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
+	Title       string       `json:"Title"    example:"Тестовый чат"`
+	ID          int          `json:"Id"       example:"125216"`
+	Type        string       `json:"Type"     example:"group"`
+	Private     bool         `json:"Private"  example:"false"`
+	Description string       `json:"Description" example:"Обсуждение релиза"`
+	AvatarURL   string       `json:"AvatarUrl" example:"https://example.com/avatar.png"`
+	Settings    ChatSettings `json:"Settings"`
+	LegalHold   bool         `json:"LegalHold" example:"false"`
+	Version     int          `json:"Version"  example:"1"`
+	CreatedAt   string       `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
 }
 
 type ChatWithMessagesResponse struct {
-	Title     string            `json:"Title" example:"Тестовый чат"`
-	ID        int               `json:"Id" example:"125216"`
-	CreatedAt string            `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
-	Messages  []MessageResponse `json:"messages"`
+	Title       string            `json:"Title" example:"Тестовый чат"`
+	ID          int               `json:"Id" example:"125216"`
+	Type        string            `json:"Type" example:"group"`
+	Private     bool              `json:"Private" example:"false"`
+	Description string            `json:"Description" example:"Обсуждение релиза"`
+	AvatarURL   string            `json:"AvatarUrl" example:"https://example.com/avatar.png"`
+	Settings    ChatSettings      `json:"Settings"`
+	LegalHold   bool              `json:"LegalHold" example:"false"`
+	Version     int               `json:"Version" example:"1"`
+	CreatedAt   string            `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
+	Messages    []MessageResponse `json:"messages"`
 }
 
 type ChatsResponse struct {
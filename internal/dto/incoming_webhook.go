@@ -0,0 +1,55 @@
+package dto
+
+type IncomingWebhookIn struct {
+	// Имя бота, от которого публикуются сообщения
+	Name string `json:"Name" example:"ci" normalize:"" validate:"notempty,max=64,singleline"`
+}
+
+type IncomingWebhookResponse struct {
+	ID        int    `json:"Id"        example:"3"`
+	ChatId    int    `json:"ChatId"    example:"125216"`
+	Name      string `json:"Name"      example:"ci"`
+	CreatedBy string `json:"CreatedBy" example:"user-1"`
+	CreatedAt string `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
+}
+
+// IncomingWebhookCreatedResponse содержит токен, он возвращается только один раз
+type IncomingWebhookCreatedResponse struct {
+	IncomingWebhookResponse
+	Token string `json:"Token" example:"Jk1QyQnP2hQm1oX3aE4pQm0yQm6m4bQ0y3hN2y8m2e0"`
+	// Путь для публикации сообщений, POST без X-User-Id
+	Path string `json:"Path" example:"/v1/hooks/Jk1QyQnP2hQm1oX3aE4pQm0yQm6m4bQ0y3hN2y8m2e0"`
+}
+
+type IncomingWebhooksResponse struct {
+	Webhooks []IncomingWebhookResponse `json:"webhooks"`
+}
+
+// IncomingMessageIn сообщение входящего вебхука: простой {"text": "..."} или совместимый со Slack формат.
+// Из blocks и attachments берется только текст
+type IncomingMessageIn struct {
+	Text        string               `json:"text" example:"Build #42 passed"`
+	Blocks      []IncomingBlock      `json:"blocks,omitempty"`
+	Attachments []IncomingAttachment `json:"attachments,omitempty"`
+}
+
+type IncomingBlock struct {
+	// section, header, context и т.д. Блоки без текста пропускаются
+	Type     string               `json:"type" example:"section"`
+	Text     *IncomingTextObject  `json:"text,omitempty"`
+	Fields   []IncomingTextObject `json:"fields,omitempty"`
+	Elements []IncomingTextObject `json:"elements,omitempty"`
+}
+
+type IncomingTextObject struct {
+	// plain_text или mrkdwn, разметка не преобразуется
+	Type string `json:"type" example:"mrkdwn"`
+	Text string `json:"text" example:"*Build #42* passed"`
+}
+
+type IncomingAttachment struct {
+	Fallback string `json:"fallback" example:"Build #42 passed"`
+	Pretext  string `json:"pretext"`
+	Title    string `json:"title"    example:"Build #42"`
+	Text     string `json:"text"     example:"All tests passed"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+type InviteIn struct {
+	// Роль, которую получит принявший приглашение: member или admin
+	Role string `json:"Role"      example:"member"`
+	// Максимальное количество использований, 0 - без ограничений
+	MaxUses int `json:"MaxUses"   example:"10"`
+	// Время жизни приглашения в секундах, 0 - бессрочно
+	ExpiresIn int `json:"ExpiresIn" example:"86400"`
+}
+
+type InviteResponse struct {
+	ID        int    `json:"Id"        example:"12"`
+	ChatId    int    `json:"ChatId"    example:"125216"`
+	Role      string `json:"Role"      example:"member"`
+	MaxUses   int    `json:"MaxUses"   example:"10"`
+	Uses      int    `json:"Uses"      example:"3"`
+	ExpiresAt string `json:"ExpiresAt" example:"2024-01-02T12:00:00Z"`
+	CreatedBy string `json:"CreatedBy" example:"user-1"`
+	CreatedAt string `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
+	Revoked   bool   `json:"Revoked"   example:"false"`
+}
+
+// InviteCreatedResponse содержит код приглашения, он возвращается только один раз
+type InviteCreatedResponse struct {
+	InviteResponse
+	Code string `json:"Code" example:"q2Lr0yQm6m4bQ0y3hN2y8m2e0Jk1QyQnP2hQm1oX3aE"`
+}
+
+type InvitesResponse struct {
+	Invites []InviteResponse `json:"invites"`
+}
+
+type MemberResponse struct {
+	ChatId   int    `json:"ChatId"   example:"125216"`
+	UserId   string `json:"UserId"   example:"user-2"`
+	Role     string `json:"Role"     example:"member"`
+	JoinedAt string `json:"JoinedAt" example:"2024-01-01T12:00:00Z"`
+}
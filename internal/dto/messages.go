@@ -1,14 +1,29 @@
 package dto
 
+import "time"
+
 type MessageIn struct {
-	Text    string `json:"Text"      example:"Hello world!"`
+	Text    string `json:"Text"      example:"Hello world!" normalize:"" validate:"notempty,maxlen=message,nocontrol"`
+	// Необязательный идентификатор сообщения на клиенте, возвращается в ответе и в событии SSE
+	ClientNonce string `json:"ClientNonce,omitempty" example:"7f9c2b1e-4d3a-4c8e-9b6f-2a1d0e5c8f3b" normalize:"" validate:"omitempty,max=64,printascii"`
+	// Время отправки. Если задано, сообщение планируется, а не отправляется сразу
+	SendAt *time.Time `json:"SendAt,omitempty" example:"2024-01-01T09:00:00Z"`
+	// Время жизни в секундах, не больше недели. После него сообщение исчезает из чата и удаляется
+	TtlSeconds int `json:"TtlSeconds,omitempty" example:"60" validate:"gte=0,lte=604800"`
 }
 
 type MessageResponse struct {
 	ID        int    `json:"Id"        example:"125216"`
 	ChatId   int    `json:"ChatId"    example:"125216"`
+	Kind      string `json:"Kind"      example:"user"`
 	Text      string `json:"Text"      example:"Hello world!"`
+	AuthorId    string `json:"AuthorId,omitempty"    example:"alice"`
+	ClientNonce string `json:"ClientNonce,omitempty" example:"7f9c2b1e-4d3a-4c8e-9b6f-2a1d0e5c8f3b"`
+	// Ответ слэш-команды только для отправителя: не сохраняется, Id равен 0
+	Ephemeral bool   `json:"Ephemeral,omitempty" example:"false"`
 	CreatedAt string `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
+	// Время исчезновения сообщения с TtlSeconds
+	ExpiresAt string `json:"ExpiresAt,omitempty" example:"2024-01-01T12:01:00Z"`
 }
 
 type MessagesResponse struct {
@@ -0,0 +1,23 @@
+package dto
+
+// ProblemResponse ответ с ошибкой в формате RFC 7807 (application/problem+json).
+// Code стабильный машиночитаемый код, по нему клиенты различают ошибки.
+type ProblemResponse struct {
+	Type     string `json:"type"               example:"about:blank"`
+	Title    string `json:"title"              example:"Not Found"`
+	Status   int    `json:"status"             example:"404"`
+	Detail   string `json:"detail,omitempty"   example:"chat not found"`
+	Instance string `json:"instance,omitempty" example:"/v1/chats/125216/messages"`
+	Code     string `json:"code"               example:"chat_not_found"`
+	// Идентификатор трассировки запроса, по нему ошибку можно найти в логах
+	TraceId string `json:"traceId,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	// Ошибки отдельных полей для кода validation_failed
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError ошибка проверки одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"   example:"Title"`
+	Code    string `json:"code"    example:"too_long"`
+	Message string `json:"message" example:"must be at most 255 characters"`
+}
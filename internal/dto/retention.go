@@ -0,0 +1,24 @@
+package dto
+
+// RetentionReport сообщения, которые удалит следующий запуск удаления по сроку хранения
+type RetentionReport struct {
+	DefaultDays int                   `json:"DefaultDays" example:"365"`
+	Now         string                `json:"Now"         example:"2024-01-01T12:00:00Z"`
+	Chats       []RetentionReportItem `json:"Chats"`
+	// Сколько сообщений будет удалено, без удерживаемых чатов
+	Expired int64 `json:"Expired" example:"1200"`
+}
+
+// RetentionReportItem чат со сроком хранения или удержанием
+type RetentionReportItem struct {
+	ChatId int    `json:"ChatId" example:"125216"`
+	Title  string `json:"Title"  example:"Тестовый чат"`
+	// Действующий срок в днях, 0 - хранить всегда
+	RetentionDays int `json:"RetentionDays" example:"30"`
+	// chat - срок из настроек чата, default - срок по умолчанию
+	Source    string `json:"Source"    example:"chat"`
+	LegalHold bool   `json:"LegalHold" example:"false"`
+	// Удаляются сообщения, созданные раньше Before
+	Before  string `json:"Before,omitempty" example:"2023-12-02T12:00:00Z"`
+	Expired int64  `json:"Expired"          example:"40"`
+}
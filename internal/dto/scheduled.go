@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// ScheduledMessagePatchIn изменение запланированного сообщения. Пустые поля не меняются
+type ScheduledMessagePatchIn struct {
+	Text   *string    `json:"Text"   example:"Release at 18:00" normalize:"" validate:"omitnil,notempty,maxlen=message,nocontrol"`
+	SendAt *time.Time `json:"SendAt" example:"2024-01-01T09:00:00Z"`
+}
+
+type ScheduledMessageResponse struct {
+	ID       int    `json:"Id"       example:"12"`
+	ChatId   int    `json:"ChatId"   example:"125216"`
+	Kind     string `json:"Kind"     example:"user"`
+	Text     string `json:"Text"     example:"Release at 18:00"`
+	AuthorId string `json:"AuthorId" example:"alice"`
+	SendAt   string `json:"SendAt"   example:"2024-01-01T09:00:00Z"`
+	// pending или failed. Отправленные сообщения удаляются из списка
+	Status    string `json:"Status"    example:"pending"`
+	Attempts  int    `json:"Attempts"  example:"0"`
+	LastError string `json:"LastError,omitempty" example:"chat 125216 not found"`
+	CreatedAt string `json:"CreatedAt" example:"2024-01-01T08:00:00Z"`
+}
+
+type ScheduledMessagesResponse struct {
+	Messages []ScheduledMessageResponse `json:"messages"`
+}
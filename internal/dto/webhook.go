@@ -0,0 +1,46 @@
+package dto
+
+type WebhookIn struct {
+	// Адрес, на который отправляются события
+	URL string `json:"Url" example:"https://example.com/hooks/chat" normalize:"" validate:"notempty,max=2048,http_url"`
+	// Типы событий: message.created, message.deleted, message.expired, chat.updated, chat.deleted. Пустой список - все события
+	Events []string `json:"Events" example:"message.created" validate:"max=10"`
+}
+
+type WebhookResponse struct {
+	ID        int      `json:"Id"        example:"7"`
+	ChatId    int      `json:"ChatId"    example:"125216"`
+	URL       string   `json:"Url"       example:"https://example.com/hooks/chat"`
+	Events    []string `json:"Events"    example:"message.created"`
+	CreatedBy string   `json:"CreatedBy" example:"user-1"`
+	CreatedAt string   `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
+}
+
+// WebhookCreatedResponse содержит секрет подписи, он возвращается только один раз
+type WebhookCreatedResponse struct {
+	WebhookResponse
+	Secret string `json:"Secret" example:"whsec_4pQm0yQm6m4bQ0y3hN2y8m2e0Jk1QyQnP2hQm1oX3aE"`
+}
+
+type WebhooksResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID        int    `json:"Id"        example:"1024"`
+	WebhookId int    `json:"WebhookId" example:"7"`
+	Event     string `json:"Event"     example:"message.created"`
+	// pending, delivered или dead
+	Status   string `json:"Status"   example:"pending"`
+	Attempts int    `json:"Attempts" example:"3"`
+	// Время следующей попытки, только для pending
+	NextAttemptAt  string `json:"NextAttemptAt,omitempty" example:"2024-01-01T12:01:20Z"`
+	LastStatusCode int    `json:"LastStatusCode" example:"503"`
+	LastError      string `json:"LastError"      example:"unexpected status 503"`
+	CreatedAt      string `json:"CreatedAt"      example:"2024-01-01T12:00:00Z"`
+	DeliveredAt    string `json:"DeliveredAt,omitempty" example:"2024-01-01T12:00:01Z"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
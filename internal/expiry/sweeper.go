@@ -0,0 +1,52 @@
+// Package expiry удаляет самоуничтожающиеся сообщения, TTL которых истек
+package expiry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"chat-project/config"
+)
+
+// Deleter удаляет сообщения с истекшим TTL. Реализуется services.ExpiryService
+type Deleter interface {
+	DeleteExpired(ctx context.Context) (int, error)
+}
+
+// Sweeper удаляет истекшие сообщения раз в Interval. Несколько инстансов могут работать одновременно:
+// сообщения удаляются с SKIP LOCKED, и каждое удаляется и рассылается один раз
+type Sweeper struct {
+	deleter Deleter
+	cfg     config.Expiry
+	log     *slog.Logger
+}
+
+func New(deleter Deleter, cfg config.Expiry, log *slog.Logger) *Sweeper {
+	return &Sweeper{
+		deleter: deleter,
+		cfg:     cfg,
+		log:     log.With(slog.String("component", "expiry")),
+	}
+}
+
+// Run удаляет истекшие сообщения по расписанию, пока не отменен ctx
+func (s *Sweeper) Run(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.deleter.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+			s.log.ErrorContext(ctx, "unable to delete expired messages", slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/logger"
+)
+
+type fakeDeleter struct {
+	calls atomic.Int32
+}
+
+func (d *fakeDeleter) DeleteExpired(ctx context.Context) (int, error) {
+	d.calls.Add(1)
+	return 0, errors.New("postgres is down")
+}
+
+func TestSweeperDisabled(t *testing.T) {
+	deleter := &fakeDeleter{}
+	New(deleter, config.Expiry{}, logger.Discard()).Run(context.Background())
+	if deleter.calls.Load() != 0 {
+		t.Fatalf("disabled sweeper deleted %d times", deleter.calls.Load())
+	}
+}
+
+func TestSweeperRetriesAfterError(t *testing.T) {
+	deleter := &fakeDeleter{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		New(deleter, config.Expiry{Interval: time.Millisecond}, logger.Discard()).Run(ctx)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for deleter.calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("sweeper did not run again after an error")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
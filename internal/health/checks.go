@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Таблица версий golang-migrate
+const migrationsTable = "schema_migrations"
+
+func PostgresCheck(pool *pgxpool.Pool) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		return nil, pool.Ping(ctx)
+	}
+}
+
+func RedisCheck(client *redis.Client) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		return nil, client.Ping(ctx).Err()
+	}
+}
+
+// RowQuerier выполняет запрос, возвращающий одну строку, например *pgxpool.Pool
+type RowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// MigrationsCheck сообщает текущую версию схемы.
+// Незавершенная (dirty) миграция считается неготовностью.
+func MigrationsCheck(pool RowQuerier) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		var (
+			version int64
+			dirty   bool
+		)
+		err := pool.QueryRow(ctx, "SELECT version, dirty FROM "+migrationsTable+" LIMIT 1").Scan(&version, &dirty)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("no migrations applied")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error while getting migration version: %w", err)
+		}
+
+		details := map[string]any{"version": version, "dirty": dirty}
+		if dirty {
+			return details, fmt.Errorf("migration %d is dirty", version)
+		}
+		return details, nil
+	}
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+var ShuttingDownError = errors.New("server is shutting down")
+
+// Check проверяет одну зависимость. details попадают в отчет как есть (например версия миграций)
+type Check func(ctx context.Context) (details map[string]any, err error)
+
+type CheckResult struct {
+	Status     string         `json:"status"`
+	DurationMs int64          `json:"durationMs"`
+	Error      string         `json:"error,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Health собирает проверки готовности зависимостей.
+// Каждая проверка выполняется со своим таймаутом, проверки идут параллельно.
+type Health struct {
+	timeout      time.Duration
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout}
+}
+
+// AddCheck регистрирует проверку, вызывать до начала обработки запросов
+func (h *Health) AddCheck(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown переводит готовность в failing, чтобы балансировщик перестал слать новые запросы
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *Health) ShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
+// Ready выполняет все проверки и возвращает отчет по каждой зависимости
+func (h *Health) Ready(ctx context.Context) Report {
+	if h.ShuttingDown() {
+		return Report{Status: StatusDown, Error: ShuttingDownError.Error()}
+	}
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(h.checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.run(ctx, c.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check(ctx)
+	result := CheckResult{
+		Status:     StatusUp,
+		DurationMs: time.Since(start).Milliseconds(),
+		Details:    details,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
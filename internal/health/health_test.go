@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestReady(t *testing.T) {
+	h := New(time.Second)
+	h.AddCheck("ok", func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"version": 3}, nil
+	})
+	h.AddCheck("broken", func(ctx context.Context) (map[string]any, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	report := h.Ready(context.Background())
+	if report.Ready() {
+		t.Fatalf("expected failing readiness, got %+v", report)
+	}
+	if got := report.Checks["ok"]; got.Status != StatusUp || got.Details["version"] != 3 {
+		t.Fatalf("unexpected ok check: %+v", got)
+	}
+	if got := report.Checks["broken"]; got.Status != StatusDown || got.Error != "connection refused" {
+		t.Fatalf("unexpected broken check: %+v", got)
+	}
+}
+
+func TestReadyCheckTimeout(t *testing.T) {
+	h := New(20 * time.Millisecond)
+	h.AddCheck("slow", func(ctx context.Context) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	start := time.Now()
+	report := h.Ready(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("check was not cancelled by timeout, took %s", elapsed)
+	}
+	if got := report.Checks["slow"]; got.Status != StatusDown || got.Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected deadline exceeded, got %+v", got)
+	}
+}
+
+func TestReadyShuttingDown(t *testing.T) {
+	h := New(time.Second)
+	called := false
+	h.AddCheck("ok", func(ctx context.Context) (map[string]any, error) {
+		called = true
+		return nil, nil
+	})
+	if !h.Ready(context.Background()).Ready() {
+		t.Fatal("expected ready before shutdown")
+	}
+
+	called = false
+	h.SetShuttingDown()
+	report := h.Ready(context.Background())
+	if report.Ready() || report.Error != ShuttingDownError.Error() {
+		t.Fatalf("expected shutting down report, got %+v", report)
+	}
+	// Во время остановки зависимости не опрашиваются
+	if called {
+		t.Fatal("checks must not run while shutting down")
+	}
+}
+
+// fakeRow возвращает заданную версию схемы или ошибку
+type fakeRow struct {
+	version int64
+	dirty   bool
+	err     error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int64) = r.version
+	*dest[1].(*bool) = r.dirty
+	return nil
+}
+
+type fakeQuerier struct {
+	row fakeRow
+}
+
+func (q fakeQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return q.row
+}
+
+func TestMigrationsCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     fakeRow
+		details map[string]any
+		wantErr string
+	}{
+		{name: "applied", row: fakeRow{version: 12}, details: map[string]any{"version": int64(12), "dirty": false}},
+		{name: "dirty", row: fakeRow{version: 12, dirty: true}, details: map[string]any{"version": int64(12), "dirty": true}, wantErr: "migration 12 is dirty"},
+		{name: "empty schema", row: fakeRow{err: pgx.ErrNoRows}, wantErr: "no migrations applied"},
+		{name: "query error", row: fakeRow{err: errors.New("relation does not exist")}, wantErr: "relation does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := MigrationsCheck(fakeQuerier{row: tt.row})(context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+			if len(details) != len(tt.details) || details["version"] != tt.details["version"] || details["dirty"] != tt.details["dirty"] {
+				t.Fatalf("unexpected details: %v", details)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Package idempotency хранит ответы на запросы с заголовком Idempotency-Key,
+// чтобы повтор запроса получил тот же ответ, а не создал дубликат.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Префикс ключей записей в общем хранилище
+const _keyPrefix = "idempotency:"
+
+// Record состояние запроса с ключом. Пока запрос выполняется, Done false и ответа нет
+type Record struct {
+	Fingerprint string            `json:"fingerprint"`
+	Done        bool              `json:"done"`
+	Status      int               `json:"status,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+type Store interface {
+	// Start атомарно создает незавершенную запись на время lockTTL, если ключа еще нет.
+	// Если ключ занят, возвращает существующую запись и false
+	Start(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (Record, bool, error)
+	// Complete сохраняет ответ на время ttl
+	Complete(ctx context.Context, key string, record Record, ttl time.Duration) error
+	// Release удаляет незавершенную запись, чтобы запрос можно было повторить
+	Release(ctx context.Context, key string) error
+}
+
+// Fingerprint отпечаток запроса: повтор с тем же ключом должен совпадать с первым запросом
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
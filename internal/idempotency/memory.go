@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// Memory хранит записи в памяти процесса, повторы защищены только в пределах одного инстанса
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		entries: make(map[string]entry),
+		now:     time.Now,
+	}
+}
+
+func (m *Memory) Start(_ context.Context, key, fingerprint string, lockTTL time.Duration) (Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+	if e, ok := m.entries[key]; ok {
+		return e.record, false, nil
+	}
+	record := Record{Fingerprint: fingerprint}
+	m.entries[key] = entry{record: record, expiresAt: now.Add(lockTTL)}
+	return record, true, nil
+}
+
+func (m *Memory) Complete(_ context.Context, key string, record Record, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = entry{record: record, expiresAt: m.now().Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok && !e.record.Done {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	for key, e := range m.entries {
+		if !now.Before(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Удаляет запись, только если запрос еще не завершен
+var releaseScript = redis.NewScript(`
+local value = redis.call('GET', KEYS[1])
+if value and not cjson.decode(value).done then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Redis хранит записи в redis, повторы защищены для всех инстансов
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+func (r *Redis) Start(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (Record, bool, error) {
+	record := Record{Fingerprint: fingerprint}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	// Запись могла истечь между SET NX и GET, тогда пробуем занять ключ еще раз
+	for range 2 {
+		started, err := r.client.SetNX(ctx, _keyPrefix+key, data, lockTTL).Result()
+		if err != nil {
+			return Record{}, false, fmt.Errorf("error while starting idempotent request: %w", err)
+		}
+		if started {
+			return record, true, nil
+		}
+
+		existing, err := r.client.Get(ctx, _keyPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return Record{}, false, fmt.Errorf("error while getting idempotent request: %w", err)
+		}
+		var stored Record
+		if err := json.Unmarshal(existing, &stored); err != nil {
+			return Record{}, false, fmt.Errorf("error while decoding idempotent request: %w", err)
+		}
+		return stored, false, nil
+	}
+	return Record{}, false, errors.New("idempotency key is changing too fast")
+}
+
+func (r *Redis) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := r.client.Set(ctx, _keyPrefix+key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("error while saving idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (r *Redis) Release(ctx context.Context, key string) error {
+	if err := releaseScript.Run(ctx, r.client, []string{_keyPrefix + key}).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("error while releasing idempotency key: %w", err)
+	}
+	return nil
+}
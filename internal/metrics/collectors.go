@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+func desc(subsystem, name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(Namespace, subsystem, name), help, nil, nil)
+}
+
+// pgxPoolCollector снимает статистику пула postgres в момент сбора метрик
+type pgxPoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns    *prometheus.Desc
+	idleConns        *prometheus.Desc
+	totalConns       *prometheus.Desc
+	maxConns         *prometheus.Desc
+	acquireCount     *prometheus.Desc
+	acquireDuration  *prometheus.Desc
+	emptyAcquire     *prometheus.Desc
+	canceledAcquire  *prometheus.Desc
+	constructingConn *prometheus.Desc
+}
+
+func NewPgxPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	return &pgxPoolCollector{
+		pool:             pool,
+		acquiredConns:    desc("pgx_pool", "acquired_connections", "Connections currently in use."),
+		idleConns:        desc("pgx_pool", "idle_connections", "Idle connections in the pool."),
+		totalConns:       desc("pgx_pool", "total_connections", "Total connections in the pool."),
+		maxConns:         desc("pgx_pool", "max_connections", "Maximum size of the pool."),
+		acquireCount:     desc("pgx_pool", "acquires_total", "Successful connection acquires."),
+		acquireDuration:  desc("pgx_pool", "acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquire:     desc("pgx_pool", "empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquire:  desc("pgx_pool", "canceled_acquires_total", "Acquires canceled by context."),
+		constructingConn: desc("pgx_pool", "constructing_connections", "Connections being established."),
+	}
+}
+
+func (c *pgxPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *pgxPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConn, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+}
+
+// redisPoolCollector снимает статистику пула соединений redis
+type redisPoolCollector struct {
+	client *redis.Client
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func NewRedisPoolCollector(client *redis.Client) prometheus.Collector {
+	return &redisPoolCollector{
+		client:     client,
+		hits:       desc("redis_pool", "hits_total", "Times a free connection was found in the pool."),
+		misses:     desc("redis_pool", "misses_total", "Times a free connection was not found in the pool."),
+		timeouts:   desc("redis_pool", "timeouts_total", "Times a wait for a connection timed out."),
+		totalConns: desc("redis_pool", "total_connections", "Total connections in the pool."),
+		idleConns:  desc("redis_pool", "idle_connections", "Idle connections in the pool."),
+		staleConns: desc("redis_pool", "stale_connections_total", "Stale connections removed from the pool."),
+	}
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
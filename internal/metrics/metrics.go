@@ -0,0 +1,267 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace общий префикс всех метрик сервиса. Имена и метки описаны в docs/metrics.md
+const Namespace = "chat"
+
+// Metrics хранит метрики приложения. Методы безопасно вызывать у nil,
+// чтобы сервисы в тестах можно было создавать без метрик.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	sseConnections *prometheus.GaugeVec
+	sseMu          sync.Mutex
+	sseCounts      map[int]int
+
+	eventsPublished *prometheus.CounterVec
+	eventsDelivered *prometheus.CounterVec
+	eventsDropped   *prometheus.CounterVec
+
+	rateLimited *prometheus.CounterVec
+
+	webhookAttempts *prometheus.CounterVec
+	webhookDuration prometheus.Histogram
+
+	scheduledMessages *prometheus.CounterVec
+
+	messagesPurged  prometheus.Counter
+	messagesExpired prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests processed, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+
+		sseConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "sse",
+			Name:      "connections",
+			Help:      "Active SSE connections, by chat.",
+		}, []string{"chat_id"}),
+		sseCounts: make(map[int]int),
+
+		eventsPublished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "events",
+			Name:      "published_total",
+			Help:      "Chat events published to the listener storage, by event type.",
+		}, []string{"type"}),
+		eventsDelivered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "events",
+			Name:      "delivered_total",
+			Help:      "Chat events broadcast to connected clients, by event type.",
+		}, []string{"type"}),
+		eventsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "events",
+			Name:      "dropped_total",
+			Help:      "Chat events dropped because a client was not ready to receive, by event type.",
+		}, []string{"type"}),
+
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "ratelimit",
+			Name:      "rejected_total",
+			Help:      "Requests rejected by rate limits and chat slow mode, by rule.",
+		}, []string{"rule"}),
+
+		webhookAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "webhook",
+			Name:      "attempts_total",
+			Help:      "Webhook delivery attempts, by event type and result.",
+		}, []string{"type", "result"}),
+		webhookDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "webhook",
+			Name:      "attempt_duration_seconds",
+			Help:      "Webhook delivery attempt latency, including failed attempts.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+
+		scheduledMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "scheduled",
+			Name:      "messages_total",
+			Help:      "Scheduled message send attempts, by result.",
+		}, []string{"result"}),
+
+		messagesPurged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "retention",
+			Name:      "purged_messages_total",
+			Help:      "Messages deleted by retention policies.",
+		}),
+		messagesExpired: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "expiry",
+			Name:      "deleted_messages_total",
+			Help:      "Self-destructing messages deleted after their TTL.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.sseConnections,
+		m.eventsPublished,
+		m.eventsDelivered,
+		m.eventsDropped,
+		m.rateLimited,
+		m.webhookAttempts,
+		m.webhookDuration,
+		m.scheduledMessages,
+		m.messagesPurged,
+		m.messagesExpired,
+	)
+
+	return m
+}
+
+// Register добавляет внешние коллекторы, например статистику пулов соединений
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(cs...)
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) ObserveHTTP(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func (m *Metrics) SSEConnected(chatId int) {
+	m.addSSEConnections(chatId, 1)
+}
+
+func (m *Metrics) SSEDisconnected(chatId int) {
+	m.addSSEConnections(chatId, -1)
+}
+
+// Серия удаляется, когда у чата не осталось соединений, чтобы не копить метки удаленных чатов
+func (m *Metrics) addSSEConnections(chatId, delta int) {
+	if m == nil {
+		return
+	}
+	m.sseMu.Lock()
+	defer m.sseMu.Unlock()
+
+	label := strconv.Itoa(chatId)
+	count := m.sseCounts[chatId] + delta
+	if count <= 0 {
+		delete(m.sseCounts, chatId)
+		m.sseConnections.DeleteLabelValues(label)
+		return
+	}
+	m.sseCounts[chatId] = count
+	m.sseConnections.WithLabelValues(label).Set(float64(count))
+}
+
+// RegisterListeners публикует количество запущенных ChatListener
+func (m *Metrics) RegisterListeners(count func() int) {
+	m.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "listeners",
+		Help:      "Live ChatListener instances in ChatListenerManager.",
+	}, func() float64 { return float64(count()) }))
+}
+
+func (m *Metrics) EventPublished(eventType string) {
+	if m == nil {
+		return
+	}
+	m.eventsPublished.WithLabelValues(eventType).Inc()
+}
+
+func (m *Metrics) EventDelivered(eventType string) {
+	if m == nil {
+		return
+	}
+	m.eventsDelivered.WithLabelValues(eventType).Inc()
+}
+
+func (m *Metrics) EventDropped(eventType string) {
+	if m == nil {
+		return
+	}
+	m.eventsDropped.WithLabelValues(eventType).Inc()
+}
+
+func (m *Metrics) RateLimited(rule string) {
+	if m == nil {
+		return
+	}
+	m.rateLimited.WithLabelValues(rule).Inc()
+}
+
+// WebhookAttempt учитывает попытку доставки вебхука. result: delivered, retry или dead
+func (m *Metrics) WebhookAttempt(eventType, result string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.webhookAttempts.WithLabelValues(eventType, result).Inc()
+	m.webhookDuration.Observe(duration.Seconds())
+}
+
+// ScheduledMessage учитывает попытку отправки запланированного сообщения. result: sent, retry или failed
+func (m *Metrics) ScheduledMessage(result string) {
+	if m == nil {
+		return
+	}
+	m.scheduledMessages.WithLabelValues(result).Inc()
+}
+
+// MessagesPurged учитывает сообщения, удаленные по сроку хранения
+func (m *Metrics) MessagesPurged(count int) {
+	if m == nil {
+		return
+	}
+	m.messagesPurged.Add(float64(count))
+}
+
+// MessagesExpired учитывает самоуничтожающиеся сообщения, удаленные после истечения TTL
+func (m *Metrics) MessagesExpired(count int) {
+	if m == nil {
+		return
+	}
+	m.messagesExpired.Add(float64(count))
+}
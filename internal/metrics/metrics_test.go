@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape возвращает метрики в текстовом формате Prometheus
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("scrape: unexpected status %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestSSEConnections(t *testing.T) {
+	m := New()
+
+	m.SSEConnected(1)
+	m.SSEConnected(1)
+	m.SSEConnected(2)
+	m.SSEDisconnected(1)
+	out := scrape(t, m)
+	for _, want := range []string{`chat_sse_connections{chat_id="1"} 1`, `chat_sse_connections{chat_id="2"} 1`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in:\n%s", want, out)
+		}
+	}
+
+	// Серия чата без соединений удаляется, а не остается с нулем
+	m.SSEDisconnected(1)
+	m.SSEDisconnected(2)
+	if out := scrape(t, m); strings.Contains(out, "chat_sse_connections{") {
+		t.Fatalf("expected no sse series, got:\n%s", out)
+	}
+
+	// Лишнее отключение не уводит счетчик в минус
+	m.SSEDisconnected(3)
+	m.SSEConnected(3)
+	if out := scrape(t, m); !strings.Contains(out, `chat_sse_connections{chat_id="3"} 1`) {
+		t.Fatalf("expected one connection for chat 3, got:\n%s", out)
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObserveHTTP(http.MethodGet, "/healthz", http.StatusOK, time.Millisecond)
+	m.SSEConnected(1)
+	m.SSEDisconnected(1)
+	m.EventPublished("message")
+	m.RateLimited("messages")
+	m.WebhookAttempt("message", "delivered", time.Millisecond)
+	m.MessagesPurged(1)
+}
@@ -0,0 +1,53 @@
+// Package queue содержит общие части обработчиков очередей с lease:
+// доставок вебхуков и запланированных сообщений
+package queue
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxErrorLength ошибка попытки обрезается, чтобы не хранить в очереди большие тексты
+const MaxErrorLength = 512
+
+// Poll разбирает очередь, пока не отменен ctx. batch обрабатывает одну пачку и возвращает ее размер:
+// полная пачка значит, что в очереди может быть еще работа, и batch вызывается снова без ожидания.
+// idle, если задан, вызывается после того, как очередь разобрана, перед ожиданием следующего тика
+func Poll(ctx context.Context, interval time.Duration, batchSize int, batch func(ctx context.Context) int, idle func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			if batch(ctx) < batchSize {
+				break
+			}
+		}
+		if idle != nil && ctx.Err() == nil {
+			idle(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ErrorText возвращает текст ошибки не длиннее MaxErrorLength байт
+func ErrorText(err error) string {
+	return truncate(err.Error(), MaxErrorLength)
+}
+
+// truncate обрезает s до n байт по границе символа, чтобы не оставить неполную последовательность UTF-8
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exact", 5, "exact"},
+		{"truncated", 5, "trunc"},
+		// "ошибка": каждая буква занимает два байта, обрезка не режет символ пополам
+		{"ошибка", 5, "ош"},
+		{"ошибка", 6, "оши"},
+		{"日本", 4, "日"},
+		{"日本", 2, ""},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Fatalf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestErrorText(t *testing.T) {
+	text := ErrorText(errors.New(strings.Repeat("я", MaxErrorLength)))
+	if len(text) > MaxErrorLength || !utf8.ValidString(text) {
+		t.Fatalf("unexpected error text: %d bytes, valid utf8: %v", len(text), utf8.ValidString(text))
+	}
+}
+
+func TestPoll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Две полные пачки и неполная разбираются без ожидания тика, после чего вызывается idle
+	sizes := []int{3, 3, 1}
+	var batches, idles int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Poll(ctx, time.Hour, 3, func(context.Context) int {
+			batches++
+			if batches > len(sizes) {
+				t.Error("batch called again before the next tick")
+				return 0
+			}
+			return sizes[batches-1]
+		}, func(context.Context) {
+			idles++
+			cancel()
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Poll did not stop after cancel")
+	}
+	if batches != len(sizes) || idles != 1 {
+		t.Fatalf("expected %d batches and 1 idle call, got %d and %d", len(sizes), batches, idles)
+	}
+}
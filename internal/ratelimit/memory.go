@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Как часто удалять заполненные корзины, чтобы не копить ключи ушедших клиентов
+const _sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+// Memory хранит корзины в памяти процесса, лимиты не общие для нескольких инстансов
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, rate Rate) (Result, error) {
+	if !rate.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.Limit), updated: now}
+		m.buckets[key] = b
+	}
+	tokens, res := take(b.tokens, now.Sub(b.updated), rate)
+	b.tokens, b.updated, b.period = tokens, now, rate.Period
+	return res, nil
+}
+
+// sweep удаляет корзины, которые успели заполниться: без них результат тот же
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < _sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if now.Sub(b.updated) >= b.period {
+			delete(m.buckets, key)
+		}
+	}
+}
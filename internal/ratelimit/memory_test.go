@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestMemory() (*Memory, *clock) {
+	c := &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	m := NewMemory()
+	m.now = c.Now
+	return m, c
+}
+
+func allow(t *testing.T, m *Memory, key string, rate Rate) Result {
+	t.Helper()
+	res, err := m.Allow(context.Background(), key, rate)
+	if err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	return res
+}
+
+func TestMemoryBucket(t *testing.T) {
+	m, c := newTestMemory()
+	rate := Rate{Limit: 2, Period: 10 * time.Second}
+
+	first := allow(t, m, "a", rate)
+	if !first.Allowed || first.Limit != 2 || first.Remaining != 1 || first.Reset != 5*time.Second {
+		t.Fatalf("unexpected first result: %+v", first)
+	}
+	allow(t, m, "a", rate)
+
+	rejected := allow(t, m, "a", rate)
+	if rejected.Allowed || rejected.Remaining != 0 || rejected.RetryAfter != 5*time.Second {
+		t.Fatalf("expected rejection with retry in 5s, got %+v", rejected)
+	}
+	// Другой ключ расходует свою корзину
+	if !allow(t, m, "b", rate).Allowed {
+		t.Fatal("expected separate bucket for another key")
+	}
+
+	// За половину периода пополняется один токен из двух
+	c.now = c.now.Add(5 * time.Second)
+	if !allow(t, m, "a", rate).Allowed {
+		t.Fatal("expected token after refill")
+	}
+	if allow(t, m, "a", rate).Allowed {
+		t.Fatal("expected only one refilled token")
+	}
+
+	// Корзина не переполняется после долгого простоя
+	c.now = c.now.Add(time.Hour)
+	allow(t, m, "a", rate)
+	allow(t, m, "a", rate)
+	if allow(t, m, "a", rate).Allowed {
+		t.Fatal("expected bucket capacity to be limited")
+	}
+}
+
+func TestMemoryDisabledRate(t *testing.T) {
+	m, _ := newTestMemory()
+	for i := 0; i < 10; i++ {
+		if !allow(t, m, "a", Rate{}).Allowed {
+			t.Fatal("expected disabled rate to allow every request")
+		}
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	m, c := newTestMemory()
+	allow(t, m, "a", Rate{Limit: 1, Period: time.Second})
+	allow(t, m, "b", Rate{Limit: 1, Period: time.Hour})
+
+	c.now = c.now.Add(2 * _sweepInterval)
+	allow(t, m, "c", Rate{Limit: 1, Period: time.Second})
+
+	if _, ok := m.buckets["a"]; ok {
+		t.Fatal("expected refilled bucket to be removed")
+	}
+	if _, ok := m.buckets["b"]; !ok {
+		t.Fatal("expected partially used bucket to be kept")
+	}
+}
@@ -0,0 +1,119 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket.
+// Корзина вмещает Rate.Limit токенов и пополняется на Rate.Limit токенов за Rate.Period.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Префикс ключей корзин в общем хранилище
+const _keyPrefix = "ratelimit:"
+
+// ExceededError причина отказа, когда исчерпан лимит запросов
+var ExceededError = errors.New("rate limit exceeded")
+
+// Rate не больше Limit запросов за Period. Нулевой Rate отключает лимит
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRate разбирает лимит в формате "20/10s". Пустая строка или "0" отключают лимит
+func ParseRate(value string) (Rate, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Rate{}, nil
+	}
+	limit, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q, expected <limit>/<period>", value)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 0 {
+		return Rate{}, fmt.Errorf("invalid rate limit %q", limit)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d < time.Millisecond {
+		return Rate{}, fmt.Errorf("invalid rate period %q", period)
+	}
+	return Rate{Limit: n, Period: d}, nil
+}
+
+func (r Rate) Enabled() bool {
+	return r.Limit > 0 && r.Period > 0
+}
+
+// Result решение по одному запросу
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Через сколько появится токен для следующего запроса, если текущий отклонен
+	RetryAfter time.Duration
+	// Через сколько корзина заполнится полностью
+	Reset time.Duration
+}
+
+type Limiter interface {
+	// Allow расходует один токен из корзины key, если он есть
+	Allow(ctx context.Context, key string, rate Rate) (Result, error)
+}
+
+// LimitError запрос отклонен лимитером. Reason уточняет, какой лимит сработал
+type LimitError struct {
+	Reason error
+	Result Result
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s, retry in %ds", e.Reason, seconds(e.Result.RetryAfter))
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Reason
+}
+
+// SetHeaders выставляет RateLimit-* и, если запрос отклонен, Retry-After. Время в целых секундах с округлением вверх
+func SetHeaders(header http.Header, res Result) {
+	header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+	if !res.Allowed {
+		header.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+	}
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// take пополняет корзину за elapsed и пробует взять из нее токен. Возвращает новое число токенов
+func take(tokens float64, elapsed time.Duration, rate Rate) (float64, Result) {
+	limit := float64(rate.Limit)
+	perSecond := limit / rate.Period.Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(limit, tokens+elapsed.Seconds()*perSecond)
+	}
+
+	res := Result{Limit: rate.Limit}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsDuration((1 - tokens) / perSecond)
+	}
+	res.Remaining = int(tokens)
+	res.Reset = secondsDuration((limit - tokens) / perSecond)
+	return tokens, res
+}
+
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Скрипт выполняет take атомарно. Время берется из redis, чтобы инстансы с разными часами
+// расходовали одну корзину одинаково. Ключ живет не дольше периода: за это время корзина заполняется.
+var takeScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local perMs = limit / period
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = limit
+	ts = now
+end
+tokens = math.min(limit, tokens + math.max(0, now - ts) * perMs)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / perMs)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, math.floor(tokens), retry, math.ceil((limit - tokens) / perMs)}
+`)
+
+// Redis хранит корзины в redis, лимиты общие для всех инстансов
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+func (r *Redis) Allow(ctx context.Context, key string, rate Rate) (Result, error) {
+	if !rate.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	values, err := takeScript.Run(ctx, r.client, []string{_keyPrefix + key}, rate.Limit, rate.Period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("error while taking rate limit token: %w", err)
+	}
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      rate.Limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,52 @@
+// Package retention периодически удаляет сообщения с истекшим сроком хранения
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"chat-project/config"
+)
+
+// Purger удаляет сообщения с истекшим сроком хранения. Реализуется services.RetentionService
+type Purger interface {
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// Job запускает удаление раз в Interval. Несколько инстансов могут работать одновременно:
+// пачки удаляются с SKIP LOCKED, и каждое сообщение удаляется и публикуется один раз
+type Job struct {
+	purger Purger
+	cfg    config.Retention
+	log    *slog.Logger
+}
+
+func New(purger Purger, cfg config.Retention, log *slog.Logger) *Job {
+	return &Job{
+		purger: purger,
+		cfg:    cfg,
+		log:    log.With(slog.String("component", "retention")),
+	}
+}
+
+// Run удаляет сообщения сразу и затем по расписанию, пока не отменен ctx
+func (j *Job) Run(ctx context.Context) {
+	if j.cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.purger.PurgeExpired(ctx); err != nil && ctx.Err() == nil {
+			j.log.ErrorContext(ctx, "unable to purge expired messages", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
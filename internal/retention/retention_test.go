@@ -0,0 +1,49 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/logger"
+)
+
+type fakePurger struct {
+	calls atomic.Int32
+}
+
+func (p *fakePurger) PurgeExpired(ctx context.Context) (int64, error) {
+	p.calls.Add(1)
+	return 0, errors.New("postgres is down")
+}
+
+func TestJobDisabled(t *testing.T) {
+	purger := &fakePurger{}
+	New(purger, config.Retention{}, logger.Discard()).Run(context.Background())
+	if purger.calls.Load() != 0 {
+		t.Fatalf("disabled job purged %d times", purger.calls.Load())
+	}
+}
+
+func TestJobRetriesAfterError(t *testing.T) {
+	purger := &fakePurger{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		New(purger, config.Retention{Interval: time.Millisecond}, logger.Discard()).Run(ctx)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for purger.calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("job did not run again after an error")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,112 @@
+// Package scheduler отправляет запланированные сообщения, когда наступает их время
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/metrics"
+	"chat-project/internal/queue"
+	"chat-project/internal/storage"
+)
+
+// Sender отправляет наступившее сообщение в чат. Реализуется services.ChatService
+type Sender interface {
+	SendScheduled(ctx context.Context, msg domain.ScheduledMessage) error
+}
+
+// Scheduler забирает наступившие сообщения и отправляет их.
+// Несколько инстансов могут работать с одной очередью: ClaimScheduledMessages не выдает одно сообщение дважды
+type Scheduler struct {
+	repo    storage.ScheduledMessageRepo
+	sender  Sender
+	cfg     config.Scheduler
+	log     *slog.Logger
+	metrics *metrics.Metrics
+	now     func() time.Time
+}
+
+func New(repo storage.ScheduledMessageRepo, sender Sender, cfg config.Scheduler, log *slog.Logger, m *metrics.Metrics) *Scheduler {
+	return &Scheduler{
+		repo:    repo,
+		sender:  sender,
+		cfg:     cfg,
+		log:     log.With(slog.String("component", "scheduler")),
+		metrics: m,
+		now:     time.Now,
+	}
+}
+
+// Run отправляет сообщения, пока не отменен ctx, и дожидается начатой пачки
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.cfg.BatchSize <= 0 {
+		return
+	}
+	queue.Poll(ctx, s.cfg.PollInterval, s.cfg.BatchSize, s.dispatch, nil)
+}
+
+// dispatch отправляет одну пачку сообщений и возвращает ее размер
+func (s *Scheduler) dispatch(ctx context.Context) int {
+	messages, err := s.repo.ClaimScheduledMessages(ctx, s.cfg.BatchSize, s.cfg.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.ErrorContext(ctx, "unable to claim scheduled messages", slog.Any("error", err))
+		}
+		return 0
+	}
+
+	// Взятые сообщения отправляются и при остановке, иначе они ждали бы окончания lease.
+	// Сообщения одного чата уходят по порядку SendAt
+	sendCtx := context.WithoutCancel(ctx)
+	for _, msg := range messages {
+		s.send(sendCtx, msg)
+	}
+	return len(messages)
+}
+
+func (s *Scheduler) send(ctx context.Context, msg domain.ScheduledMessage) {
+	err := s.sender.SendScheduled(ctx, msg)
+	log := s.log.With(
+		slog.Int("scheduled_id", msg.ID),
+		slog.Int("chat_id", msg.ChatId),
+		slog.Int("attempt", msg.Attempts+1),
+	)
+
+	if err == nil {
+		s.metrics.ScheduledMessage("sent")
+		if err := s.repo.CompleteScheduledMessage(ctx, msg.ID); err != nil {
+			log.ErrorContext(ctx, "unable to complete scheduled message", slog.Any("error", err))
+		}
+		log.DebugContext(ctx, "scheduled message sent")
+		return
+	}
+
+	msg.Attempts++
+	msg.LastError = queue.ErrorText(err)
+	var result string
+	switch {
+	case permanent(err) || msg.Attempts >= s.cfg.MaxAttempts:
+		result = "failed"
+		msg.Status = domain.ScheduledFailed
+		log.WarnContext(ctx, "scheduled message failed, giving up", slog.Any("error", err))
+	default:
+		result = "retry"
+		msg.NextAttemptAt = s.now().Add(time.Duration(msg.Attempts) * s.cfg.RetryDelay)
+		log.InfoContext(ctx, "scheduled message failed, will retry", slog.Any("error", err), slog.Time("next_attempt_at", msg.NextAttemptAt))
+	}
+	s.metrics.ScheduledMessage(result)
+
+	if err := s.repo.FailScheduledMessage(ctx, msg); err != nil {
+		log.ErrorContext(ctx, "unable to save scheduled message result", slog.Any("error", err))
+	}
+}
+
+// permanent ошибки не исчезнут при повторе: чат удален, текст не проходит проверку, конфликт или нет прав писать в чат
+func permanent(err error) bool {
+	return errors.Is(err, domain.NotFoundError) || errors.Is(err, domain.ValidationError) || errors.Is(err, domain.ConflictError) ||
+		errors.Is(err, domain.ForbiddenError)
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/logger"
+	"chat-project/internal/storage/memory"
+)
+
+type fakeSender struct {
+	sent []domain.ScheduledMessage
+	err  error
+}
+
+func (s *fakeSender) SendScheduled(ctx context.Context, msg domain.ScheduledMessage) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func setup(t *testing.T, sender *fakeSender) (*Scheduler, *memory.ScheduledMessageRepoMemory, domain.ScheduledMessage) {
+	t.Helper()
+	ctx := context.Background()
+	chats := memory.NewUserRepoMemory()
+	repo := memory.NewScheduledMessageRepoMemory(chats)
+
+	chat, err := chats.CreateChat(ctx, domain.Chat{Title: "scheduled"}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	msg, err := repo.CreateScheduledMessage(ctx, domain.ScheduledMessage{
+		ChatId:    chat.ID,
+		Kind:      domain.MessageKindUser,
+		AuthorId:  "alice",
+		CreatedBy: "alice",
+		Text:      "good morning",
+		SendAt:    time.Now().Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatalf("create scheduled message: %v", err)
+	}
+	cfg := config.Scheduler{BatchSize: 10, Lease: time.Minute, MaxAttempts: 2, RetryDelay: time.Hour}
+	return New(repo, sender, cfg, logger.Discard(), nil), repo, msg
+}
+
+func TestSchedulerSendsDueMessage(t *testing.T) {
+	sender := &fakeSender{}
+	s, repo, msg := setup(t, sender)
+	ctx := context.Background()
+
+	if n := s.dispatch(ctx); n != 1 {
+		t.Fatalf("dispatched %d messages, want 1", n)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].Text != "good morning" {
+		t.Fatalf("unexpected sent messages: %+v", sender.sent)
+	}
+	if _, err := repo.GetScheduledMessage(ctx, msg.ChatId, msg.ID, "alice"); !domain.IsNotFound(err, domain.EntityScheduled) {
+		t.Fatalf("sent message must be removed, got %v", err)
+	}
+	if n := s.dispatch(ctx); n != 0 {
+		t.Fatalf("dispatched %d messages after send, want 0", n)
+	}
+}
+
+func TestSchedulerRetriesThenFails(t *testing.T) {
+	sender := &fakeSender{err: errors.New("redis is down")}
+	s, repo, msg := setup(t, sender)
+	ctx := context.Background()
+
+	s.dispatch(ctx)
+	got, err := repo.GetScheduledMessage(ctx, msg.ChatId, msg.ID, "alice")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Status != domain.ScheduledPending || got.Attempts != 1 || got.LastError != "redis is down" || !got.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("expected retry, got %+v", got)
+	}
+	if n := s.dispatch(ctx); n != 0 {
+		t.Fatalf("message retried before delay")
+	}
+
+	// Вторая попытка последняя при MaxAttempts 2
+	s.send(ctx, got)
+	got, _ = repo.GetScheduledMessage(ctx, msg.ChatId, msg.ID, "alice")
+	if got.Status != domain.ScheduledFailed || got.Attempts != 2 {
+		t.Fatalf("expected failed after max attempts, got %+v", got)
+	}
+}
+
+func TestSchedulerFailsPermanentErrorAtOnce(t *testing.T) {
+	sender := &fakeSender{err: domain.Validation(domain.EntityMessage, errors.New("too long"))}
+	s, repo, msg := setup(t, sender)
+	ctx := context.Background()
+
+	s.dispatch(ctx)
+	got, err := repo.GetScheduledMessage(ctx, msg.ChatId, msg.ID, "alice")
+	if err != nil || got.Status != domain.ScheduledFailed || got.Attempts != 1 {
+		t.Fatalf("expected failed after one attempt, got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"chat-project/internal/domain"
+)
+
+var (
+	// NotChatMemberError приватный или личный чат доступен только его участникам
+	NotChatMemberError = errors.New("only chat members can access private and direct chats")
+	// ReadOnlyChatError в чат только для чтения пишут только его владелец и администраторы
+	ReadOnlyChatError = errors.New("chat is read-only, only chat owner or admin can post")
+)
+
+// CheckAccess проверяет, что пользователь может читать чат и писать в него.
+// Публичный групповой чат доступен любому клиенту, приватный и личный только участникам
+func (c ChatService) CheckAccess(ctx context.Context, chatId int, userId string) error {
+	chat, err := c.chatRepo.GetChatByID(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+	return c.checkAccess(ctx, chat, userId)
+}
+
+func (c ChatService) checkAccess(ctx context.Context, chat domain.Chat, userId string) error {
+	if chat.Type != domain.ChatTypeDirect && !chat.Private {
+		return nil
+	}
+	if userId == "" {
+		return domain.Forbidden(domain.EntityChat, chat.ID, NotChatMemberError)
+	}
+	_, err := c.chatRepo.GetMember(ctx, chat.ID, userId)
+	if domain.IsNotFound(err, domain.EntityMember) {
+		return domain.Forbidden(domain.EntityChat, chat.ID, NotChatMemberError)
+	} else if err != nil {
+		return fmt.Errorf("error while getting member of chat with id %d: %w", chat.ID, err)
+	}
+	return nil
+}
+
+// checkDelete удалить групповой чат может его владелец или администратор, личный любой из участников
+func (c ChatService) checkDelete(ctx context.Context, chat domain.Chat, userId string) error {
+	if chat.Type == domain.ChatTypeDirect {
+		return c.checkAccess(ctx, chat, userId)
+	}
+	return c.checkGroupManager(ctx, chat, userId)
+}
+
+// checkGroupManager управлять групповым чатом может его владелец или администратор.
+// У чата без управляющих (созданного анонимно или до появления участников) права те же, что на доступ к нему
+func (c ChatService) checkGroupManager(ctx context.Context, chat domain.Chat, userId string) error {
+	if chat.Type == domain.ChatTypeGroup {
+		managed, err := c.chatRepo.HasManager(ctx, chat.ID)
+		if err != nil {
+			return fmt.Errorf("error while checking managers of chat with id %d: %w", chat.ID, err)
+		}
+		if !managed {
+			return c.checkAccess(ctx, chat, userId)
+		}
+	}
+	return checkManager(ctx, c.chatRepo, chat.ID, userId)
+}
+
+// checkWritable в чат с настройкой ReadOnly может писать только тот, кто может им управлять
+func (c ChatService) checkWritable(ctx context.Context, chatId int, userId string) error {
+	chat, err := c.chatRepo.GetChatByID(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+	if !chat.Settings.ReadOnly {
+		return nil
+	}
+	if err := c.checkGroupManager(ctx, chat, userId); errors.Is(err, NotChatManagerError) {
+		return domain.Forbidden(domain.EntityChat, chatId, ReadOnlyChatError)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"chat-project/config"
+	"chat-project/internal/commands"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+func TestDirectChatAccess(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	chats := New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+
+	chat, _, err := chats.GetOrCreateDirectChat(ctx, "alice", "bob")
+	if err != nil {
+		t.Fatalf("create direct chat: %v", err)
+	}
+
+	tests := []struct {
+		userId  string
+		allowed bool
+	}{
+		{"alice", true},
+		{"bob", true},
+		{"carol", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run("user="+tt.userId, func(t *testing.T) {
+			_, getErr := chats.GetWithMessages(ctx, chat.ID, tt.userId)
+			_, addErr := chats.AddMessage(ctx, chat.ID, Sender{UserId: tt.userId}, dto.MessageIn{Text: "hi"})
+			accessErr := chats.CheckAccess(ctx, chat.ID, tt.userId)
+			for _, err := range []error{getErr, addErr, accessErr} {
+				if tt.allowed && err != nil {
+					t.Fatalf("expected access, got %v", err)
+				}
+				if !tt.allowed && (!errors.Is(err, domain.ForbiddenError) || !errors.Is(err, NotChatMemberError)) {
+					t.Fatalf("expected NotChatMemberError, got %v", err)
+				}
+			}
+		})
+	}
+
+	title := "renamed"
+	if _, err := chats.updateChat(ctx, chat.ID, dto.ChatPatchIn{Title: &title}, 0); !errors.Is(err, domain.ValidationError) || !errors.Is(err, DirectChatPatchError) {
+		t.Fatalf("expected DirectChatPatchError, got %v", err)
+	}
+	slowMode := 5
+	if _, err := chats.updateChat(ctx, chat.ID, dto.ChatPatchIn{Settings: &dto.ChatSettingsPatch{SlowModeSeconds: &slowMode}}, 0); err != nil {
+		t.Fatalf("settings of direct chat: %v", err)
+	}
+
+	if err := chats.DeleteChat(ctx, chat.ID, "carol"); !errors.Is(err, domain.ForbiddenError) {
+		t.Fatalf("expected stranger delete to be forbidden, got %v", err)
+	}
+	if err := chats.DeleteChat(ctx, chat.ID, "bob"); err != nil {
+		t.Fatalf("participant delete: %v", err)
+	}
+}
+
+func TestReadOnlyChat(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	registry := commands.NewRegistry()
+	if err := registry.Register(commands.Poll{}); err != nil {
+		t.Fatalf("register commands: %v", err)
+	}
+	chats := New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), memory.NewTxManagerMemory(), registry, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+
+	readOnly := true
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "announcements", Settings: &dto.ChatSettings{ReadOnly: readOnly}}, "alice")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if err := repo.AddMember(ctx, domain.ChatMember{ChatId: chat.ID, UserId: "bob", Role: domain.ChatRoleMember}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	requireReadOnly := func(name string, err error) {
+		t.Helper()
+		if !errors.Is(err, domain.ForbiddenError) || !errors.Is(err, ReadOnlyChatError) {
+			t.Fatalf("%s: expected ReadOnlyChatError, got %v", name, err)
+		}
+	}
+	_, err = chats.AddMessage(ctx, chat.ID, Sender{UserId: "bob"}, dto.MessageIn{Text: "hi"})
+	requireReadOnly("member message", err)
+	_, err = chats.AddMessage(ctx, chat.ID, Sender{Addr: "10.0.0.1"}, dto.MessageIn{Text: "hi"})
+	requireReadOnly("anonymous message", err)
+	_, err = chats.AddMessage(ctx, chat.ID, Sender{Bot: "ci"}, dto.MessageIn{Text: "build passed"})
+	requireReadOnly("incoming webhook", err)
+	_, err = chats.AddMessage(ctx, chat.ID, Sender{UserId: "bob"}, dto.MessageIn{Text: "/poll Lunch? | Pizza | Sushi"})
+	requireReadOnly("public command reply", err)
+	err = chats.SendScheduled(ctx, domain.ScheduledMessage{ID: 1, ChatId: chat.ID, Kind: domain.MessageKindUser, AuthorId: "bob", CreatedBy: "bob", Text: "later"})
+	requireReadOnly("scheduled message", err)
+
+	// Владелец, бот его команды и система пишут в чат только для чтения
+	if _, err := chats.AddMessage(ctx, chat.ID, Sender{UserId: "alice"}, dto.MessageIn{Text: "release today"}); err != nil {
+		t.Fatalf("owner message: %v", err)
+	}
+	if _, err := chats.AddMessage(ctx, chat.ID, Sender{UserId: "alice"}, dto.MessageIn{Text: "/poll Lunch? | Pizza | Sushi"}); err != nil {
+		t.Fatalf("owner command reply: %v", err)
+	}
+	if _, err := chats.AddSystemMessage(ctx, chat.ID, "maintenance"); err != nil {
+		t.Fatalf("system message: %v", err)
+	}
+
+	got, err := chats.GetWithMessages(ctx, chat.ID, "alice")
+	if err != nil || len(got.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %+v, %v", got, err)
+	}
+
+	readOnly = false
+	if _, err := chats.UpdateChat(ctx, chat.ID, "alice", dto.ChatPatchIn{Settings: &dto.ChatSettingsPatch{ReadOnly: &readOnly}}, 0); err != nil {
+		t.Fatalf("disable read-only: %v", err)
+	}
+	if _, err := chats.AddMessage(ctx, chat.ID, Sender{UserId: "bob"}, dto.MessageIn{Text: "hi"}); err != nil {
+		t.Fatalf("member message after read-only is disabled: %v", err)
+	}
+}
+
+func TestOwnerlessChatUpdate(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	chats := New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+
+	// Чат без владельца меняет любой клиент, как до появления ролей
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "lobby"}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	slowMode := 10
+	for _, userId := range []string{"", "bob"} {
+		if _, err := chats.UpdateChat(ctx, chat.ID, userId, dto.ChatPatchIn{Settings: &dto.ChatSettingsPatch{SlowModeSeconds: &slowMode}}, 0); err != nil {
+			t.Fatalf("user %q: update ownerless chat: %v", userId, err)
+		}
+	}
+
+	// Участник без роли управляющего не делает чат управляемым
+	if err := repo.AddMember(ctx, domain.ChatMember{ChatId: chat.ID, UserId: "bob", Role: domain.ChatRoleMember}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if _, err := chats.UpdateChat(ctx, chat.ID, "", dto.ChatPatchIn{Settings: &dto.ChatSettingsPatch{SlowModeSeconds: &slowMode}}, 0); err != nil {
+		t.Fatalf("update chat with plain members: %v", err)
+	}
+
+	if err := repo.AddMember(ctx, domain.ChatMember{ChatId: chat.ID, UserId: "alice", Role: domain.ChatRoleAdmin}); err != nil {
+		t.Fatalf("add admin: %v", err)
+	}
+	if _, err := chats.UpdateChat(ctx, chat.ID, "bob", dto.ChatPatchIn{Settings: &dto.ChatSettingsPatch{SlowModeSeconds: &slowMode}}, 0); !errors.Is(err, NotChatManagerError) {
+		t.Fatalf("expected NotChatManagerError once the chat has an admin, got %v", err)
+	}
+}
@@ -1,113 +1,590 @@
 package services
 
 import (
+	"chat-project/internal/commands"
 	"chat-project/internal/domain"
 	"chat-project/internal/dto"
+	"chat-project/internal/metrics"
+	"chat-project/internal/ratelimit"
 	"chat-project/internal/storage"
+	"chat-project/internal/tracing"
+	"chat-project/internal/validation"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// SlowModeError в чате включен медленный режим и отправитель еще не может написать снова
+var SlowModeError = errors.New("slow mode is enabled")
+
+// updateChatAttempts сколько раз UpdateChat без If-Match перечитывает чат при параллельном изменении
+const updateChatAttempts = 3
+
+// Sender отправитель сообщения. Медленный режим действует и по UserId, и по Addr: X-User-Id задает сам клиент,
+// поэтому смена заголовка не должна его обходить.
+// Bot задан у входящего вебхука: сообщение получает тип bot, а медленный режим к нему не применяется
+type Sender struct {
+	UserId string
+	Addr   string
+	Bot    string
+}
+
+func (s Sender) keys() []string {
+	if s.Bot != "" {
+		return nil
+	}
+	var keys []string
+	if s.UserId != "" {
+		keys = append(keys, "user:"+s.UserId)
+	}
+	if s.Addr != "" {
+		keys = append(keys, "ip:"+s.Addr)
+	}
+	return keys
+}
+
 type ChatService struct {
-	chatRepo storage.ChatRepo
-	chatListener  storage.ChatListener
+	chatRepo     storage.ChatRepo
+	webhookRepo  storage.WebhookRepo
+	scheduled    storage.ScheduledMessageRepo
+	chatListener storage.ChatListener
+	tx           storage.TxManager
+	commands     *commands.Registry
+	validator    *validation.Validator
+	limiter      ratelimit.Limiter
+	log          *slog.Logger
+	metrics      *metrics.Metrics
+	tracer       trace.Tracer
+	now          func() time.Time
 }
 
-func New(chatRepo storage.ChatRepo, chatListener storage.ChatListener) *ChatService {
+// New создает сервис чатов. registry nil отключает слэш-команды
+func New(chatRepo storage.ChatRepo, webhookRepo storage.WebhookRepo, scheduled storage.ScheduledMessageRepo, chatListener storage.ChatListener, tx storage.TxManager, registry *commands.Registry, validator *validation.Validator, limiter ratelimit.Limiter, log *slog.Logger, metrics *metrics.Metrics) *ChatService {
 	return &ChatService{
-		chatRepo: chatRepo,
+		chatRepo:     chatRepo,
+		webhookRepo:  webhookRepo,
+		scheduled:    scheduled,
 		chatListener: chatListener,
+		tx:           tx,
+		commands:     registry,
+		validator:    validator,
+		limiter:      limiter,
+		log:          log,
+		metrics:      metrics,
+		tracer:       tracing.Tracer("chat-project/internal/services"),
+		now:          time.Now,
+	}
+}
+
+func (c ChatService) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "ChatService."+name, trace.WithAttributes(attrs...))
+}
+
+// publish отправляет событие подписчикам чата на всех инстансах.
+// Контекст трассировки передается в событии, чтобы доставка клиентам ссылалась на исходный запрос.
+func (c ChatService) publish(ctx context.Context, event domain.Event) error {
+	event.Trace = tracing.Inject(ctx)
+	if err := c.chatListener.Publish(ctx, event.ChatId, event); err != nil {
+		return err
+	}
+	c.metrics.EventPublished(string(event.Type))
+	return nil
+}
+
+// withEvents выполняет write и ставит его события в очередь вебхуков в одной транзакции,
+// чтобы событие не терялось и не уходило без изменения. Подписчикам события рассылаются после фиксации
+func (c ChatService) withEvents(ctx context.Context, write func(ctx context.Context) ([]domain.Event, error)) ([]domain.Event, error) {
+	var events []domain.Event
+	err := c.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if events, err = write(ctx); err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := enqueueWebhooks(ctx, c.webhookRepo, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+// withEvent то же, что withEvents, для изменения с одним событием
+func (c ChatService) withEvent(ctx context.Context, write func(ctx context.Context) (domain.Event, error)) (domain.Event, error) {
+	events, err := c.withEvents(ctx, func(ctx context.Context) ([]domain.Event, error) {
+		event, err := write(ctx)
+		return []domain.Event{event}, err
+	})
+	if err != nil {
+		return domain.Event{}, err
+	}
+	return events[0], nil
+}
+
+// notify рассылает подписчикам событие о том, что уже изменено в хранилище, например удалении сообщений:
+// ошибки только логируются
+func (c ChatService) notify(ctx context.Context, event domain.Event) {
+	if err := c.publish(ctx, event); err != nil {
+		c.log.WarnContext(ctx, "unable to publish event", slog.Int("chat_id", event.ChatId), slog.String("event", string(event.Type)), slog.Any("error", err))
 	}
 }
 
-// Создать чат
-func (c ChatService) Create(ctx context.Context, chatIn dto.ChatIn) (*dto.ChatResponse, error) {
+// Создать чат. Если userId не пустой, пользователь становится владельцем чата
+func (c ChatService) Create(ctx context.Context, chatIn dto.ChatIn, userId string) (resp *dto.ChatResponse, err error) {
+	ctx, span := c.startSpan(ctx, "Create")
+	defer func() { tracing.End(span, err) }()
+
+	if err = c.validator.Validate(domain.EntityChat, &chatIn); err != nil {
+		return nil, err
+	}
+	// Срок хранения задает владелец, у чата без владельца его может изменить только admin CLI
+	if userId == "" && chatIn.Settings != nil && chatIn.Settings.RetentionDays != 0 {
+		return nil, domain.Forbidden(domain.EntityChat, 0, NotChatManagerError)
+	}
+
 	chat := domain.Chat{
-		Title:     chatIn.Title,
-		CreatedAt: time.Now(),
+		Title:       chatIn.Title,
+		Private:     chatIn.Private,
+		Description: chatIn.Description,
+		AvatarURL:   chatIn.AvatarURL,
+		CreatedAt:   c.now(),
+	}
+	if chatIn.Settings != nil {
+		chat.Settings = domain.ChatSettings(*chatIn.Settings)
 	}
 
-	chat, err := c.chatRepo.CreateChat(ctx, chat)
+	chat, err = c.chatRepo.CreateChat(ctx, chat, userId)
 	if err != nil {
 		return nil, fmt.Errorf("error while creating chat: %w", err)
 	}
 
+	c.log.InfoContext(ctx, "chat created", slog.Int("chat_id", chat.ID), slog.Bool("private", chat.Private))
+
+	return newChatResponse(chat), nil
+}
+
+// Обновить метаданные чата. Срок хранения сообщений может менять только владелец или администратор чата.
+// expectedVersion - версия из If-Match, 0 если клиент не передал условие.
+func (c ChatService) UpdateChat(ctx context.Context, chatId int, userId string, patch dto.ChatPatchIn, expectedVersion int) (resp *dto.ChatResponse, err error) {
+	ctx, span := c.startSpan(ctx, "UpdateChat", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	chat, err := c.chatRepo.GetChatByID(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting chat: %w", err)
+	}
+	if err = c.checkGroupManager(ctx, chat, userId); err != nil {
+		return nil, err
+	}
+	return c.updateChat(ctx, chatId, patch, expectedVersion)
+}
+
+// updateChat применяет изменение без проверки прав, для admin CLI и вызовов после проверки.
+// Без expectedVersion клиент не ждет конкретной версии, поэтому при параллельном изменении чтение и запись повторяются
+func (c ChatService) updateChat(ctx context.Context, chatId int, patch dto.ChatPatchIn, expectedVersion int) (*dto.ChatResponse, error) {
+	if err := c.validator.Validate(domain.EntityChat, &patch); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.applyUpdate(ctx, chatId, patch, expectedVersion)
+		if expectedVersion == 0 && attempt < updateChatAttempts && errors.Is(err, storage.ChatVersionConflictError) {
+			continue
+		}
+		return resp, err
+	}
+}
+
+func (c ChatService) applyUpdate(ctx context.Context, chatId int, patch dto.ChatPatchIn, expectedVersion int) (*dto.ChatResponse, error) {
+	chat, err := c.chatRepo.GetChatByID(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting chat: %w", err)
+	}
+
+	if chat.Type == domain.ChatTypeDirect && directChatMetadata(patch) {
+		return nil, domain.Validation(domain.EntityChat, DirectChatPatchError)
+	}
+	if expectedVersion != 0 && expectedVersion != chat.Version {
+		return nil, domain.Conflict(domain.EntityChat, chatId, storage.ChatVersionConflictError)
+	}
+
+	applyChatPatch(&chat, patch)
+
+	event, err := c.withEvent(ctx, func(ctx context.Context) (domain.Event, error) {
+		chat, err = c.chatRepo.UpdateChat(ctx, chat)
+		if err != nil {
+			return domain.Event{}, fmt.Errorf("error while updating chat with id %d: %w", chatId, err)
+		}
+		return domain.NewChatUpdatedEvent(chat), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = c.publish(ctx, event)
+	if err != nil {
+		return nil, fmt.Errorf("error while publishing update of chat with id %d: %w", chatId, err)
+	}
+
+	c.log.InfoContext(ctx, "chat updated", slog.Int("chat_id", chatId), slog.Int("version", chat.Version))
+
+	return newChatResponse(chat), nil
+}
+
+func applyChatPatch(chat *domain.Chat, patch dto.ChatPatchIn) {
+	if patch.Title != nil {
+		chat.Title = *patch.Title
+	}
+	if patch.Private != nil {
+		chat.Private = *patch.Private
+	}
+	if patch.Description != nil {
+		chat.Description = *patch.Description
+	}
+	if patch.AvatarURL != nil {
+		chat.AvatarURL = *patch.AvatarURL
+	}
+	if settings := patch.Settings; settings != nil {
+		if settings.SlowModeSeconds != nil {
+			chat.Settings.SlowModeSeconds = *settings.SlowModeSeconds
+		}
+		if settings.ReadOnly != nil {
+			chat.Settings.ReadOnly = *settings.ReadOnly
+		}
+		if settings.RetentionDays != nil {
+			chat.Settings.RetentionDays = *settings.RetentionDays
+		}
+	}
+}
+
+func newChatResponse(chat domain.Chat) *dto.ChatResponse {
 	return &dto.ChatResponse{
-		Title:     chat.Title,
-		ID:        chat.ID,
-		CreatedAt: chat.CreatedAt.Format(time.RFC3339),
-	}, nil
+		Title:       chat.Title,
+		ID:          chat.ID,
+		Type:        string(chat.Type),
+		Private:     chat.Private,
+		Description: chat.Description,
+		AvatarURL:   chat.AvatarURL,
+		Settings:    dto.ChatSettings(chat.Settings),
+		LegalHold:   chat.LegalHold,
+		Version:     chat.Version,
+		CreatedAt:   chat.CreatedAt.Format(time.RFC3339),
+	}
 }
 
-// Добавить сообщение в чат
-func (c ChatService) AddMessage(ctx context.Context, chatId int, message dto.MessageIn) (*dto.MessageResponse, error) {
-	msg, err := c.chatRepo.AddMessage(
-		ctx,
-		domain.Message{
-			ChatId:    chatId,
-			Text:      message.Text,
-			CreatedAt: time.Now(),
-		},
-		chatId,
-	)
+// Список публичных чатов
+func (c ChatService) ListChats(ctx context.Context, limit, offset int) (resp *dto.ChatsResponse, err error) {
+	ctx, span := c.startSpan(ctx, "ListChats")
+	defer func() { tracing.End(span, err) }()
 
-	if err != nil && errors.Is(err, storage.ChatNotFoundError) {
-		return nil, fmt.Errorf("error while adding msg to chat with id %d: %w", chatId, err)
-	} else if err != nil {
-		return nil, fmt.Errorf("error while adding message: %w", err)
+	chats, err := c.chatRepo.ListChats(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing chats: %w", err)
 	}
 
-	err = c.chatListener.Publish(ctx, chatId, msg)
+	resp = &dto.ChatsResponse{Chats: make([]dto.ChatResponse, 0, len(chats))}
+	for _, chat := range chats {
+		resp.Chats = append(resp.Chats, *newChatResponse(chat))
+	}
+	return resp, nil
+}
+
+// Список всех чатов, включая приватные и личные, для администрирования
+func (c ChatService) ListAllChats(ctx context.Context, limit, offset int) (resp *dto.ChatsResponse, err error) {
+	ctx, span := c.startSpan(ctx, "ListAllChats")
+	defer func() { tracing.End(span, err) }()
+
+	chats, err := c.chatRepo.ListAllChats(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing chats: %w", err)
+	}
+
+	resp = &dto.ChatsResponse{Chats: make([]dto.ChatResponse, 0, len(chats))}
+	for _, chat := range chats {
+		resp.Chats = append(resp.Chats, *newChatResponse(chat))
+	}
+	return resp, nil
+}
+
+// Добавить сообщение в чат. Повтор с тем же ClientNonce и текстом возвращает уже сохраненное сообщение.
+// Сообщение вида "/name args" не сохраняется, а выполняет слэш-команду
+func (c ChatService) AddMessage(ctx context.Context, chatId int, sender Sender, message dto.MessageIn) (resp *dto.MessageResponse, err error) {
+	ctx, span := c.startSpan(ctx, "AddMessage", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	if err = c.validator.Validate(domain.EntityMessage, &message); err != nil {
+		return nil, err
+	}
+	// Входящий вебхук уже создан менеджером чата, для него участие не проверяется
+	if sender.Bot == "" {
+		if err = c.CheckAccess(ctx, chatId, sender.UserId); err != nil {
+			return nil, err
+		}
+	}
+	if c.commands != nil && sender.Bot == "" {
+		if name, args, ok := commands.Parse(message.Text); ok {
+			return c.runCommand(ctx, chatId, sender, message, name, args)
+		}
+		// "//" отправляет текст, начинающийся со слэша
+		if strings.HasPrefix(message.Text, "//") {
+			message.Text = message.Text[1:]
+		}
+	}
+	msg := domain.Message{
+		ChatId:      chatId,
+		Kind:        domain.MessageKindUser,
+		Text:        message.Text,
+		AuthorId:    sender.UserId,
+		ClientNonce: message.ClientNonce,
+	}
+	if sender.Bot != "" {
+		msg.Kind = domain.MessageKindBot
+		msg.AuthorId = sender.Bot
+	}
+	if message.TtlSeconds > 0 {
+		expiresAt := c.now().Add(time.Duration(message.TtlSeconds) * time.Second)
+		msg.ExpiresAt = &expiresAt
+	}
+	// Повтор проверяется до медленного режима, иначе клиент получил бы 429 за уже отправленное сообщение
+	if existing, ok, err := c.sentMessage(ctx, msg); ok || err != nil {
+		return existing, err
+	}
+	if err = c.checkSlowMode(ctx, chatId, sender); err != nil {
+		return nil, err
+	}
+
+	resp, err = c.addMessage(ctx, msg, sender.UserId)
+	if errors.Is(err, storage.MessageNonceConflictError) {
+		// Параллельный повтор успел сохранить сообщение первым
+		if existing, ok, sentErr := c.sentMessage(ctx, msg); ok || sentErr != nil {
+			return existing, sentErr
+		}
+	}
+	return resp, err
+}
+
+// sentMessage ищет сообщение автора с тем же ClientNonce. Если оно есть, но текст другой, возвращает конфликт
+func (c ChatService) sentMessage(ctx context.Context, msg domain.Message) (*dto.MessageResponse, bool, error) {
+	if msg.ClientNonce == "" {
+		return nil, false, nil
+	}
+	existing, err := c.chatRepo.GetMessageByNonce(ctx, msg.ChatId, msg.AuthorId, msg.InvokedBy, msg.ClientNonce)
+	if domain.IsNotFound(err, domain.EntityMessage) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error while getting message by client nonce: %w", err)
+	}
+	if existing.Kind != msg.Kind || existing.Text != msg.Text {
+		return nil, false, domain.Conflict(domain.EntityMessage, msg.ClientNonce, storage.MessageNonceConflictError)
+	}
+	return newMessageResponse(existing), true, nil
+}
+
+// Добавить служебное сообщение от имени системы
+func (c ChatService) AddSystemMessage(ctx context.Context, chatId int, text string) (resp *dto.MessageResponse, err error) {
+	ctx, span := c.startSpan(ctx, "AddSystemMessage", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	// Служебные сообщения проверяются по тем же правилам, что и пользовательские
+	message := dto.MessageIn{Text: text}
+	if err = c.validator.Validate(domain.EntityMessage, &message); err != nil {
+		return nil, err
+	}
+	return c.addMessage(ctx, domain.Message{ChatId: chatId, Kind: domain.MessageKindSystem, Text: message.Text}, "")
+}
+
+// checkSlowMode пропускает не больше одного сообщения отправителя за SlowModeSeconds чата.
+// Сообщение должно пройти лимит по каждому ключу отправителя
+func (c ChatService) checkSlowMode(ctx context.Context, chatId int, sender Sender) error {
+	keys := sender.keys()
+	if len(keys) == 0 {
+		return nil
+	}
+	chat, err := c.chatRepo.GetChatByID(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+	if chat.Settings.SlowModeSeconds <= 0 {
+		return nil
+	}
+
+	rate := ratelimit.Rate{Limit: 1, Period: time.Duration(chat.Settings.SlowModeSeconds) * time.Second}
+	for _, key := range keys {
+		res, err := c.limiter.Allow(ctx, fmt.Sprintf("slowmode:%d:%s", chatId, key), rate)
+		if err != nil {
+			// Недоступное хранилище лимитов не должно останавливать переписку
+			c.log.WarnContext(ctx, "slow mode check failed", slog.Int("chat_id", chatId), slog.Any("error", err))
+			return nil
+		}
+		if !res.Allowed {
+			c.metrics.RateLimited("slow_mode")
+			return domain.RateLimited(domain.EntityChat, chatId, &ratelimit.LimitError{Reason: SlowModeError, Result: res})
+		}
+	}
+	return nil
+}
+
+// addMessage сохраняет и публикует сообщение. userId - пользователь, от чьего имени оно пишется:
+// в чат только для чтения пишут его владелец и администраторы, служебные сообщения пишутся всегда
+func (c ChatService) addMessage(ctx context.Context, msg domain.Message, userId string) (*dto.MessageResponse, error) {
+	chatId := msg.ChatId
+	if msg.Kind != domain.MessageKindSystem {
+		if err := c.checkWritable(ctx, chatId, userId); err != nil {
+			return nil, err
+		}
+	}
+	msg.CreatedAt = c.now()
+	event, err := c.withEvent(ctx, func(ctx context.Context) (domain.Event, error) {
+		var err error
+		msg, err = c.chatRepo.AddMessage(ctx, msg, chatId)
+		if err != nil {
+			return domain.Event{}, fmt.Errorf("error while adding message to chat with id %d: %w", chatId, err)
+		}
+		return domain.NewMessageCreatedEvent(msg), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.publish(ctx, event)
 	if err != nil {
 		return nil, fmt.Errorf("error while publishing message to chat with id %d: %w", chatId, err)
 	}
-	// TODO transaction manager
+	c.log.DebugContext(ctx, "message added", slog.Int("chat_id", chatId), slog.Int("message_id", msg.ID), slog.String("kind", string(msg.Kind)))
+
+	return newMessageResponse(msg), nil
+}
 
-	return &dto.MessageResponse{
-		ID:        msg.ID,
-		ChatId:    msg.ChatId,
-		Text:      msg.Text,
-		CreatedAt: msg.CreatedAt.Format(time.RFC3339),
-	}, nil
+func newMessageResponse(msg domain.Message) *dto.MessageResponse {
+	resp := &dto.MessageResponse{
+		ID:          msg.ID,
+		ChatId:      msg.ChatId,
+		Kind:        string(msg.Kind),
+		Text:        msg.Text,
+		AuthorId:    msg.AuthorId,
+		ClientNonce: msg.ClientNonce,
+		CreatedAt:   msg.CreatedAt.Format(time.RFC3339),
+	}
+	if msg.ExpiresAt != nil {
+		resp.ExpiresAt = msg.ExpiresAt.Format(time.RFC3339)
+	}
+	return resp
 }
 
-// Получить чат с лимитом последних сообщений
-func (c ChatService) GetWithMessages(ctx context.Context, chatId int) (*dto.ChatWithMessagesResponse, error) {
+// Получить чат с лимитом последних сообщений. Приватный и личный чат доступен только участникам
+func (c ChatService) GetWithMessages(ctx context.Context, chatId int, userId string) (resp *dto.ChatWithMessagesResponse, err error) {
+	ctx, span := c.startSpan(ctx, "GetWithMessages", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
 	chat, err := c.chatRepo.GetWithMessages(ctx, chatId)
-	if err != nil && err == storage.ChatNotFoundError {
-		return nil, fmt.Errorf("error while receive chat with id: %d : %w", chatId, err)
-	} else if err != nil {
-		return nil, fmt.Errorf("error while getting chat with messages: %w", err)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting chat with id %d with messages: %w", chatId, err)
+	}
+	if err = c.checkAccess(ctx, chat, userId); err != nil {
+		return nil, err
 	}
+	return newChatWithMessagesResponse(chat), nil
+}
+
+// Получить любой чат с сообщениями для admin CLI, без проверки участия
+func (c ChatService) AdminGetWithMessages(ctx context.Context, chatId int) (resp *dto.ChatWithMessagesResponse, err error) {
+	ctx, span := c.startSpan(ctx, "AdminGetWithMessages", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
 
+	chat, err := c.chatRepo.GetWithMessages(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting chat with id %d with messages: %w", chatId, err)
+	}
+	return newChatWithMessagesResponse(chat), nil
+}
+
+func newChatWithMessagesResponse(chat domain.Chat) *dto.ChatWithMessagesResponse {
 	messages := make([]dto.MessageResponse, 0, len(chat.Messages))
 	for _, msg := range chat.Messages {
-		messages = append(messages, dto.MessageResponse{
-			ID:        msg.ID,
-			ChatId:    msg.ChatId,
-			Text:      msg.Text,
-			CreatedAt:msg.CreatedAt.Format(time.RFC3339),
-		})
+		messages = append(messages, *newMessageResponse(msg))
 	}
 
 	return &dto.ChatWithMessagesResponse{
-		ID:        chat.ID,
-		Title:     chat.Title,
-		CreatedAt: chat.CreatedAt.Format(time.RFC3339),
-		Messages:  messages,
-	}, nil
+		ID:          chat.ID,
+		Type:        string(chat.Type),
+		Private:     chat.Private,
+		Title:       chat.Title,
+		Description: chat.Description,
+		AvatarURL:   chat.AvatarURL,
+		Settings:    dto.ChatSettings(chat.Settings),
+		LegalHold:   chat.LegalHold,
+		Version:     chat.Version,
+		CreatedAt:   chat.CreatedAt.Format(time.RFC3339),
+		Messages:    messages,
+	}
 }
 
-// Удалить чат
-func (c ChatService) DeleteChat(ctx context.Context, chatId int) error {
-	err := c.chatRepo.DeleteChat(ctx, chatId)
-	if err != nil && err == storage.ChatNotFoundError {
-		return fmt.Errorf("chat with id %d not found: %w", chatId, err)
-	} else if err != nil {
-		return fmt.Errorf("error while deleting chat: %w", err)
+// Сообщения, добавленные после afterId, для досылки подписчику, переподключившемуся с Last-Event-ID
+func (c ChatService) MessagesAfter(ctx context.Context, chatId, afterId, limit int) (messages []domain.Message, err error) {
+	ctx, span := c.startSpan(ctx, "MessagesAfter", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	messages, err = c.chatRepo.GetMessagesAfter(ctx, chatId, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting messages of chat with id %d after %d: %w", chatId, afterId, err)
+	}
+	return messages, nil
+}
+
+// Удалить чат и отключить всех его подписчиков. Групповой чат удаляет владелец или администратор,
+// личный любой из участников
+func (c ChatService) DeleteChat(ctx context.Context, chatId int, userId string) (err error) {
+	ctx, span := c.startSpan(ctx, "DeleteChat", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	chat, err := c.chatRepo.GetChatByID(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+	if err = c.checkDelete(ctx, chat, userId); err != nil {
+		return err
+	}
+	return c.deleteChat(ctx, chatId)
+}
+
+// Удалить любой чат из admin CLI, без проверки прав
+func (c ChatService) AdminDeleteChat(ctx context.Context, chatId int) (err error) {
+	ctx, span := c.startSpan(ctx, "AdminDeleteChat", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	return c.deleteChat(ctx, chatId)
+}
+
+func (c ChatService) deleteChat(ctx context.Context, chatId int) error {
+	// Вебхуки удаляются вместе с чатом, поэтому событие ставится в их очередь до удаления, в той же транзакции
+	event := domain.NewChatDeletedEvent(chatId)
+	err := c.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := enqueueWebhooks(ctx, c.webhookRepo, event); err != nil {
+			return err
+		}
+		if err := c.chatRepo.DeleteChat(ctx, chatId); err != nil {
+			return fmt.Errorf("error while deleting chat with id %d: %w", chatId, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	// Уведомить подписчиков на всех инстансах, слушатели закроют свои соединения
+	err = c.publish(ctx, event)
+	if err != nil {
+		return fmt.Errorf("error while publishing deletion of chat with id %d: %w", chatId, err)
+	}
+
+	c.log.InfoContext(ctx, "chat deleted", slog.Int("chat_id", chatId))
+
 	return nil
 }
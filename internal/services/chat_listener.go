@@ -2,20 +2,42 @@ package services
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
+	"sync/atomic"
 
 	"chat-project/internal/domain"
+	"chat-project/internal/metrics"
 	"chat-project/internal/storage"
+	"chat-project/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type ClientConn chan domain.Message
+var ListenerClosedError = errors.New("chat listener is closed")
+
+// Размер буфера клиентского канала, чтобы финальное событие успело попасть клиенту до закрытия канала
+const clientBufferSize = 16
+
+type ClientConn chan domain.Event
+
+func NewClientConn() ClientConn {
+	return make(ClientConn, clientBufferSize)
+}
 
-// Прослушиватель сообщений в чате, который будет отправлять новые сообщения всем подписанным клиентам
+// Прослушиватель сообщений в чате, который будет отправлять новые сообщения всем подписанным клиентам.
+// Временем жизни управляет ChatListenerManager: слушатель живет, пока на него есть ссылки,
+// и останавливается отменой собственного контекста.
 type ChatListener struct {
 	ChatId   int
 	listener storage.ChatListener
 
-	messages chan domain.Message
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	messages chan domain.Event
 
 	// New client connections
 	newClients chan ClientConn
@@ -26,37 +48,79 @@ type ChatListener struct {
 	// Total client connections
 	totalClients map[ClientConn]bool
 	chatManager  *ChatListenerManager
+	log          *slog.Logger
+	metrics      *metrics.Metrics
+	tracer       trace.Tracer
+
+	// Количество клиентов для статистики, totalClients доступна только из ListenChannels
+	clients atomic.Int64
+
+	// Guarded by ChatListenerManager.mu
+	refs    int
+	idleSeq int
 }
 
-func NewChatListener(listener storage.ChatListener, chatManager *ChatListenerManager, chatId int) *ChatListener {
+func NewChatListener(ctx context.Context, listener storage.ChatListener, chatManager *ChatListenerManager, chatId int, log *slog.Logger, metrics *metrics.Metrics) *ChatListener {
+	ctx, cancel := context.WithCancel(ctx)
 	chatListener := &ChatListener{
 		ChatId:        chatId,
 		listener:      listener,
-		messages:      make(chan domain.Message),
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+		messages:      make(chan domain.Event),
 		newClients:    make(chan ClientConn),
 		closedClients: make(chan ClientConn),
 		totalClients:  make(map[ClientConn]bool),
-		chatManager: chatManager,
+		chatManager:   chatManager,
+		log:           log.With(slog.Int("chat_id", chatId)),
+		metrics:       metrics,
+		tracer:        tracing.Tracer("chat-project/internal/services"),
 	}
 
 	return chatListener
 }
 
-func (l *ChatListener) StartListening(ctx context.Context) {
-	go l.ListenChannels(ctx)
-	go l.ListenStorage(ctx)
+func (l *ChatListener) StartListening() {
+	go l.ListenChannels(l.ctx)
+	go l.ListenStorage(l.ctx)
 }
 
-func (l *ChatListener) AddClient(ctx context.Context, clientChan ClientConn) {
-	l.newClients <- clientChan
+// ClientsCount возвращает количество подключенных клиентов
+func (l *ChatListener) ClientsCount() int {
+	return int(l.clients.Load())
 }
 
+// Done закрывается, когда слушатель полностью остановлен
+func (l *ChatListener) Done() <-chan struct{} {
+	return l.done
+}
+
+func (l *ChatListener) AddClient(ctx context.Context, clientChan ClientConn) error {
+	select {
+	case l.newClients <- clientChan:
+		return nil
+	case <-l.done:
+		return domain.Unavailable(domain.EntityListener, ListenerClosedError)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RemoveClient отписывает клиента и закрывает его канал.
+// Если слушатель уже остановлен, канал был закрыт при остановке.
 func (l *ChatListener) RemoveClient(ctx context.Context, clientChan ClientConn) {
-	l.closedClients <- clientChan
+	select {
+	case l.closedClients <- clientChan:
+	case <-l.done:
+	}
 }
 
-func (l *ChatListener) BroadcastMessage(ctx context.Context, message domain.Message) {
-	l.messages <- message
+func (l *ChatListener) BroadcastEvent(ctx context.Context, event domain.Event) {
+	select {
+	case l.messages <- event:
+	case <-ctx.Done():
+	}
 }
 
 // Прослушивание новых сообщений из стораджа
@@ -65,50 +129,95 @@ func (l *ChatListener) ListenStorage(ctx context.Context) {
 
 	for {
 		select {
-		case msg := <-messageChan:
-			l.BroadcastMessage(ctx, msg)
+		case event, ok := <-messageChan:
+			if !ok {
+				return
+			}
+			l.BroadcastEvent(ctx, event)
 		case <-ctx.Done():
-			log.Printf("Stopping storage listener for chat %d", l.ChatId)
+			l.log.Debug("stopping storage listener")
 			return
 		}
 	}
 }
 
+// deliver рассылает событие всем клиентам слушателя.
+// Спан доставки начинает новую трассировку и ссылается на запрос, опубликовавший событие,
+// который мог выполняться на другом инстансе.
+func (l *ChatListener) deliver(ctx context.Context, event domain.Event) {
+	opts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.Int("chat.id", l.ChatId),
+			attribute.String("chat.event", string(event.Type)),
+			attribute.Int("chat.clients", len(l.totalClients)),
+		),
+	}
+	if origin := trace.SpanContextFromContext(tracing.Extract(ctx, event.Trace)); origin.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: origin}))
+	}
+	_, span := l.tracer.Start(ctx, "ChatListener.deliver", opts...)
+	defer span.End()
+
+	dropped := 0
+	for clientMessageChan := range l.totalClients {
+		select {
+		case clientMessageChan <- event:
+			// Message sent successfully
+			l.metrics.EventDelivered(string(event.Type))
+		default:
+			// Failed to send, dropping message
+			dropped++
+			l.metrics.EventDropped(string(event.Type))
+			l.log.Warn("failed to send event to client, dropping", slog.String("event", string(event.Type)))
+		}
+	}
+	span.SetAttributes(attribute.Int("chat.dropped", dropped))
+}
+
 // Прослушивание каналов для управления клиентами и рассылки сообщений
-func (l *ChatListener) ListenChannels(ctx context.Context) (<-chan domain.Message, error) {
+func (l *ChatListener) ListenChannels(ctx context.Context) {
+	defer close(l.done)
+
 	for {
 		select {
 		// Add new available client
 		case client := <-l.newClients:
 			l.totalClients[client] = true
-			log.Printf("Client added to chat %d. %d registered clients", l.ChatId, len(l.totalClients))
+			l.clients.Store(int64(len(l.totalClients)))
+			l.log.Debug("client added", slog.Int("clients", len(l.totalClients)))
 
 		// Remove closed client
 		case client := <-l.closedClients:
-			delete(l.totalClients, client)
-			close(client)
-			log.Printf("Removed client from chatId %d. %d registered clients", l.ChatId, len(l.totalClients))
-
-			if len(l.totalClients) == 0 {
-				err := l.chatManager.CloseChat(l.ChatId)
-				if err != nil {
-					log.Printf("Error while closing chat %d: %v", l.ChatId, err)
-				}
-				return nil, nil
+			if l.totalClients[client] {
+				delete(l.totalClients, client)
+				close(client)
 			}
+			l.clients.Store(int64(len(l.totalClients)))
+			l.log.Debug("client removed", slog.Int("clients", len(l.totalClients)))
 
 		// Broadcast message to client
 		case eventMsg := <-l.messages:
-			for clientMessageChan := range l.totalClients {
-				select {
-				case clientMessageChan <- eventMsg:
-					log.Printf("Message sent to client")
-					// Message sent successfully
-				default:
-					log.Println("Failed to send message to client")
-					// Failed to send, dropping message
+			l.deliver(ctx, eventMsg)
+
+			// Chat was deleted: clients got the final event, stop listener and disconnect them
+			if eventMsg.Type == domain.EventChatDeleted {
+				if l.chatManager != nil {
+					l.chatManager.forget(l)
 				}
+				l.cancel()
 			}
+
+		// Listener stopped by manager, disconnect remaining clients
+		case <-ctx.Done():
+			for client := range l.totalClients {
+				delete(l.totalClients, client)
+				close(client)
+			}
+			l.clients.Store(0)
+			l.log.Debug("listener stopped")
+			return
 		}
 	}
 }
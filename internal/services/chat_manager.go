@@ -1,79 +1,235 @@
 package services
 
 import (
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/metrics"
 	"chat-project/internal/storage"
 	"context"
 	"errors"
+	"log/slog"
+	"sort"
 	"sync"
+	"time"
 )
 
-var ChatNotFoundError = errors.New("chat not found")
+var (
+	ManagerClosedError      = errors.New("chat listener manager is closed")
+	TooManyConnectionsError = errors.New("too many open connections")
+)
 
-// Менеджер слушателей, который будет хранить всех слушателей для разных чатов и создавать новых при необходимости
+// Менеджер слушателей, который будет хранить всех слушателей для разных чатов и создавать новых при необходимости.
+// Слушатели учитываются по ссылкам: Acquire увеличивает счетчик, Release уменьшает.
+// Когда ссылок не осталось, слушатель останавливается после gracePeriod,
+// если за это время его никто снова не запросил.
+// Менеджер также считает открытые подключения каждого клиента, чтобы один клиент не занял все соединения инстанса.
 type ChatListenerManager struct {
-	repoChat       storage.ChatRepo
-	listener       storage.ChatListener
-	chatListeners  map[int]*ChatListener
-	closedChannels chan int
-	mu sync.Mutex
+	repoChat      storage.ChatRepo
+	listener      storage.ChatListener
+	gracePeriod   time.Duration
+	maxClientConn int
+	chatListeners map[int]*ChatListener
+	clientConns   map[string]int
+	mu            sync.Mutex
+	log           *slog.Logger
+	metrics       *metrics.Metrics
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed bool
+}
+
+func NewChatListenerManager(repoChat storage.ChatRepo, listener storage.ChatListener, cfg config.Listener, log *slog.Logger, metrics *metrics.Metrics) *ChatListenerManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ChatListenerManager{
+		repoChat:      repoChat,
+		listener:      listener,
+		gracePeriod:   cfg.GracePeriod,
+		maxClientConn: cfg.MaxConnectionsPerClient,
+		chatListeners: make(map[int]*ChatListener),
+		clientConns:   make(map[string]int),
+		log:           log,
+		metrics:       metrics,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
 }
 
-func NewChatListenerManager(repoChat storage.ChatRepo, listener storage.ChatListener) *ChatListenerManager {
-	chatListener := &ChatListenerManager{
-		repoChat:       repoChat,
-		listener:       listener,
-		chatListeners:  make(map[int]*ChatListener),
-		closedChannels: make(chan int, 1),
+// Acquire возвращает запущенного слушателя чата и увеличивает счетчик ссылок на него.
+// Каждый успешный вызов должен завершаться вызовом Release.
+func (m *ChatListenerManager) Acquire(ctx context.Context, chatId int) (*ChatListener, error) {
+	if listener, err := m.acquireExisting(chatId); listener != nil || err != nil {
+		return listener, err
+	}
+
+	if _, err := m.repoChat.GetChatByID(ctx, chatId); err != nil {
+		return nil, err
 	}
-	go chatListener.ListenChannels()
 
-	return chatListener
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, domain.Unavailable(domain.EntityListener, ManagerClosedError)
+	}
+	if listener, exists := m.chatListeners[chatId]; exists {
+		m.retain(listener)
+		return listener, nil
+	}
+
+	// запустить прослушивание стораджа и отправлять в канал сообщений
+	listener := NewChatListener(m.ctx, m.listener, m, chatId, m.log, m.metrics)
+	listener.refs = 1
+	m.chatListeners[chatId] = listener
+	listener.StartListening()
+
+	return listener, nil
 }
 
-func (m *ChatListenerManager) ListenChannels() {
-	for chatId := range m.closedChannels {
+func (m *ChatListenerManager) acquireExisting(chatId int) (*ChatListener, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, domain.Unavailable(domain.EntityListener, ManagerClosedError)
+	}
+	listener, exists := m.chatListeners[chatId]
+	if !exists {
+		return nil, nil
+	}
+	m.retain(listener)
+	return listener, nil
+}
+
+// retain must be called with m.mu held
+func (m *ChatListenerManager) retain(listener *ChatListener) {
+	listener.refs++
+	// Invalidate any pending teardown
+	listener.idleSeq++
+}
+
+// Release уменьшает счетчик ссылок и планирует остановку слушателя, когда ссылок не осталось
+func (m *ChatListenerManager) Release(listener *ChatListener) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if listener.refs == 0 {
+		return
+	}
+	listener.refs--
+	if listener.refs > 0 {
+		return
+	}
+
+	listener.idleSeq++
+	seq := listener.idleSeq
+	if m.gracePeriod <= 0 {
+		m.evict(listener)
+		return
+	}
+	time.AfterFunc(m.gracePeriod, func() {
 		m.mu.Lock()
-		delete(m.chatListeners, chatId)
-		m.mu.Unlock()
+		defer m.mu.Unlock()
+		if listener.refs == 0 && listener.idleSeq == seq {
+			m.evict(listener)
+		}
+	})
+}
+
+// evict must be called with m.mu held
+func (m *ChatListenerManager) evict(listener *ChatListener) {
+	if m.chatListeners[listener.ChatId] == listener {
+		delete(m.chatListeners, listener.ChatId)
+	}
+	listener.cancel()
+}
+
+// forget убирает слушателя из менеджера, чтобы новые подписки создали нового слушателя
+func (m *ChatListenerManager) forget(listener *ChatListener) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.chatListeners[listener.ChatId] == listener {
+		delete(m.chatListeners, listener.ChatId)
 	}
 }
 
+// CloseChat немедленно останавливает слушателя чата и отключает всех его клиентов
 func (m *ChatListenerManager) CloseChat(chatId int) error {
 	m.mu.Lock()
-	_, exists := m.chatListeners[chatId]
+	listener, exists := m.chatListeners[chatId]
+	if exists {
+		m.evict(listener)
+	}
 	m.mu.Unlock()
 	if !exists {
-		return ChatNotFoundError
+		return domain.NotFound(domain.EntityListener, chatId)
 	}
 
-	m.closedChannels <- chatId
+	<-listener.Done()
 	return nil
 }
 
-func (m *ChatListenerManager) GetChatListener(chatId int) (*ChatListener, error) {
+// Connect учитывает открытое подключение клиента и отклоняет его, если у клиента уже открыто MaxConnectionsPerClient.
+// Каждый успешный вызов должен завершаться вызовом Disconnect с тем же ключом
+func (m *ChatListenerManager) Connect(client string) error {
 	m.mu.Lock()
-	listener, exists := m.chatListeners[chatId]
-	m.mu.Unlock()
-	if exists {
-		return listener, nil
+	defer m.mu.Unlock()
+	if m.maxClientConn > 0 && m.clientConns[client] >= m.maxClientConn {
+		m.metrics.RateLimited("sse_connections")
+		return domain.RateLimited(domain.EntityListener, nil, TooManyConnectionsError)
 	}
+	m.clientConns[client]++
+	return nil
+}
 
-	if _, err := m.repoChat.GetChatByID(context.Background(), chatId); err != nil {
-		return nil, err
+// Disconnect освобождает подключение клиента, учтенное Connect
+func (m *ChatListenerManager) Disconnect(client string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.clientConns[client] <= 1 {
+		delete(m.clientConns, client)
+		return
 	}
+	m.clientConns[client]--
+}
 
-	// запустить прослушивание стораджа и отправлять в канал сообщений
+// ListenersCount возвращает количество запущенных слушателей
+func (m *ChatListenerManager) ListenersCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.chatListeners)
+}
 
-	chatListener := NewChatListener(m.listener, m, chatId)
+type ListenerStats struct {
+	ChatId  int
+	Clients int
+	Refs    int
+}
 
+// Stats возвращает состояние запущенных слушателей, отсортированное по chatId
+func (m *ChatListenerManager) Stats() []ListenerStats {
 	m.mu.Lock()
-	if _, exists := m.chatListeners[chatId]; exists {
-		m.mu.Unlock()
-		return m.chatListeners[chatId], nil
+	defer m.mu.Unlock()
+
+	stats := make([]ListenerStats, 0, len(m.chatListeners))
+	for chatId, listener := range m.chatListeners {
+		stats = append(stats, ListenerStats{ChatId: chatId, Clients: listener.ClientsCount(), Refs: listener.refs})
 	}
-	m.chatListeners[chatId] = chatListener
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ChatId < stats[j].ChatId })
+	return stats
+}
+
+// Close останавливает всех слушателей, новые подписки после этого невозможны
+func (m *ChatListenerManager) Close() {
+	m.mu.Lock()
+	m.closed = true
+	listeners := make([]*ChatListener, 0, len(m.chatListeners))
+	for _, listener := range m.chatListeners {
+		listeners = append(listeners, listener)
+	}
+	m.chatListeners = make(map[int]*ChatListener)
 	m.mu.Unlock()
 
-	go chatListener.StartListening(context.Background())
-	return chatListener, nil
+	m.cancel()
+	for _, listener := range listeners {
+		<-listener.Done()
+	}
 }
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/logger"
+	"chat-project/internal/storage/memory"
+)
+
+func newTestManager(t *testing.T, gracePeriod time.Duration) (*ChatListenerManager, *memory.ListenerMemory, int) {
+	t.Helper()
+
+	repo := memory.NewUserRepoMemory()
+	chat, err := repo.CreateChat(context.Background(), domain.Chat{Title: "test", CreatedAt: time.Now()}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+
+	listener := memory.NewListenerMemory()
+	manager := NewChatListenerManager(repo, listener, config.Listener{GracePeriod: gracePeriod}, logger.Discard(), nil)
+	t.Cleanup(manager.Close)
+
+	return manager, listener, chat.ID
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestChatListenerManagerUnknownChat(t *testing.T) {
+	manager, _, chatId := newTestManager(t, 0)
+
+	if _, err := manager.Acquire(context.Background(), chatId+1); !domain.IsNotFound(err, domain.EntityChat) {
+		t.Fatalf("expected chat not found error, got %v", err)
+	}
+	if n := manager.ListenersCount(); n != 0 {
+		t.Fatalf("expected no listeners, got %d", n)
+	}
+}
+
+func TestChatListenerManagerGracePeriod(t *testing.T) {
+	manager, listener, chatId := newTestManager(t, 50*time.Millisecond)
+	ctx := context.Background()
+
+	first, err := manager.Acquire(ctx, chatId)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	manager.Release(first)
+
+	second, err := manager.Acquire(ctx, chatId)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if first != second {
+		t.Fatal("listener was not reused within grace period")
+	}
+	manager.Release(second)
+
+	waitFor(t, func() bool { return manager.ListenersCount() == 0 })
+	<-second.Done()
+	waitFor(t, func() bool { return listener.SubscribersCount(chatId) == 0 })
+
+	third, err := manager.Acquire(ctx, chatId)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer manager.Release(third)
+	if third == second {
+		t.Fatal("stopped listener was returned")
+	}
+}
+
+func TestChatListenerManagerCloseChat(t *testing.T) {
+	manager, _, chatId := newTestManager(t, time.Minute)
+	ctx := context.Background()
+
+	chatListener, err := manager.Acquire(ctx, chatId)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	client := NewClientConn()
+	if err := chatListener.AddClient(ctx, client); err != nil {
+		t.Fatalf("add client: %v", err)
+	}
+
+	if err := manager.CloseChat(chatId); err != nil {
+		t.Fatalf("close chat: %v", err)
+	}
+	if _, ok := <-client; ok {
+		t.Fatal("client channel was not closed")
+	}
+	if err := chatListener.AddClient(ctx, NewClientConn()); !errors.Is(err, ListenerClosedError) || !errors.Is(err, domain.UnavailableError) {
+		t.Fatalf("expected ListenerClosedError, got %v", err)
+	}
+
+	chatListener.RemoveClient(ctx, client)
+	manager.Release(chatListener)
+}
+
+func TestChatListenerManagerConcurrentClients(t *testing.T) {
+	const clients = 5000
+
+	manager, listener, chatId := newTestManager(t, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Publish continuously while clients come and go
+	publisherDone := make(chan struct{})
+	go func() {
+		defer close(publisherDone)
+		for i := 0; ctx.Err() == nil; i++ {
+			_ = listener.Publish(ctx, chatId, domain.NewMessageCreatedEvent(domain.Message{ID: i, ChatId: chatId, Text: "hi"}))
+			time.Sleep(10 * time.Microsecond)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			chatListener, err := manager.Acquire(ctx, chatId)
+			if err != nil {
+				t.Errorf("acquire: %v", err)
+				return
+			}
+			defer manager.Release(chatListener)
+
+			client := NewClientConn()
+			if err := chatListener.AddClient(ctx, client); err != nil {
+				t.Errorf("add client: %v", err)
+				return
+			}
+
+			select {
+			case <-client:
+			case <-time.After(time.Millisecond):
+			}
+
+			chatListener.RemoveClient(ctx, client)
+			for range client {
+				// drain until closed by the listener
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
+	<-publisherDone
+
+	waitFor(t, func() bool { return manager.ListenersCount() == 0 })
+	waitFor(t, func() bool { return listener.SubscribersCount(chatId) == 0 })
+}
+
+func TestChatListenerChatDeletedEvent(t *testing.T) {
+	manager, listener, chatId := newTestManager(t, time.Minute)
+	ctx := context.Background()
+
+	chatListener, err := manager.Acquire(ctx, chatId)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer manager.Release(chatListener)
+
+	client := NewClientConn()
+	if err := chatListener.AddClient(ctx, client); err != nil {
+		t.Fatalf("add client: %v", err)
+	}
+	waitFor(t, func() bool { return listener.SubscribersCount(chatId) == 1 })
+
+	if err := listener.Publish(ctx, chatId, domain.NewChatDeletedEvent(chatId)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	event, ok := <-client
+	if !ok || event.Type != domain.EventChatDeleted {
+		t.Fatalf("expected final %s event, got %+v", domain.EventChatDeleted, event)
+	}
+	if _, ok := <-client; ok {
+		t.Fatal("client channel was not closed after chat deletion")
+	}
+	<-chatListener.Done()
+	if n := manager.ListenersCount(); n != 0 {
+		t.Fatalf("expected listener to be evicted, got %d listeners", n)
+	}
+}
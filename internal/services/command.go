@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"chat-project/internal/commands"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/storage"
+)
+
+// runCommand выполняет слэш-команду. Команда считается сообщением для медленного режима.
+// Ответ Ephemeral возвращается только отправителю и не сохраняется. Повтор с тем же ClientNonce
+// возвращает уже сохраненный публичный ответ и не выполняет команду снова
+func (c ChatService) runCommand(ctx context.Context, chatId int, sender Sender, message dto.MessageIn, name, args string) (*dto.MessageResponse, error) {
+	chat, err := c.chatRepo.GetChatByID(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+
+	cmd, ok := c.commands.Lookup(name)
+	if !ok {
+		text := fmt.Sprintf("Unknown command /%s, see /help. Start a message with // to send it as text", name)
+		return newEphemeralResponse(chatId, "help", message.ClientNonce, text), nil
+	}
+	reply := domain.Message{ChatId: chatId, Kind: domain.MessageKindBot, AuthorId: name, InvokedBy: sender.UserId, ClientNonce: message.ClientNonce}
+	if existing, ok, err := c.sentReply(ctx, reply); ok || err != nil {
+		return existing, err
+	}
+	if err = c.checkSlowMode(ctx, chatId, sender); err != nil {
+		return nil, err
+	}
+
+	env := commandEnv{service: c, chatId: chatId, author: name, userId: sender.UserId}
+	out, err := cmd.Run(ctx, commands.Request{Name: name, Args: args, UserId: sender.UserId, Chat: chat, Env: env})
+	if err != nil {
+		return nil, fmt.Errorf("error while running command /%s: %w", name, err)
+	}
+	c.log.DebugContext(ctx, "command executed", slog.Int("chat_id", chatId), slog.String("command", name), slog.Bool("public", out.Public))
+
+	if !out.Public {
+		return newEphemeralResponse(chatId, name, message.ClientNonce, out.Text), nil
+	}
+	reply.Text = out.Text
+	resp, err := c.addBotMessage(ctx, reply)
+	if errors.Is(err, storage.MessageNonceConflictError) {
+		// Параллельный повтор успел сохранить ответ первым
+		if existing, ok, sentErr := c.sentReply(ctx, reply); ok || sentErr != nil {
+			return existing, sentErr
+		}
+	}
+	return resp, err
+}
+
+// sentReply ищет публичный ответ на команду с тем же ClientNonce от того же пользователя.
+// Текст не сравнивается: ответ, например /roll, при повторе мог бы получиться другим
+func (c ChatService) sentReply(ctx context.Context, reply domain.Message) (*dto.MessageResponse, bool, error) {
+	if reply.ClientNonce == "" {
+		return nil, false, nil
+	}
+	existing, err := c.chatRepo.GetMessageByNonce(ctx, reply.ChatId, reply.AuthorId, reply.InvokedBy, reply.ClientNonce)
+	if domain.IsNotFound(err, domain.EntityMessage) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error while getting command reply by client nonce: %w", err)
+	}
+	return newMessageResponse(existing), true, nil
+}
+
+// addBotMessage публикует сообщение бота команды, вызванной msg.InvokedBy. Текст проверяется по правилам пользовательских сообщений
+func (c ChatService) addBotMessage(ctx context.Context, msg domain.Message) (*dto.MessageResponse, error) {
+	message := dto.MessageIn{Text: msg.Text}
+	if err := c.validator.Validate(domain.EntityMessage, &message); err != nil {
+		return nil, err
+	}
+	msg.Text = message.Text
+	return c.addMessage(ctx, msg, msg.InvokedBy)
+}
+
+func newEphemeralResponse(chatId int, author, clientNonce, text string) *dto.MessageResponse {
+	return &dto.MessageResponse{
+		ChatId:      chatId,
+		Kind:        string(domain.MessageKindBot),
+		Text:        text,
+		AuthorId:    author,
+		ClientNonce: clientNonce,
+		Ephemeral:   true,
+		CreatedAt:   time.Now().Format(time.RFC3339),
+	}
+}
+
+// commandEnv действия команды над чатом, в котором она вызвана
+type commandEnv struct {
+	service ChatService
+	chatId  int
+	author  string
+	userId  string
+}
+
+func (e commandEnv) Post(ctx context.Context, text string) error {
+	_, err := e.service.addBotMessage(ctx, domain.Message{ChatId: e.chatId, Kind: domain.MessageKindBot, AuthorId: e.author, InvokedBy: e.userId, Text: text})
+	return err
+}
+
+func (e commandEnv) SetTopic(ctx context.Context, topic string) error {
+	_, err := e.service.UpdateChat(ctx, e.chatId, e.userId, dto.ChatPatchIn{Description: &topic}, 0)
+	return err
+}
+
+// Schedule создает запланированное сообщение бота. Оно видно в списке запланированных сообщений вызвавшего пользователя
+func (e commandEnv) Schedule(ctx context.Context, at time.Time, text string) error {
+	message := dto.MessageIn{Text: text}
+	if err := e.service.validator.Validate(domain.EntityMessage, &message); err != nil {
+		return err
+	}
+	_, err := e.service.schedule(ctx, domain.ScheduledMessage{
+		ChatId:    e.chatId,
+		Kind:      domain.MessageKindBot,
+		AuthorId:  e.author,
+		CreatedBy: e.userId,
+		Text:      message.Text,
+		SendAt:    at,
+	})
+	return err
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"chat-project/config"
+	"chat-project/internal/commands"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+// counter публичная команда, ответ которой меняется при каждом запуске, как у /roll
+type counter struct{ runs *int }
+
+func (counter) Name() string  { return "count" }
+func (counter) Usage() string { return "/count" }
+
+func (c counter) Run(ctx context.Context, req commands.Request) (commands.Reply, error) {
+	*c.runs++
+	return commands.Reply{Text: strconv.Itoa(*c.runs), Public: true}, nil
+}
+
+func newCommandService(t *testing.T, repo *memory.ChatRepoMemory, cmds ...commands.Command) *ChatService {
+	t.Helper()
+	registry := commands.NewRegistry()
+	for _, cmd := range cmds {
+		if err := registry.Register(cmd); err != nil {
+			t.Fatalf("register command: %v", err)
+		}
+	}
+	return New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), memory.NewTxManagerMemory(), registry, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+}
+
+func TestCommandReplyNonce(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	runs := 0
+	chats := newCommandService(t, repo, counter{runs: &runs})
+
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "dice"}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	send := func(userId string) *dto.MessageResponse {
+		t.Helper()
+		resp, err := chats.AddMessage(ctx, chat.ID, Sender{UserId: userId}, dto.MessageIn{Text: "/count", ClientNonce: "n-1"})
+		if err != nil {
+			t.Fatalf("user %s: run command: %v", userId, err)
+		}
+		return resp
+	}
+
+	first := send("alice")
+	retry := send("alice")
+	if retry.ID != first.ID || retry.Text != first.Text || runs != 1 {
+		t.Fatalf("retry with the same nonce ran the command again: %+v, %+v, runs %d", first, retry, runs)
+	}
+	if first.ClientNonce != "n-1" {
+		t.Fatalf("nonce not echoed: %+v", first)
+	}
+
+	// Nonce принадлежит вызвавшему пользователю, у другого пользователя команда выполняется
+	other := send("bob")
+	if other.ID == first.ID || runs != 2 {
+		t.Fatalf("expected a separate reply for another user: %+v, runs %d", other, runs)
+	}
+
+	got, err := chats.GetWithMessages(ctx, chat.ID, "alice")
+	if err != nil || len(got.Messages) != 2 {
+		t.Fatalf("expected 2 bot messages, got %+v, %v", got, err)
+	}
+}
+
+func TestTopicInOwnerlessChat(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	chats := newCommandService(t, repo, commands.Topic{})
+
+	// /topic меняет чат через UpdateChat, поэтому в чате без владельца тему задает любой участник
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "lobby"}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if _, err := chats.AddMessage(ctx, chat.ID, Sender{UserId: "bob"}, dto.MessageIn{Text: "/topic Lunch plans"}); err != nil {
+		t.Fatalf("set topic in ownerless chat: %v", err)
+	}
+	got, err := chats.GetWithMessages(ctx, chat.ID, "bob")
+	if err != nil || got.Description != "Lunch plans" {
+		t.Fatalf("topic not set: %+v, %v", got, err)
+	}
+
+	// С появлением администратора тему меняет только он
+	if err := repo.AddMember(ctx, domain.ChatMember{ChatId: chat.ID, UserId: "alice", Role: domain.ChatRoleAdmin}); err != nil {
+		t.Fatalf("add admin: %v", err)
+	}
+	if _, err := chats.AddMessage(ctx, chat.ID, Sender{UserId: "bob"}, dto.MessageIn{Text: "/topic Dinner plans"}); !errors.Is(err, NotChatManagerError) {
+		t.Fatalf("expected NotChatManagerError once the chat has an admin, got %v", err)
+	}
+	if _, err := chats.AddMessage(ctx, chat.ID, Sender{UserId: "alice"}, dto.MessageIn{Text: "/topic Dinner plans"}); err != nil {
+		t.Fatalf("admin sets topic: %v", err)
+	}
+}
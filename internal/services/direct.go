@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/tracing"
+)
+
+var (
+	SelfDirectChatError  = errors.New("direct chat with yourself is not allowed")
+	DirectChatPatchError = errors.New("direct chats have no title, description, avatar or privacy to change")
+)
+
+// Получить или создать личный чат текущего пользователя с другим пользователем.
+// Второе значение сообщает, был ли чат создан этим вызовом.
+func (c ChatService) GetOrCreateDirectChat(ctx context.Context, userId, peerId string) (resp *dto.ChatResponse, created bool, err error) {
+	ctx, span := c.startSpan(ctx, "GetOrCreateDirectChat")
+	defer func() { tracing.End(span, err) }()
+
+	if userId == peerId {
+		return nil, false, domain.Validation(domain.EntityChat, SelfDirectChatError)
+	}
+
+	chat, created, err := c.chatRepo.GetOrCreateDirectChat(ctx, userId, peerId)
+	if err != nil {
+		return nil, false, fmt.Errorf("error while getting direct chat: %w", err)
+	}
+	span.SetAttributes(attribute.Int("chat.id", chat.ID), attribute.Bool("chat.created", created))
+
+	if created {
+		c.log.InfoContext(ctx, "direct chat created", slog.Int("chat_id", chat.ID))
+	}
+
+	return newChatResponse(chat), created, nil
+}
+
+// directChatMetadata изменение полей, которых у личного чата нет: он всегда приватный и называется по собеседнику
+func directChatMetadata(patch dto.ChatPatchIn) bool {
+	return patch.Title != nil || patch.Private != nil || patch.Description != nil || patch.AvatarURL != nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/metrics"
+	"chat-project/internal/storage"
+	"chat-project/internal/tracing"
+)
+
+// ExpiryService удаляет самоуничтожающиеся сообщения с истекшим TTL
+type ExpiryService struct {
+	expired storage.ExpiredMessageRepo
+	chats   *ChatService
+	cfg     config.Expiry
+	log     *slog.Logger
+	metrics *metrics.Metrics
+	now     func() time.Time
+}
+
+func NewExpiryService(expired storage.ExpiredMessageRepo, chats *ChatService, cfg config.Expiry, log *slog.Logger, m *metrics.Metrics) *ExpiryService {
+	return &ExpiryService{
+		expired: expired,
+		chats:   chats,
+		cfg:     cfg,
+		log:     log,
+		metrics: m,
+		now:     time.Now,
+	}
+}
+
+// Удалить сообщения с истекшим TTL пачками, пока они есть.
+// Подписчики и вебхуки каждого чата получают message.expired с id удаленных сообщений
+func (s ExpiryService) DeleteExpired(ctx context.Context) (deleted int, err error) {
+	ctx, span := s.chats.startSpan(ctx, "DeleteExpired")
+	defer func() { tracing.End(span, err) }()
+
+	batch := max(s.cfg.BatchSize, 1)
+	now := s.now()
+	for {
+		var messages []domain.Message
+		events, err := s.chats.withEvents(ctx, func(ctx context.Context) ([]domain.Event, error) {
+			var err error
+			messages, err = s.expired.DeleteExpiredMessages(ctx, now, batch)
+			if err != nil {
+				return nil, err
+			}
+			return expiredEvents(messages), nil
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("error while deleting expired messages: %w", err)
+		}
+		deleted += len(messages)
+		s.metrics.MessagesExpired(len(messages))
+		for _, event := range events {
+			s.chats.notify(ctx, event)
+		}
+
+		if len(messages) < batch || ctx.Err() != nil {
+			break
+		}
+	}
+	if deleted > 0 {
+		s.log.DebugContext(ctx, "expired messages deleted", slog.Int("deleted", deleted))
+	}
+	return deleted, ctx.Err()
+}
+
+// expiredEvents одно событие на чат, в порядке истечения сообщений
+func expiredEvents(messages []domain.Message) []domain.Event {
+	chatIds := make([]int, 0)
+	ids := make(map[int][]int)
+	for _, msg := range messages {
+		if _, ok := ids[msg.ChatId]; !ok {
+			chatIds = append(chatIds, msg.ChatId)
+		}
+		ids[msg.ChatId] = append(ids[msg.ChatId], msg.ID)
+	}
+	events := make([]domain.Event, 0, len(chatIds))
+	for _, chatId := range chatIds {
+		events = append(events, domain.NewMessagesExpiredEvent(chatId, ids[chatId]))
+	}
+	return events
+}
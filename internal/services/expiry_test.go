@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+func TestExpiringMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	repo := memory.NewUserRepoMemory()
+	repo.SetClock(clock)
+	listener := memory.NewListenerMemory()
+	chats := New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), listener, memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+	chats.now = clock
+	service := NewExpiryService(memory.NewExpiredMessageRepoMemory(repo), chats, config.Expiry{BatchSize: 10}, logger.Discard(), nil)
+	service.now = clock
+
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "secrets"}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	sender := Sender{UserId: "alice"}
+	secret, err := chats.AddMessage(ctx, chat.ID, sender, dto.MessageIn{Text: "password", TtlSeconds: 60})
+	if err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	if secret.ExpiresAt != "2024-06-01T12:01:00Z" {
+		t.Fatalf("unexpected ExpiresAt %q", secret.ExpiresAt)
+	}
+	if _, err = chats.AddMessage(ctx, chat.ID, sender, dto.MessageIn{Text: "plain"}); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	events := listener.Subscribe(ctx, chat.ID)
+
+	if deleted, err := service.DeleteExpired(ctx); err != nil || deleted != 0 {
+		t.Fatalf("deleted before expiry: %d, %v", deleted, err)
+	}
+
+	now = now.Add(time.Minute)
+	got, err := chats.GetWithMessages(ctx, chat.ID, "alice")
+	if err != nil || len(got.Messages) != 1 || got.Messages[0].Text != "plain" {
+		t.Fatalf("expired message must be hidden: %+v, %v", got, err)
+	}
+
+	deleted, err := service.DeleteExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("delete expired: %d, %v", deleted, err)
+	}
+	event := <-events
+	if event.Type != domain.EventMessageExpired || len(event.MessageIds) != 1 || event.MessageIds[0] != secret.ID {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	_, err = chats.ScheduleMessage(ctx, chat.ID, sender, dto.MessageIn{Text: "later", SendAt: &now, TtlSeconds: 60})
+	if !errors.Is(err, ScheduledTTLError) {
+		t.Fatalf("expected scheduled ttl error, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/storage"
+	"chat-project/internal/validation"
+)
+
+// Путь публикации сообщений во входящий вебхук, к нему добавляется токен
+const IncomingWebhookPath = "/v1/hooks/"
+
+type IncomingWebhookService struct {
+	chatRepo  storage.ChatRepo
+	hookRepo  storage.IncomingWebhookRepo
+	chats     *ChatService
+	validator *validation.Validator
+	log       *slog.Logger
+}
+
+func NewIncomingWebhookService(chatRepo storage.ChatRepo, hookRepo storage.IncomingWebhookRepo, chats *ChatService, validator *validation.Validator, log *slog.Logger) *IncomingWebhookService {
+	return &IncomingWebhookService{
+		chatRepo:  chatRepo,
+		hookRepo:  hookRepo,
+		chats:     chats,
+		validator: validator,
+		log:       log,
+	}
+}
+
+// Создать входящий вебхук чата. Токен возвращается только в этом ответе
+func (s IncomingWebhookService) CreateIncomingWebhook(ctx context.Context, chatId int, userId string, in dto.IncomingWebhookIn) (*dto.IncomingWebhookCreatedResponse, error) {
+	if err := s.validator.Validate(domain.EntityIncomingWebhook, &in); err != nil {
+		return nil, err
+	}
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return nil, err
+	}
+
+	token, hash, err := newSecretCode()
+	if err != nil {
+		return nil, fmt.Errorf("error while generating incoming webhook token: %w", err)
+	}
+
+	hook, err := s.hookRepo.CreateIncomingWebhook(ctx, domain.IncomingWebhook{
+		ChatId:    chatId,
+		Name:      in.Name,
+		TokenHash: hash,
+		CreatedBy: userId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while creating incoming webhook for chat with id %d: %w", chatId, err)
+	}
+	s.log.InfoContext(ctx, "incoming webhook created", slog.Int("chat_id", chatId), slog.Int("incoming_webhook_id", hook.ID))
+
+	return &dto.IncomingWebhookCreatedResponse{
+		IncomingWebhookResponse: newIncomingWebhookResponse(hook),
+		Token:                   token,
+		Path:                    IncomingWebhookPath + token,
+	}, nil
+}
+
+// Список входящих вебхуков чата
+func (s IncomingWebhookService) ListIncomingWebhooks(ctx context.Context, chatId int, userId string) (*dto.IncomingWebhooksResponse, error) {
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return nil, err
+	}
+
+	hooks, err := s.hookRepo.ListIncomingWebhooks(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing incoming webhooks of chat with id %d: %w", chatId, err)
+	}
+
+	resp := &dto.IncomingWebhooksResponse{Webhooks: make([]dto.IncomingWebhookResponse, 0, len(hooks))}
+	for _, hook := range hooks {
+		resp.Webhooks = append(resp.Webhooks, newIncomingWebhookResponse(hook))
+	}
+	return resp, nil
+}
+
+// Удалить входящий вебхук, его токен сразу перестает действовать
+func (s IncomingWebhookService) DeleteIncomingWebhook(ctx context.Context, chatId, hookId int, userId string) error {
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return err
+	}
+
+	if err := s.hookRepo.DeleteIncomingWebhook(ctx, chatId, hookId); err != nil {
+		return fmt.Errorf("error while deleting incoming webhook %d: %w", hookId, err)
+	}
+	s.log.InfoContext(ctx, "incoming webhook deleted", slog.Int("chat_id", chatId), slog.Int("incoming_webhook_id", hookId))
+	return nil
+}
+
+// Опубликовать сообщение по токену входящего вебхука от имени его бота
+func (s IncomingWebhookService) PostMessage(ctx context.Context, token string, in dto.IncomingMessageIn) (*dto.MessageResponse, error) {
+	hook, err := s.hookRepo.GetIncomingWebhookByToken(ctx, hashSecretCode(token))
+	if err != nil {
+		return nil, fmt.Errorf("error while getting incoming webhook: %w", err)
+	}
+	return s.chats.AddMessage(ctx, hook.ChatId, Sender{Bot: hook.Name}, dto.MessageIn{Text: incomingText(in)})
+}
+
+func (s IncomingWebhookService) requireManager(ctx context.Context, chatId int, userId string) error {
+	if _, err := s.chatRepo.GetChatByID(ctx, chatId); err != nil {
+		return fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+	return checkManager(ctx, s.chatRepo, chatId, userId)
+}
+
+// incomingText собирает текст сообщения как Slack: если есть blocks, text служит только запасным
+// вариантом для уведомлений. Текст вложений добавляется в конец
+func incomingText(in dto.IncomingMessageIn) string {
+	parts := make([]string, 0)
+	for _, block := range in.Blocks {
+		if block.Text != nil {
+			parts = append(parts, block.Text.Text)
+		}
+		for _, obj := range block.Fields {
+			parts = append(parts, obj.Text)
+		}
+		for _, obj := range block.Elements {
+			parts = append(parts, obj.Text)
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, in.Text)
+	}
+	for _, a := range in.Attachments {
+		attachment := []string{a.Pretext, a.Title, a.Text}
+		if a.Title == "" && a.Text == "" {
+			attachment = []string{a.Pretext, a.Fallback}
+		}
+		parts = append(parts, attachment...)
+	}
+
+	lines := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if strings.TrimSpace(part) != "" {
+			lines = append(lines, part)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func newIncomingWebhookResponse(hook domain.IncomingWebhook) dto.IncomingWebhookResponse {
+	return dto.IncomingWebhookResponse{
+		ID:        hook.ID,
+		ChatId:    hook.ChatId,
+		Name:      hook.Name,
+		CreatedBy: hook.CreatedBy,
+		CreatedAt: hook.CreatedAt.Format(time.RFC3339),
+	}
+}
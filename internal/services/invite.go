@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/storage"
+)
+
+var (
+	NotChatManagerError    = errors.New("only chat owner or admin can manage this chat")
+	DirectChatInviteError  = errors.New("direct chats do not support invites")
+	InvalidInviteRoleError = errors.New("invite role must be member or admin")
+	InvalidInviteError     = errors.New("max uses and expiration must not be negative")
+)
+
+// Длина случайной части кодов приглашений и токенов входящих вебхуков в байтах
+const secretCodeSize = 32
+
+type InviteService struct {
+	chatRepo   storage.ChatRepo
+	inviteRepo storage.InviteRepo
+	log        *slog.Logger
+}
+
+func NewInviteService(chatRepo storage.ChatRepo, inviteRepo storage.InviteRepo, log *slog.Logger) *InviteService {
+	return &InviteService{
+		chatRepo:   chatRepo,
+		inviteRepo: inviteRepo,
+		log:        log,
+	}
+}
+
+// Создать приглашение в чат
+func (s InviteService) CreateInvite(ctx context.Context, chatId int, userId string, in dto.InviteIn) (*dto.InviteCreatedResponse, error) {
+	if in.Role == "" {
+		in.Role = domain.ChatRoleMember
+	}
+	if in.Role != domain.ChatRoleMember && in.Role != domain.ChatRoleAdmin {
+		return nil, domain.Validation(domain.EntityInvite, InvalidInviteRoleError)
+	}
+	if in.MaxUses < 0 || in.ExpiresIn < 0 {
+		return nil, domain.Validation(domain.EntityInvite, InvalidInviteError)
+	}
+
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return nil, err
+	}
+
+	code, hash, err := newSecretCode()
+	if err != nil {
+		return nil, fmt.Errorf("error while generating invite code: %w", err)
+	}
+
+	invite := domain.Invite{
+		ChatId:    chatId,
+		TokenHash: hash,
+		Role:      in.Role,
+		MaxUses:   in.MaxUses,
+		CreatedBy: userId,
+	}
+	if in.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(in.ExpiresIn) * time.Second)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	invite, err = s.inviteRepo.CreateInvite(ctx, invite)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating invite for chat with id %d: %w", chatId, err)
+	}
+	s.log.InfoContext(ctx, "invite created", slog.Int("chat_id", chatId), slog.Int("invite_id", invite.ID))
+
+	return &dto.InviteCreatedResponse{
+		InviteResponse: newInviteResponse(invite),
+		Code:           code,
+	}, nil
+}
+
+// Список приглашений чата
+func (s InviteService) ListInvites(ctx context.Context, chatId int, userId string) (*dto.InvitesResponse, error) {
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return nil, err
+	}
+
+	invites, err := s.inviteRepo.ListInvites(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing invites of chat with id %d: %w", chatId, err)
+	}
+
+	resp := &dto.InvitesResponse{Invites: make([]dto.InviteResponse, 0, len(invites))}
+	for _, invite := range invites {
+		resp.Invites = append(resp.Invites, newInviteResponse(invite))
+	}
+	return resp, nil
+}
+
+// Отозвать приглашение
+func (s InviteService) RevokeInvite(ctx context.Context, chatId, inviteId int, userId string) error {
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return err
+	}
+
+	if err := s.inviteRepo.RevokeInvite(ctx, chatId, inviteId); err != nil {
+		return fmt.Errorf("error while revoking invite %d: %w", inviteId, err)
+	}
+	s.log.InfoContext(ctx, "invite revoked", slog.Int("chat_id", chatId), slog.Int("invite_id", inviteId))
+	return nil
+}
+
+// Принять приглашение. Второе значение false, если пользователь уже был участником чата
+func (s InviteService) AcceptInvite(ctx context.Context, code, userId string) (*dto.MemberResponse, bool, error) {
+	member, joined, err := s.inviteRepo.AcceptInvite(ctx, hashSecretCode(code), userId)
+	if err != nil {
+		return nil, false, fmt.Errorf("error while accepting invite: %w", err)
+	}
+	if joined {
+		s.log.InfoContext(ctx, "invite accepted", slog.Int("chat_id", member.ChatId), slog.String("role", member.Role))
+	}
+
+	return &dto.MemberResponse{
+		ChatId:   member.ChatId,
+		UserId:   member.UserId,
+		Role:     member.Role,
+		JoinedAt: member.JoinedAt.Format(time.RFC3339),
+	}, joined, nil
+}
+
+func (s InviteService) requireManager(ctx context.Context, chatId int, userId string) error {
+	chat, err := s.chatRepo.GetChatByID(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+	if chat.Type == domain.ChatTypeDirect {
+		return domain.Validation(domain.EntityInvite, DirectChatInviteError)
+	}
+
+	return checkManager(ctx, s.chatRepo, chatId, userId)
+}
+
+// checkManager проверяет, что пользователь владелец или администратор чата
+func checkManager(ctx context.Context, chatRepo storage.ChatRepo, chatId int, userId string) error {
+	member, err := chatRepo.GetMember(ctx, chatId, userId)
+	if domain.IsNotFound(err, domain.EntityMember) {
+		return domain.Forbidden(domain.EntityChat, chatId, NotChatManagerError)
+	} else if err != nil {
+		return fmt.Errorf("error while getting member of chat with id %d: %w", chatId, err)
+	}
+	if !member.CanManage() {
+		return domain.Forbidden(domain.EntityChat, chatId, NotChatManagerError)
+	}
+	return nil
+}
+
+// newSecretCode возвращает случайный код и его хэш для хранения
+func newSecretCode() (string, []byte, error) {
+	buf := make([]byte, secretCodeSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	code := base64.RawURLEncoding.EncodeToString(buf)
+	return code, hashSecretCode(code), nil
+}
+
+func hashSecretCode(code string) []byte {
+	sum := sha256.Sum256([]byte(code))
+	return sum[:]
+}
+
+func newInviteResponse(invite domain.Invite) dto.InviteResponse {
+	resp := dto.InviteResponse{
+		ID:        invite.ID,
+		ChatId:    invite.ChatId,
+		Role:      invite.Role,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		CreatedBy: invite.CreatedBy,
+		CreatedAt: invite.CreatedAt.Format(time.RFC3339),
+		Revoked:   invite.RevokedAt != nil,
+	}
+	if invite.ExpiresAt != nil {
+		resp.ExpiresAt = invite.ExpiresAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/storage"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+type txMarker struct{}
+
+// recordingTx помечает контекст транзакции, чтобы репозитории могли проверить, что их вызвали внутри нее
+type recordingTx struct{}
+
+func (recordingTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txMarker{}, true))
+}
+
+func inTx(ctx context.Context) bool {
+	v, _ := ctx.Value(txMarker{}).(bool)
+	return v
+}
+
+type txChatRepo struct {
+	storage.ChatRepo
+	writes map[string]bool
+}
+
+func (r txChatRepo) AddMessage(ctx context.Context, msg domain.Message, chatId int) (domain.Message, error) {
+	r.writes["AddMessage"] = inTx(ctx)
+	return r.ChatRepo.AddMessage(ctx, msg, chatId)
+}
+
+func (r txChatRepo) UpdateChat(ctx context.Context, chat domain.Chat) (domain.Chat, error) {
+	r.writes["UpdateChat"] = inTx(ctx)
+	return r.ChatRepo.UpdateChat(ctx, chat)
+}
+
+func (r txChatRepo) DeleteChat(ctx context.Context, chatId int) error {
+	r.writes["DeleteChat"] = inTx(ctx)
+	return r.ChatRepo.DeleteChat(ctx, chatId)
+}
+
+type txWebhookRepo struct {
+	storage.WebhookRepo
+	enqueued map[domain.EventType]bool
+}
+
+func (r txWebhookRepo) EnqueueEvent(ctx context.Context, event domain.Event, payload []byte) (int, error) {
+	r.enqueued[event.Type] = inTx(ctx)
+	return r.WebhookRepo.EnqueueEvent(ctx, event, payload)
+}
+
+func TestEventsEnqueuedInWriteTransaction(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	chats := txChatRepo{ChatRepo: repo, writes: make(map[string]bool)}
+	webhooks := txWebhookRepo{WebhookRepo: memory.NewWebhookRepoMemory(repo), enqueued: make(map[domain.EventType]bool)}
+	service := New(chats, webhooks, memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), recordingTx{}, nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+
+	chat, err := service.Create(ctx, dto.ChatIn{Title: "outbox"}, "alice")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if _, err := service.AddMessage(ctx, chat.ID, Sender{UserId: "alice"}, dto.MessageIn{Text: "hi"}); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	title := "renamed"
+	if _, err := service.UpdateChat(ctx, chat.ID, "alice", dto.ChatPatchIn{Title: &title}, 0); err != nil {
+		t.Fatalf("update chat: %v", err)
+	}
+	if err := service.DeleteChat(ctx, chat.ID, "alice"); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+
+	for _, write := range []string{"AddMessage", "UpdateChat", "DeleteChat"} {
+		if inTx, ok := chats.writes[write]; !ok || !inTx {
+			t.Errorf("%s must run in transaction", write)
+		}
+	}
+	for _, event := range []domain.EventType{domain.EventMessageCreated, domain.EventChatUpdated, domain.EventChatDeleted} {
+		if inTx, ok := webhooks.enqueued[event]; !ok || !inTx {
+			t.Errorf("%s must be enqueued in transaction", event)
+		}
+	}
+}
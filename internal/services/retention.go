@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/metrics"
+	"chat-project/internal/storage"
+	"chat-project/internal/tracing"
+)
+
+var LegalHoldError = errors.New("chat is under legal hold")
+
+// Сколько чатов читается за один запрос при обходе
+const retentionChatPage = 100
+
+// RetentionService удаляет сообщения по сроку хранения чатов и управляет удержанием
+type RetentionService struct {
+	chatRepo  storage.ChatRepo
+	retention storage.RetentionRepo
+	chats     *ChatService
+	cfg       config.Retention
+	log       *slog.Logger
+	metrics   *metrics.Metrics
+	now       func() time.Time
+}
+
+func NewRetentionService(chatRepo storage.ChatRepo, retention storage.RetentionRepo, chats *ChatService, cfg config.Retention, log *slog.Logger, m *metrics.Metrics) *RetentionService {
+	return &RetentionService{
+		chatRepo:  chatRepo,
+		retention: retention,
+		chats:     chats,
+		cfg:       cfg,
+		log:       log,
+		metrics:   m,
+		now:       time.Now,
+	}
+}
+
+// Включить или снять удержание чата. Пока удержание включено, сообщения чата не удаляются
+func (s RetentionService) SetLegalHold(ctx context.Context, chatId int, hold bool) (*dto.ChatResponse, error) {
+	chat, err := s.retention.SetLegalHold(ctx, chatId, hold)
+	if err != nil {
+		return nil, fmt.Errorf("error while setting legal hold of chat with id %d: %w", chatId, err)
+	}
+	s.log.InfoContext(ctx, "legal hold changed", slog.Int("chat_id", chatId), slog.Bool("legal_hold", hold))
+	return newChatResponse(chat), nil
+}
+
+// Изменить срок хранения сообщений чата из admin CLI: 0 - срок по умолчанию сервера, -1 - хранить всегда
+func (s RetentionService) SetRetentionDays(ctx context.Context, chatId, days int) (*dto.ChatResponse, error) {
+	chat, err := s.chats.updateChat(ctx, chatId, dto.ChatPatchIn{Settings: &dto.ChatSettingsPatch{RetentionDays: &days}}, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.log.InfoContext(ctx, "retention changed", slog.Int("chat_id", chatId), slog.Int("retention_days", days))
+	return chat, nil
+}
+
+// Отчет без удаления: какие чаты и сколько сообщений затронет следующий запуск
+func (s RetentionService) Report(ctx context.Context) (resp *dto.RetentionReport, err error) {
+	ctx, span := s.chats.startSpan(ctx, "RetentionReport")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now()
+	resp = &dto.RetentionReport{DefaultDays: s.cfg.DefaultDays, Now: now.Format(time.RFC3339), Chats: make([]dto.RetentionReportItem, 0)}
+	err = s.eachChat(ctx, func(chat domain.Chat) error {
+		days := chat.Settings.Retention(s.cfg.DefaultDays)
+		if days == 0 && !chat.LegalHold {
+			return nil
+		}
+		item := dto.RetentionReportItem{ChatId: chat.ID, Title: chat.Title, RetentionDays: days, Source: "default", LegalHold: chat.LegalHold}
+		if chat.Settings.RetentionDays != 0 {
+			item.Source = "chat"
+		}
+		if days > 0 {
+			before := retentionCutoff(now, days)
+			item.Before = before.Format(time.RFC3339)
+			item.Expired, err = s.retention.CountMessagesBefore(ctx, chat.ID, before)
+			if err != nil {
+				return fmt.Errorf("error while counting expired messages of chat with id %d: %w", chat.ID, err)
+			}
+		}
+		if !chat.LegalHold {
+			resp.Expired += item.Expired
+		}
+		resp.Chats = append(resp.Chats, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Удалить сообщения с истекшим сроком хранения во всех чатах, кроме удерживаемых.
+// Подписчики чата получают message.deleted с id удаленных сообщений после каждой пачки
+func (s RetentionService) PurgeExpired(ctx context.Context) (purged int64, err error) {
+	ctx, span := s.chats.startSpan(ctx, "PurgeExpired")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now()
+	err = s.eachChat(ctx, func(chat domain.Chat) error {
+		days := chat.Settings.Retention(s.cfg.DefaultDays)
+		if days == 0 || chat.LegalHold {
+			return nil
+		}
+		n, err := s.purgeChat(ctx, chat.ID, retentionCutoff(now, days))
+		purged += n
+		return err
+	})
+	if purged > 0 {
+		s.log.InfoContext(ctx, "expired messages purged", slog.Int64("purged", purged))
+	}
+	return purged, err
+}
+
+// Удалить сообщения старше before из admin CLI. chatId 0 - во всех чатах, кроме удерживаемых.
+// Удаление идет пачками, как у PurgeExpired, и подписчики получают message.deleted
+func (s RetentionService) PurgeMessages(ctx context.Context, chatId int, before time.Time) (purged int64, err error) {
+	ctx, span := s.chats.startSpan(ctx, "PurgeMessages", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	if chatId != 0 {
+		chat, err := s.chatRepo.GetChatByID(ctx, chatId)
+		if err != nil {
+			return 0, fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+		}
+		if chat.LegalHold {
+			return 0, domain.Conflict(domain.EntityChat, chatId, LegalHoldError)
+		}
+		purged, err = s.purgeChat(ctx, chatId, before)
+	} else {
+		err = s.eachChat(ctx, func(chat domain.Chat) error {
+			if chat.LegalHold {
+				return nil
+			}
+			n, err := s.purgeChat(ctx, chat.ID, before)
+			purged += n
+			return err
+		})
+	}
+	s.log.InfoContext(ctx, "messages purged", slog.Int("chat_id", chatId), slog.Time("before", before), slog.Int64("purged", purged))
+	return purged, err
+}
+
+// purgeChat удаляет сообщения чата пачками, чтобы не держать долгие блокировки на messages
+func (s RetentionService) purgeChat(ctx context.Context, chatId int, before time.Time) (int64, error) {
+	ctx, span := s.chats.startSpan(ctx, "PurgeExpiredChat", attribute.Int("chat.id", chatId))
+	defer span.End()
+
+	batch := max(s.cfg.BatchSize, 1)
+	var purged int64
+	for {
+		var ids []int
+		events, err := s.chats.withEvents(ctx, func(ctx context.Context) ([]domain.Event, error) {
+			var err error
+			ids, err = s.retention.PurgeMessagesBatch(ctx, chatId, before, batch)
+			if err != nil || len(ids) == 0 {
+				return nil, err
+			}
+			return []domain.Event{domain.NewMessagesDeletedEvent(chatId, ids)}, nil
+		})
+		if err != nil {
+			return purged, fmt.Errorf("error while purging messages of chat with id %d: %w", chatId, err)
+		}
+		purged += int64(len(ids))
+		s.metrics.MessagesPurged(len(ids))
+		for _, event := range events {
+			// Сообщения уже удалены, поэтому ошибка рассылки события не прерывает удаление
+			s.chats.notify(ctx, event)
+		}
+		if len(ids) < batch {
+			return purged, nil
+		}
+
+		// Пауза между пачками оставляет место обычным запросам к messages
+		select {
+		case <-ctx.Done():
+			return purged, ctx.Err()
+		case <-time.After(s.cfg.BatchPause):
+		}
+	}
+}
+
+// eachChat обходит все чаты постранично
+func (s RetentionService) eachChat(ctx context.Context, fn func(chat domain.Chat) error) error {
+	for offset := 0; ; offset += retentionChatPage {
+		chats, err := s.chatRepo.ListAllChats(ctx, retentionChatPage, offset)
+		if err != nil {
+			return fmt.Errorf("error while listing chats: %w", err)
+		}
+		for _, chat := range chats {
+			if err := fn(chat); err != nil {
+				return err
+			}
+		}
+		if len(chats) < retentionChatPage {
+			return nil
+		}
+	}
+}
+
+func retentionCutoff(now time.Time, days int) time.Time {
+	return now.AddDate(0, 0, -days)
+}
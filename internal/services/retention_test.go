@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+func TestRetentionPurgesExpiredMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := memory.NewUserRepoMemory()
+	listener := memory.NewListenerMemory()
+	chats := New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), listener, memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+	retention := memory.NewRetentionRepoMemory(repo)
+	service := NewRetentionService(repo, retention, chats, config.Retention{DefaultDays: 90, BatchSize: 2}, logger.Discard(), nil)
+	service.now = func() time.Time { return now }
+
+	newChat := func(title string, retentionDays int, ages ...int) domain.Chat {
+		t.Helper()
+		chat, err := repo.CreateChat(ctx, domain.Chat{Title: title, Settings: domain.ChatSettings{RetentionDays: retentionDays}, CreatedAt: now}, "")
+		if err != nil {
+			t.Fatalf("create chat: %v", err)
+		}
+		for _, age := range ages {
+			msg := domain.Message{ChatId: chat.ID, Text: title, CreatedAt: now.AddDate(0, 0, -age)}
+			if _, err := repo.AddMessage(ctx, msg, chat.ID); err != nil {
+				t.Fatalf("add message: %v", err)
+			}
+		}
+		return chat
+	}
+	short := newChat("short", 30, 40, 35, 31, 1)
+	byDefault := newChat("default", 0, 100, 10)
+	forever := newChat("forever", domain.RetentionForever, 1000)
+	held := newChat("held", 1, 10)
+	if _, err := service.SetLegalHold(ctx, held.ID, true); err != nil {
+		t.Fatalf("set legal hold: %v", err)
+	}
+	events := listener.Subscribe(ctx, short.ID)
+
+	report, err := service.Report(ctx)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	expired := make(map[int]int64)
+	for _, item := range report.Chats {
+		expired[item.ChatId] = item.Expired
+	}
+	if report.Expired != 4 || len(report.Chats) != 3 || expired[short.ID] != 3 || expired[byDefault.ID] != 1 || expired[held.ID] != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, listed := expired[forever.ID]; listed {
+		t.Fatalf("chat kept forever must not be reported: %+v", report)
+	}
+
+	purged, err := service.PurgeExpired(ctx)
+	if err != nil || purged != 4 {
+		t.Fatalf("purge: %d, %v", purged, err)
+	}
+	left := map[int]int{short.ID: 1, byDefault.ID: 1, forever.ID: 1, held.ID: 1}
+	for chatId, want := range left {
+		chat, err := repo.GetWithMessages(ctx, chatId)
+		if err != nil || len(chat.Messages) != want {
+			t.Fatalf("chat %d has %d messages, want %d: %v", chatId, len(chat.Messages), want, err)
+		}
+	}
+
+	// Три сообщения удалены двумя пачками при BatchSize 2
+	var deleted []int
+	for _, want := range []int{2, 1} {
+		event := <-events
+		if event.Type != domain.EventMessageDeleted || len(event.MessageIds) != want {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+		deleted = append(deleted, event.MessageIds...)
+	}
+	if len(deleted) != 3 {
+		t.Fatalf("deleted ids: %v", deleted)
+	}
+
+	_, err = service.PurgeMessages(ctx, held.ID, now)
+	if !errors.Is(err, LegalHoldError) {
+		t.Fatalf("expected legal hold error, got %v", err)
+	}
+
+	// Ручная очистка из admin CLI тоже идет пачками и рассылает message.deleted
+	defaultEvents := listener.Subscribe(ctx, byDefault.ID)
+	purged, err = service.PurgeMessages(ctx, 0, now)
+	if err != nil || purged != 3 {
+		t.Fatalf("manual purge: %d, %v", purged, err)
+	}
+	if event := <-defaultEvents; event.Type != domain.EventMessageDeleted || len(event.MessageIds) != 1 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if chat, err := repo.GetWithMessages(ctx, held.ID); err != nil || len(chat.Messages) != 1 {
+		t.Fatalf("held chat must keep its messages: %+v, %v", chat, err)
+	}
+}
+
+func TestRetentionChangeRequiresManager(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	chats := New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+	service := NewRetentionService(repo, memory.NewRetentionRepoMemory(repo), chats, config.Retention{}, logger.Discard(), nil)
+
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "owned"}, "alice")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if err := repo.AddMember(ctx, domain.ChatMember{ChatId: chat.ID, UserId: "bob", Role: domain.ChatRoleMember}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	days := 7
+	patch := dto.ChatPatchIn{Settings: &dto.ChatSettingsPatch{RetentionDays: &days}}
+
+	for _, userId := range []string{"", "bob", "mallory"} {
+		if _, err := chats.UpdateChat(ctx, chat.ID, userId, patch, 0); !errors.Is(err, NotChatManagerError) {
+			t.Fatalf("user %q: expected NotChatManagerError, got %v", userId, err)
+		}
+	}
+	updated, err := chats.UpdateChat(ctx, chat.ID, "alice", patch, 0)
+	if err != nil || updated.Settings.RetentionDays != days {
+		t.Fatalf("owner update: %+v, %v", updated, err)
+	}
+
+	if _, err := chats.Create(ctx, dto.ChatIn{Title: "orphan", Settings: &dto.ChatSettings{RetentionDays: days}}, ""); !errors.Is(err, NotChatManagerError) {
+		t.Fatalf("expected NotChatManagerError for chat without owner, got %v", err)
+	}
+
+	// admin CLI меняет срок хранения без проверки участия
+	updated, err = service.SetRetentionDays(ctx, chat.ID, domain.RetentionForever)
+	if err != nil || updated.Settings.RetentionDays != domain.RetentionForever {
+		t.Fatalf("admin set retention: %+v, %v", updated, err)
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"chat-project/internal/commands"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/storage"
+	"chat-project/internal/tracing"
+)
+
+var (
+	InvalidSendAtError    = errors.New("SendAt must be in the future and at most 365 days ahead")
+	ScheduledCommandError = errors.New("slash commands cannot be scheduled")
+	ScheduledTTLError     = errors.New("scheduled messages cannot have a TTL")
+)
+
+// Насколько далеко вперед можно запланировать сообщение
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// Запланировать сообщение пользователя. Оно будет отправлено планировщиком в SendAt
+func (c ChatService) ScheduleMessage(ctx context.Context, chatId int, sender Sender, message dto.MessageIn) (resp *dto.ScheduledMessageResponse, err error) {
+	ctx, span := c.startSpan(ctx, "ScheduleMessage", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	if err = c.validator.Validate(domain.EntityMessage, &message); err != nil {
+		return nil, err
+	}
+	if err = c.CheckAccess(ctx, chatId, sender.UserId); err != nil {
+		return nil, err
+	}
+	if _, _, ok := commands.Parse(message.Text); ok && c.commands != nil {
+		return nil, domain.Validation(domain.EntityScheduled, ScheduledCommandError)
+	}
+	if message.TtlSeconds > 0 {
+		return nil, domain.Validation(domain.EntityScheduled, ScheduledTTLError)
+	}
+	if c.commands != nil && len(message.Text) > 1 && message.Text[:2] == "//" {
+		message.Text = message.Text[1:]
+	}
+
+	msg, err := c.schedule(ctx, domain.ScheduledMessage{
+		ChatId:    chatId,
+		Kind:      domain.MessageKindUser,
+		AuthorId:  sender.UserId,
+		CreatedBy: sender.UserId,
+		Text:      message.Text,
+		SendAt:    *message.SendAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newScheduledResponse(msg), nil
+}
+
+func (c ChatService) schedule(ctx context.Context, msg domain.ScheduledMessage) (domain.ScheduledMessage, error) {
+	if err := c.checkSendAt(msg.SendAt); err != nil {
+		return domain.ScheduledMessage{}, err
+	}
+	msg, err := c.scheduled.CreateScheduledMessage(ctx, msg)
+	if err != nil {
+		return domain.ScheduledMessage{}, fmt.Errorf("error while scheduling message in chat with id %d: %w", msg.ChatId, err)
+	}
+	c.log.InfoContext(ctx, "message scheduled", slog.Int("chat_id", msg.ChatId), slog.Int("scheduled_id", msg.ID), slog.Time("send_at", msg.SendAt))
+	return msg, nil
+}
+
+func (c ChatService) checkSendAt(sendAt time.Time) error {
+	now := c.now()
+	if !sendAt.After(now) || sendAt.After(now.Add(maxScheduleAhead)) {
+		return domain.Validation(domain.EntityScheduled, InvalidSendAtError)
+	}
+	return nil
+}
+
+// Запланированные сообщения пользователя в чате, включая напоминания, ближайшие первыми
+func (c ChatService) ListScheduledMessages(ctx context.Context, chatId int, userId string) (resp *dto.ScheduledMessagesResponse, err error) {
+	ctx, span := c.startSpan(ctx, "ListScheduledMessages", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err = c.chatRepo.GetChatByID(ctx, chatId); err != nil {
+		return nil, fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+	messages, err := c.scheduled.ListScheduledMessages(ctx, chatId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing scheduled messages of chat with id %d: %w", chatId, err)
+	}
+
+	resp = &dto.ScheduledMessagesResponse{Messages: make([]dto.ScheduledMessageResponse, 0, len(messages))}
+	for _, msg := range messages {
+		resp.Messages = append(resp.Messages, *newScheduledResponse(msg))
+	}
+	return resp, nil
+}
+
+// Изменить текст или время запланированного сообщения. Сообщение со статусом failed снова ставится в очередь
+func (c ChatService) UpdateScheduledMessage(ctx context.Context, chatId, id int, userId string, patch dto.ScheduledMessagePatchIn) (resp *dto.ScheduledMessageResponse, err error) {
+	ctx, span := c.startSpan(ctx, "UpdateScheduledMessage", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	if err = c.validator.Validate(domain.EntityScheduled, &patch); err != nil {
+		return nil, err
+	}
+	msg, err := c.scheduled.GetScheduledMessage(ctx, chatId, id, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting scheduled message %d: %w", id, err)
+	}
+	if patch.Text != nil {
+		if _, _, ok := commands.Parse(*patch.Text); ok && c.commands != nil && msg.Kind == domain.MessageKindUser {
+			return nil, domain.Validation(domain.EntityScheduled, ScheduledCommandError)
+		}
+		msg.Text = *patch.Text
+	}
+	if patch.SendAt != nil {
+		if err = c.checkSendAt(*patch.SendAt); err != nil {
+			return nil, err
+		}
+		msg.SendAt = *patch.SendAt
+	}
+
+	msg, err = c.scheduled.UpdateScheduledMessage(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("error while updating scheduled message %d: %w", id, err)
+	}
+	c.log.InfoContext(ctx, "scheduled message updated", slog.Int("chat_id", chatId), slog.Int("scheduled_id", id), slog.Time("send_at", msg.SendAt))
+	return newScheduledResponse(msg), nil
+}
+
+// Отменить запланированное сообщение
+func (c ChatService) CancelScheduledMessage(ctx context.Context, chatId, id int, userId string) (err error) {
+	ctx, span := c.startSpan(ctx, "CancelScheduledMessage", attribute.Int("chat.id", chatId))
+	defer func() { tracing.End(span, err) }()
+
+	if err = c.scheduled.DeleteScheduledMessage(ctx, chatId, id, userId); err != nil {
+		return fmt.Errorf("error while canceling scheduled message %d: %w", id, err)
+	}
+	c.log.InfoContext(ctx, "scheduled message canceled", slog.Int("chat_id", chatId), slog.Int("scheduled_id", id))
+	return nil
+}
+
+// SendScheduled отправляет наступившее сообщение обычным путем: сохранение, вебхуки, публикация.
+// ScheduledId не дает отправить сообщение дважды, если планировщик упал после сохранения.
+// Медленный режим и слэш-команды к запланированным сообщениям не применяются
+func (c ChatService) SendScheduled(ctx context.Context, scheduled domain.ScheduledMessage) (err error) {
+	ctx, span := c.startSpan(ctx, "SendScheduled", attribute.Int("chat.id", scheduled.ChatId), attribute.Int("scheduled.id", scheduled.ID))
+	defer func() { tracing.End(span, err) }()
+
+	message := dto.MessageIn{Text: scheduled.Text}
+	if err = c.validator.Validate(domain.EntityMessage, &message); err != nil {
+		return err
+	}
+	msg := domain.Message{
+		ChatId:      scheduled.ChatId,
+		Kind:        scheduled.Kind,
+		Text:        message.Text,
+		AuthorId:    scheduled.AuthorId,
+		ScheduledId: &scheduled.ID,
+	}
+	if ok, err := c.scheduledSent(ctx, scheduled); ok || err != nil {
+		return err
+	}
+	_, err = c.addMessage(ctx, msg, scheduled.CreatedBy)
+	if errors.Is(err, storage.ScheduledMessageSentError) {
+		// Сообщение успел сохранить другой инстанс после окончания lease
+		return nil
+	}
+	return err
+}
+
+// scheduledSent проверяет, сохранено ли уже сообщение из запланированного
+func (c ChatService) scheduledSent(ctx context.Context, scheduled domain.ScheduledMessage) (bool, error) {
+	_, err := c.chatRepo.GetMessageByScheduledID(ctx, scheduled.ChatId, scheduled.ID)
+	if domain.IsNotFound(err, domain.EntityMessage) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error while getting message of scheduled message %d: %w", scheduled.ID, err)
+	}
+	return true, nil
+}
+
+func newScheduledResponse(msg domain.ScheduledMessage) *dto.ScheduledMessageResponse {
+	return &dto.ScheduledMessageResponse{
+		ID:        msg.ID,
+		ChatId:    msg.ChatId,
+		Kind:      string(msg.Kind),
+		Text:      msg.Text,
+		AuthorId:  msg.AuthorId,
+		SendAt:    msg.SendAt.Format(time.RFC3339),
+		Status:    string(msg.Status),
+		Attempts:  msg.Attempts,
+		LastError: msg.LastError,
+		CreatedAt: msg.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/storage/memory"
+)
+
+func TestSendScheduledOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	chats := newCommandService(t, repo)
+
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "standup"}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	// Nonce пользователя не совпадает с ключом запланированного сообщения и не подавляет его
+	if _, err := chats.AddMessage(ctx, chat.ID, Sender{UserId: "bob"}, dto.MessageIn{Text: "hi", ClientNonce: "scheduled-42"}); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+
+	scheduled := domain.ScheduledMessage{ID: 42, ChatId: chat.ID, Kind: domain.MessageKindUser, AuthorId: "bob", CreatedBy: "bob", Text: "standup in 5 minutes"}
+	for range 2 {
+		if err := chats.SendScheduled(ctx, scheduled); err != nil {
+			t.Fatalf("send scheduled: %v", err)
+		}
+	}
+
+	got, err := chats.GetWithMessages(ctx, chat.ID, "bob")
+	if err != nil || len(got.Messages) != 2 || got.Messages[1].Text != scheduled.Text {
+		t.Fatalf("expected user message and one scheduled message, got %+v, %v", got, err)
+	}
+}
+
+func TestScheduleSendAt(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	chats := newCommandService(t, repo)
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	chats.now = func() time.Time { return now }
+
+	chat, err := chats.Create(ctx, dto.ChatIn{Title: "standup"}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	tests := []struct {
+		sendAt time.Time
+		valid  bool
+	}{
+		{now.Add(time.Minute), true},
+		{now.Add(maxScheduleAhead), true},
+		{now, false},
+		{now.Add(-time.Minute), false},
+		{now.Add(maxScheduleAhead + time.Second), false},
+	}
+	for _, tt := range tests {
+		_, err := chats.ScheduleMessage(ctx, chat.ID, Sender{UserId: "bob"}, dto.MessageIn{Text: "later", SendAt: &tt.sendAt})
+		if got := !errors.Is(err, InvalidSendAtError); got != tt.valid || (tt.valid && err != nil) {
+			t.Errorf("SendAt %s: got %v, want valid %v", tt.sendAt, err, tt.valid)
+		}
+	}
+}
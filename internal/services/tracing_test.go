@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+// setTestTracerProvider подменяет глобальные провайдер и пропагатор на время теста
+func setTestTracerProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	// Глобальные провайдер и пропагатор возвращаются после теста, чтобы не влиять на другие тесты
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		_ = provider.Shutdown(context.Background())
+	})
+	return provider, recorder
+}
+
+func TestDirectChatSpan(t *testing.T) {
+	_, recorder := setTestTracerProvider(t)
+	repo := memory.NewUserRepoMemory()
+	service := New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+
+	chat, _, err := service.GetOrCreateDirectChat(context.Background(), "alice", "bob")
+	if err != nil {
+		t.Fatalf("get or create direct chat: %v", err)
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() != "ChatService.GetOrCreateDirectChat" {
+			continue
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == "chat.id" && attr.Value.AsInt64() == int64(chat.ID) {
+				return
+			}
+		}
+		t.Fatalf("span has no chat.id: %+v", span.Attributes())
+	}
+	t.Fatal("no GetOrCreateDirectChat span")
+}
+
+func TestEventTraceLinksDeliveryToRequest(t *testing.T) {
+	provider, recorder := setTestTracerProvider(t)
+
+	repo := memory.NewUserRepoMemory()
+	chat, err := repo.CreateChat(context.Background(), domain.Chat{Title: "test", CreatedAt: time.Now()}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	listener := memory.NewListenerMemory()
+	manager := NewChatListenerManager(repo, listener, config.Listener{GracePeriod: time.Minute}, logger.Discard(), nil)
+	t.Cleanup(manager.Close)
+	service := New(repo, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), listener, memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+
+	ctx := context.Background()
+	chatListener, err := manager.Acquire(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer manager.Release(chatListener)
+	client := NewClientConn()
+	if err := chatListener.AddClient(ctx, client); err != nil {
+		t.Fatalf("add client: %v", err)
+	}
+	waitFor(t, func() bool { return listener.SubscribersCount(chat.ID) == 1 })
+
+	reqCtx, request := provider.Tracer("test").Start(ctx, "request")
+	if _, err := service.AddMessage(reqCtx, chat.ID, Sender{UserId: "alice"}, dto.MessageIn{Text: "hi"}); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	request.End()
+	traceID := request.SpanContext().TraceID()
+
+	event := <-client
+	if event.Trace["traceparent"] == "" {
+		t.Fatalf("event has no trace context: %+v", event.Trace)
+	}
+
+	var delivery sdktrace.ReadOnlySpan
+	waitFor(t, func() bool {
+		for _, span := range recorder.Ended() {
+			if span.Name() == "ChatListener.deliver" {
+				delivery = span
+				return true
+			}
+		}
+		return false
+	})
+	if delivery.SpanContext().TraceID() == traceID {
+		t.Fatal("delivery span should start a new trace")
+	}
+	links := delivery.Links()
+	if len(links) != 1 || links[0].SpanContext.TraceID() != traceID {
+		t.Fatalf("delivery span is not linked to request trace %s: %+v", traceID, links)
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/storage"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+// racingChatRepo перед первой записью меняет чат в обход сервиса, как параллельный запрос
+type racingChatRepo struct {
+	storage.ChatRepo
+	raced *bool
+}
+
+func (r racingChatRepo) UpdateChat(ctx context.Context, chat domain.Chat) (domain.Chat, error) {
+	if !*r.raced {
+		*r.raced = true
+		current, err := r.ChatRepo.GetChatByID(ctx, chat.ID)
+		if err != nil {
+			return domain.Chat{}, err
+		}
+		current.Description = "concurrent"
+		if _, err := r.ChatRepo.UpdateChat(ctx, current); err != nil {
+			return domain.Chat{}, err
+		}
+	}
+	return r.ChatRepo.UpdateChat(ctx, chat)
+}
+
+func TestUpdateChatConcurrentEdit(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepoMemory()
+	raced := false
+	chats := racingChatRepo{ChatRepo: repo, raced: &raced}
+	service := New(chats, memory.NewWebhookRepoMemory(repo), memory.NewScheduledMessageRepoMemory(repo), memory.NewListenerMemory(), memory.NewTxManagerMemory(), nil, validation.New(config.Validation{}), ratelimit.NewMemory(), logger.Discard(), nil)
+
+	chat, err := service.Create(ctx, dto.ChatIn{Title: "team"}, "alice")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if err := repo.AddMember(ctx, domain.ChatMember{ChatId: chat.ID, UserId: "bob", Role: domain.ChatRoleMember}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	title := "renamed"
+	patch := dto.ChatPatchIn{Title: &title}
+
+	for _, userId := range []string{"", "bob"} {
+		if _, err := service.UpdateChat(ctx, chat.ID, userId, patch, 0); !errors.Is(err, NotChatManagerError) {
+			t.Fatalf("user %q: expected NotChatManagerError, got %v", userId, err)
+		}
+	}
+
+	// без If-Match изменение применяется к новой версии, параллельное изменение не теряется
+	updated, err := service.UpdateChat(ctx, chat.ID, "alice", patch, 0)
+	if err != nil {
+		t.Fatalf("update without If-Match: %v", err)
+	}
+	if updated.Title != title || updated.Description != "concurrent" || updated.Version != chat.Version+2 {
+		t.Fatalf("unexpected chat: %+v", updated)
+	}
+
+	// с If-Match параллельное изменение возвращает конфликт версии
+	raced = false
+	if _, err := service.UpdateChat(ctx, chat.ID, "alice", patch, updated.Version); !errors.Is(err, storage.ChatVersionConflictError) {
+		t.Fatalf("expected ChatVersionConflictError, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+	"chat-project/internal/storage"
+	"chat-project/internal/validation"
+	"chat-project/internal/webhook"
+)
+
+var (
+	InvalidWebhookEventError   = errors.New("unknown webhook event type")
+	InvalidDeliveryStatusError = errors.New("delivery status must be pending, delivered or dead")
+)
+
+// Длина случайной части секрета вебхука в байтах
+const webhookSecretSize = 32
+
+type WebhookService struct {
+	chatRepo    storage.ChatRepo
+	webhookRepo storage.WebhookRepo
+	validator   *validation.Validator
+	cfg         config.Webhook
+	log         *slog.Logger
+}
+
+func NewWebhookService(chatRepo storage.ChatRepo, webhookRepo storage.WebhookRepo, validator *validation.Validator, cfg config.Webhook, log *slog.Logger) *WebhookService {
+	return &WebhookService{
+		chatRepo:    chatRepo,
+		webhookRepo: webhookRepo,
+		validator:   validator,
+		cfg:         cfg,
+		log:         log,
+	}
+}
+
+// Создать вебхук чата. Секрет подписи возвращается только в этом ответе
+func (s WebhookService) CreateWebhook(ctx context.Context, chatId int, userId string, in dto.WebhookIn) (*dto.WebhookCreatedResponse, error) {
+	if err := s.validator.Validate(domain.EntityWebhook, &in); err != nil {
+		return nil, err
+	}
+	events := make([]domain.EventType, 0, len(in.Events))
+	for _, e := range in.Events {
+		eventType := domain.EventType(e)
+		if !slices.Contains(domain.WebhookEvents, eventType) {
+			return nil, domain.Validation(domain.EntityWebhook, fmt.Errorf("%w %q", InvalidWebhookEventError, e))
+		}
+		if !slices.Contains(events, eventType) {
+			events = append(events, eventType)
+		}
+	}
+
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return nil, err
+	}
+	if !s.cfg.AllowPrivateAddresses {
+		if err := webhook.CheckURL(ctx, net.DefaultResolver, in.URL); err != nil {
+			return nil, domain.Validation(domain.EntityWebhook, err)
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("error while generating webhook secret: %w", err)
+	}
+
+	webhook, err := s.webhookRepo.CreateWebhook(ctx, domain.Webhook{
+		ChatId:    chatId,
+		URL:       in.URL,
+		Secret:    secret,
+		Events:    events,
+		CreatedBy: userId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while creating webhook for chat with id %d: %w", chatId, err)
+	}
+	s.log.InfoContext(ctx, "webhook created", slog.Int("chat_id", chatId), slog.Int("webhook_id", webhook.ID))
+
+	return &dto.WebhookCreatedResponse{
+		WebhookResponse: newWebhookResponse(webhook),
+		Secret:          secret,
+	}, nil
+}
+
+// Список вебхуков чата
+func (s WebhookService) ListWebhooks(ctx context.Context, chatId int, userId string) (*dto.WebhooksResponse, error) {
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.webhookRepo.ListWebhooks(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing webhooks of chat with id %d: %w", chatId, err)
+	}
+
+	resp := &dto.WebhooksResponse{Webhooks: make([]dto.WebhookResponse, 0, len(webhooks))}
+	for _, webhook := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, newWebhookResponse(webhook))
+	}
+	return resp, nil
+}
+
+// Удалить вебхук. Еще не доставленные события отменяются
+func (s WebhookService) DeleteWebhook(ctx context.Context, chatId, webhookId int, userId string) error {
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.DeleteWebhook(ctx, chatId, webhookId); err != nil {
+		return fmt.Errorf("error while deleting webhook %d: %w", webhookId, err)
+	}
+	s.log.InfoContext(ctx, "webhook deleted", slog.Int("chat_id", chatId), slog.Int("webhook_id", webhookId))
+	return nil
+}
+
+// Журнал доставок вебхука, новые первыми. status пустой - все статусы
+func (s WebhookService) ListDeliveries(ctx context.Context, chatId, webhookId int, userId, status string, limit, offset int) (*dto.WebhookDeliveriesResponse, error) {
+	deliveryStatus := domain.DeliveryStatus(status)
+	switch deliveryStatus {
+	case "", domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryDead:
+	default:
+		return nil, domain.Validation(domain.EntityDelivery, InvalidDeliveryStatusError)
+	}
+
+	if err := s.requireWebhook(ctx, chatId, webhookId, userId); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhookRepo.ListDeliveries(ctx, chatId, webhookId, deliveryStatus, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing deliveries of webhook %d: %w", webhookId, err)
+	}
+
+	resp := &dto.WebhookDeliveriesResponse{Deliveries: make([]dto.WebhookDeliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, newDeliveryResponse(d))
+	}
+	return resp, nil
+}
+
+// Вернуть в очередь доставку, попытки которой исчерпаны
+func (s WebhookService) RetryDelivery(ctx context.Context, chatId, webhookId, deliveryId int, userId string) (*dto.WebhookDeliveryResponse, error) {
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookRepo.RetryDelivery(ctx, chatId, webhookId, deliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("error while retrying delivery %d: %w", deliveryId, err)
+	}
+	s.log.InfoContext(ctx, "webhook delivery retried", slog.Int("webhook_id", webhookId), slog.Int("delivery_id", deliveryId))
+
+	resp := newDeliveryResponse(delivery)
+	return &resp, nil
+}
+
+func (s WebhookService) requireManager(ctx context.Context, chatId int, userId string) error {
+	if _, err := s.chatRepo.GetChatByID(ctx, chatId); err != nil {
+		return fmt.Errorf("error while getting chat with id %d: %w", chatId, err)
+	}
+	return checkManager(ctx, s.chatRepo, chatId, userId)
+}
+
+// requireWebhook проверяет права и то, что вебхук принадлежит чату
+func (s WebhookService) requireWebhook(ctx context.Context, chatId, webhookId int, userId string) error {
+	if err := s.requireManager(ctx, chatId, userId); err != nil {
+		return err
+	}
+	webhooks, err := s.webhookRepo.ListWebhooks(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("error while listing webhooks of chat with id %d: %w", chatId, err)
+	}
+	for _, webhook := range webhooks {
+		if webhook.ID == webhookId {
+			return nil
+		}
+	}
+	return domain.NotFound(domain.EntityWebhook, webhookId)
+}
+
+// enqueueWebhooks ставит событие в очередь вебхуков чата. Контекст трассировки в тело не попадает
+func enqueueWebhooks(ctx context.Context, repo storage.WebhookRepo, event domain.Event) error {
+	event.Trace = nil
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error while encoding %s event: %w", event.Type, err)
+	}
+	if _, err := repo.EnqueueEvent(ctx, event, payload); err != nil {
+		return fmt.Errorf("error while enqueuing webhooks for chat with id %d: %w", event.ChatId, err)
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, webhookSecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func newWebhookResponse(webhook domain.Webhook) dto.WebhookResponse {
+	events := make([]string, 0, len(webhook.Events))
+	for _, e := range webhook.Events {
+		events = append(events, string(e))
+	}
+	return dto.WebhookResponse{
+		ID:        webhook.ID,
+		ChatId:    webhook.ChatId,
+		URL:       webhook.URL,
+		Events:    events,
+		CreatedBy: webhook.CreatedBy,
+		CreatedAt: webhook.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func newDeliveryResponse(d domain.WebhookDelivery) dto.WebhookDeliveryResponse {
+	resp := dto.WebhookDeliveryResponse{
+		ID:             d.ID,
+		WebhookId:      d.WebhookId,
+		Event:          string(d.EventType),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.Status == domain.DeliveryPending {
+		resp.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	if d.DeliveredAt != nil {
+		resp.DeliveredAt = d.DeliveredAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -4,19 +4,138 @@ import (
 	"chat-project/internal/domain"
 	"context"
 	"errors"
+	"time"
 )
 
-var ChatNotFoundError = errors.New("chat not found")
+// Причины доменных ошибок хранилища. Отсутствие сущности возвращается как domain.NotFound,
+// конфликты как domain.Conflict с одной из этих причин
+var ChatVersionConflictError = errors.New("chat version conflict")
+
+var InviteUnavailableError = errors.New("invite is expired, revoked or used up")
+
+var MessageNonceConflictError = errors.New("message with this client nonce already exists")
+
+var DeliveryNotDeadError = errors.New("only dead deliveries can be retried")
+
+var ScheduledMessageSendingError = errors.New("scheduled message is being sent")
+
+var ScheduledMessageSentError = errors.New("scheduled message has already been sent")
+
+// TxManager выполняет fn в одной транзакции: репозитории, вызванные с переданным в fn контекстом, пишут в нее.
+// Ошибка fn откатывает транзакцию. Вложенный вызов присоединяется к внешней транзакции
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
 
 type ChatRepo interface {
-	CreateChat(ctx context.Context, chat domain.Chat) (domain.Chat, error)
+	// CreateChat сохраняет чат и, если ownerId не пустой, его владельца в одной транзакции
+	CreateChat(ctx context.Context, chat domain.Chat, ownerId string) (domain.Chat, error)
 	GetChatByID(ctx context.Context, chatId int) (domain.Chat, error)
+	// AddMessage возвращает domain.Conflict с MessageNonceConflictError, если у автора в чате
+	// уже есть сообщение с тем же непустым ClientNonce и тем же InvokedBy, и с ScheduledMessageSentError,
+	// если запланированное сообщение ScheduledId уже отправлено
 	AddMessage(ctx context.Context, msg domain.Message, chatId int) (domain.Message, error)
+	GetMessageByNonce(ctx context.Context, chatId int, authorId, invokedBy, nonce string) (domain.Message, error)
+	GetMessageByScheduledID(ctx context.Context, chatId, scheduledId int) (domain.Message, error)
+	// GetWithMessages и GetMessagesAfter не возвращают сообщения с истекшим ExpiresAt, даже если они еще не удалены.
+	// Текущее время берется из часов репозитория, чтобы тесты могли их подменить
 	GetWithMessages(ctx context.Context, chatId int) (domain.Chat, error)
+	// GetMessagesAfter возвращает до limit сообщений, добавленных после сообщения afterId, в порядке добавления
+	GetMessagesAfter(ctx context.Context, chatId, afterId, limit int) ([]domain.Message, error)
+	// UpdateChat сохраняет чат, если его текущая версия равна chat.Version, и увеличивает версию
+	UpdateChat(ctx context.Context, chat domain.Chat) (domain.Chat, error)
 	DeleteChat(ctx context.Context, chatId int) error
+	// ListChats возвращает только публичные групповые чаты
+	ListChats(ctx context.Context, limit, offset int) ([]domain.Chat, error)
+	// ListAllChats возвращает все чаты, включая приватные и личные
+	ListAllChats(ctx context.Context, limit, offset int) ([]domain.Chat, error)
+	// GetOrCreateDirectChat возвращает личный чат пары пользователей и признак того, что он был создан
+	GetOrCreateDirectChat(ctx context.Context, userA, userB string) (domain.Chat, bool, error)
+	// AddMember добавляет участника, если его еще нет в чате
+	AddMember(ctx context.Context, member domain.ChatMember) error
+	GetMember(ctx context.Context, chatId int, userId string) (domain.ChatMember, error)
+	// HasManager сообщает, есть ли у чата владелец или администратор
+	HasManager(ctx context.Context, chatId int) (bool, error)
+}
+
+type InviteRepo interface {
+	CreateInvite(ctx context.Context, invite domain.Invite) (domain.Invite, error)
+	ListInvites(ctx context.Context, chatId int) ([]domain.Invite, error)
+	RevokeInvite(ctx context.Context, chatId, inviteId int) error
+	// AcceptInvite атомарно расходует одно использование приглашения и добавляет пользователя в чат.
+	// Если пользователь уже участник, использование не расходуется и возвращается false.
+	AcceptInvite(ctx context.Context, tokenHash []byte, userId string) (domain.ChatMember, bool, error)
+}
+
+// WebhookRepo хранит вебхуки чатов и очередь их доставок
+type WebhookRepo interface {
+	CreateWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error)
+	ListWebhooks(ctx context.Context, chatId int) ([]domain.Webhook, error)
+	// DeleteWebhook удаляет вебхук вместе с его еще не доставленными событиями
+	DeleteWebhook(ctx context.Context, chatId, webhookId int) error
+	// EnqueueEvent ставит payload в очередь всем вебхукам чата, подписанным на тип события, и возвращает число доставок
+	EnqueueEvent(ctx context.Context, event domain.Event, payload []byte) (int, error)
+	// ClaimDeliveries забирает до limit доставок, время попытки которых наступило, и откладывает их на lease,
+	// чтобы другие обработчики их не взяли. Доставки, не обновленные за lease, возвращаются в очередь
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+	// UpdateDelivery сохраняет результат попытки: статус, число попыток, время следующей и ответ получателя
+	UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+	// ListDeliveries возвращает журнал доставок вебхука, новые первыми. status "" - все статусы
+	ListDeliveries(ctx context.Context, chatId, webhookId int, status domain.DeliveryStatus, limit, offset int) ([]domain.WebhookDelivery, error)
+	// RetryDelivery возвращает доставку из статуса dead в очередь. Для других статусов domain.Conflict с DeliveryNotDeadError
+	RetryDelivery(ctx context.Context, chatId, webhookId, deliveryId int) (domain.WebhookDelivery, error)
+	// PurgeDeliveries удаляет завершенные доставки, созданные раньше before
+	PurgeDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IncomingWebhookRepo хранит входящие вебхуки чатов
+type IncomingWebhookRepo interface {
+	CreateIncomingWebhook(ctx context.Context, webhook domain.IncomingWebhook) (domain.IncomingWebhook, error)
+	ListIncomingWebhooks(ctx context.Context, chatId int) ([]domain.IncomingWebhook, error)
+	DeleteIncomingWebhook(ctx context.Context, chatId, webhookId int) error
+	// GetIncomingWebhookByToken возвращает domain.NotFound без идентификатора, если хэша токена нет
+	GetIncomingWebhookByToken(ctx context.Context, tokenHash []byte) (domain.IncomingWebhook, error)
+}
+
+// ScheduledMessageRepo хранит запланированные сообщения до отправки. Сообщения автора ищутся по CreatedBy
+type ScheduledMessageRepo interface {
+	CreateScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) (domain.ScheduledMessage, error)
+	GetScheduledMessage(ctx context.Context, chatId, id int, createdBy string) (domain.ScheduledMessage, error)
+	// ListScheduledMessages возвращает сообщения автора в чате по возрастанию SendAt
+	ListScheduledMessages(ctx context.Context, chatId int, createdBy string) ([]domain.ScheduledMessage, error)
+	// UpdateScheduledMessage меняет текст и время и возвращает сообщение в очередь со сброшенными попытками.
+	// Сообщение, которое сейчас отправляется, не меняется: domain.Conflict с ScheduledMessageSendingError
+	UpdateScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) (domain.ScheduledMessage, error)
+	// DeleteScheduledMessage отменяет сообщение. Отправляемое сообщение не удаляется, как и в UpdateScheduledMessage
+	DeleteScheduledMessage(ctx context.Context, chatId, id int, createdBy string) error
+	// ClaimScheduledMessages выдает наступившие сообщения и блокирует их на lease. Несколько инстансов
+	// не получат одно сообщение, а после падения инстанса сообщение снова выдается по окончании lease
+	ClaimScheduledMessages(ctx context.Context, limit int, lease time.Duration) ([]domain.ScheduledMessage, error)
+	// CompleteScheduledMessage удаляет отправленное сообщение
+	CompleteScheduledMessage(ctx context.Context, id int) error
+	// FailScheduledMessage сохраняет Status, Attempts, NextAttemptAt и LastError неудачной попытки и снимает блокировку
+	FailScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) error
+}
+
+// RetentionRepo удаляет сообщения по сроку хранения и управляет удержанием чатов
+type RetentionRepo interface {
+	// SetLegalHold включает или снимает удержание чата и возвращает чат
+	SetLegalHold(ctx context.Context, chatId int, hold bool) (domain.Chat, error)
+	// CountMessagesBefore считает сообщения чата, созданные раньше before, независимо от удержания
+	CountMessagesBefore(ctx context.Context, chatId int, before time.Time) (int64, error)
+	// PurgeMessagesBatch удаляет до limit самых старых сообщений чата, созданных раньше before, и возвращает их id.
+	// Каждая пачка удаляется короткой транзакцией. Чат с LegalHold не изменяется
+	PurgeMessagesBatch(ctx context.Context, chatId int, before time.Time, limit int) ([]int, error)
+}
+
+// ExpiredMessageRepo удаляет самоуничтожающиеся сообщения, TTL которых истек
+type ExpiredMessageRepo interface {
+	// DeleteExpiredMessages удаляет до limit сообщений с ExpiresAt не позже now и возвращает их id и чаты.
+	// Сообщения чатов с LegalHold не удаляются. Параллельные вызовы не возвращают одно сообщение дважды
+	DeleteExpiredMessages(ctx context.Context, now time.Time, limit int) ([]domain.Message, error)
 }
 
 type ChatListener interface {
-	Subscribe(ctx context.Context, chatId int) <-chan domain.Message
-	Publish(ctx context.Context, chatId int, message domain.Message) error
+	Subscribe(ctx context.Context, chatId int) <-chan domain.Event
+	Publish(ctx context.Context, chatId int, event domain.Event) error
 }
@@ -4,59 +4,331 @@ import (
 	"chat-project/internal/domain"
 	"chat-project/internal/storage"
 	"context"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type ChatRepoMemory struct {
-	chats map[int]domain.Chat
+	mu         sync.RWMutex
+	chats      map[int]domain.Chat
+	directKeys map[string]int
+	members    map[int][]domain.ChatMember
+	// ID последнего сообщения. ID растут, как serial в postgres, на этом построен GetMessagesAfter
+	lastMessageId int
+	now           func() time.Time
 }
 
 func NewUserRepoMemory() *ChatRepoMemory {
 	return &ChatRepoMemory{
-		chats: make(map[int]domain.Chat),
+		chats:      make(map[int]domain.Chat),
+		directKeys: make(map[string]int),
+		members:    make(map[int][]domain.ChatMember),
+		now:        time.Now,
 	}
 }
 
-func (r *ChatRepoMemory) CreateChat(ctx context.Context, chat domain.Chat) (domain.Chat, error) {
+// SetClock подменяет часы, по которым скрываются сообщения с истекшим TTL
+func (r *ChatRepoMemory) SetClock(now func() time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = now
+}
+
+// visibleMessages возвращает сообщения без истекших, не меняя исходный срез
+func visibleMessages(messages []domain.Message, now time.Time) []domain.Message {
+	visible := make([]domain.Message, 0, len(messages))
+	for _, msg := range messages {
+		if !msg.Expired(now) {
+			visible = append(visible, msg)
+		}
+	}
+	return visible
+}
+
+func (r *ChatRepoMemory) CreateChat(ctx context.Context, chat domain.Chat, ownerId string) (domain.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	chat.ID = int(uuid.New().ID())
+	if chat.Type == "" {
+		chat.Type = domain.ChatTypeGroup
+	}
+	chat.Version = 1
 	r.chats[chat.ID] = chat
+	if ownerId != "" {
+		r.addMember(domain.ChatMember{ChatId: chat.ID, UserId: ownerId, Role: domain.ChatRoleOwner, JoinedAt: chat.CreatedAt})
+	}
 	return chat, nil
 }
 
 func (r *ChatRepoMemory) GetChatByID(ctx context.Context, chatId int) (domain.Chat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	chat, exists := r.chats[chatId]
 	if !exists {
-		return domain.Chat{}, storage.ChatNotFoundError
+		return domain.Chat{}, domain.NotFound(domain.EntityChat, chatId)
 	}
 	return chat, nil
 }
 
 func (r *ChatRepoMemory) AddMessage(ctx context.Context, message domain.Message, chatId int) (domain.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	chat, exists := r.chats[chatId]
 	if !exists {
-		return domain.Message{}, storage.ChatNotFoundError
+		return domain.Message{}, domain.NotFound(domain.EntityChat, chatId)
 	}
 
-	message.ID = int(uuid.New().ID())
+	if message.ClientNonce != "" {
+		if _, found := findByNonce(chat.Messages, message.AuthorId, message.InvokedBy, message.ClientNonce); found {
+			return domain.Message{}, domain.Conflict(domain.EntityMessage, message.ClientNonce, storage.MessageNonceConflictError)
+		}
+	}
+	if message.ScheduledId != nil {
+		if _, found := findByScheduledID(chat.Messages, *message.ScheduledId); found {
+			return domain.Message{}, domain.Conflict(domain.EntityMessage, *message.ScheduledId, storage.ScheduledMessageSentError)
+		}
+	}
+
+	r.lastMessageId++
+	message.ID = r.lastMessageId
+	if message.Kind == "" {
+		message.Kind = domain.MessageKindUser
+	}
 	chat.Messages = append(chat.Messages, message)
 	r.chats[chatId] = chat
 	return message, nil
 }
 
+func (r *ChatRepoMemory) GetMessageByNonce(ctx context.Context, chatId int, authorId, invokedBy, nonce string) (domain.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chat := r.chats[chatId]
+	if msg, found := findByNonce(chat.Messages, authorId, invokedBy, nonce); found && nonce != "" {
+		return msg, nil
+	}
+	return domain.Message{}, domain.NotFound(domain.EntityMessage, nonce)
+}
+
+func findByNonce(messages []domain.Message, authorId, invokedBy, nonce string) (domain.Message, bool) {
+	for _, msg := range messages {
+		if msg.AuthorId == authorId && msg.InvokedBy == invokedBy && msg.ClientNonce == nonce {
+			return msg, true
+		}
+	}
+	return domain.Message{}, false
+}
+
+func (r *ChatRepoMemory) GetMessageByScheduledID(ctx context.Context, chatId, scheduledId int) (domain.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if msg, found := findByScheduledID(r.chats[chatId].Messages, scheduledId); found {
+		return msg, nil
+	}
+	return domain.Message{}, domain.NotFound(domain.EntityMessage, scheduledId)
+}
+
+func findByScheduledID(messages []domain.Message, scheduledId int) (domain.Message, bool) {
+	for _, msg := range messages {
+		if msg.ScheduledId != nil && *msg.ScheduledId == scheduledId {
+			return msg, true
+		}
+	}
+	return domain.Message{}, false
+}
+
 func (r *ChatRepoMemory) GetWithMessages(ctx context.Context, chatId int) (domain.Chat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	chat, exists := r.chats[chatId]
 	if !exists {
-		return domain.Chat{}, storage.ChatNotFoundError
+		return domain.Chat{}, domain.NotFound(domain.EntityChat, chatId)
 	}
+	chat.Messages = visibleMessages(chat.Messages, r.now())
 	return chat, nil
 }
 
+// GetMessagesAfter ищет afterId по порядку добавления, так как id сообщений в памяти случайные
+func (r *ChatRepoMemory) GetMessagesAfter(ctx context.Context, chatId, afterId, limit int) ([]domain.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chat, exists := r.chats[chatId]
+	if !exists {
+		return nil, domain.NotFound(domain.EntityChat, chatId)
+	}
+
+	// Сообщения afterId может уже не быть, например после удаления по TTL, поэтому сравниваются ID
+	messages := make([]domain.Message, 0)
+	for _, msg := range visibleMessages(chat.Messages, r.now()) {
+		if len(messages) == limit {
+			break
+		}
+		if msg.ID > afterId {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+func (r *ChatRepoMemory) UpdateChat(ctx context.Context, chat domain.Chat) (domain.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, exists := r.chats[chat.ID]
+	if !exists {
+		return domain.Chat{}, domain.NotFound(domain.EntityChat, chat.ID)
+	}
+	if current.Version != chat.Version {
+		return domain.Chat{}, domain.Conflict(domain.EntityChat, chat.ID, storage.ChatVersionConflictError)
+	}
+
+	current.Title = chat.Title
+	current.Private = chat.Private
+	current.Description = chat.Description
+	current.AvatarURL = chat.AvatarURL
+	current.Settings = chat.Settings
+	current.Version++
+	r.chats[chat.ID] = current
+
+	current.Messages = nil
+	return current, nil
+}
+
+func (r *ChatRepoMemory) ListChats(ctx context.Context, limit, offset int) ([]domain.Chat, error) {
+	return r.listChats(limit, offset, func(chat domain.Chat) bool {
+		return chat.Type == domain.ChatTypeGroup && !chat.Private
+	})
+}
+
+func (r *ChatRepoMemory) ListAllChats(ctx context.Context, limit, offset int) ([]domain.Chat, error) {
+	return r.listChats(limit, offset, func(domain.Chat) bool { return true })
+}
+
+func (r *ChatRepoMemory) listChats(limit, offset int, keep func(domain.Chat) bool) ([]domain.Chat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chats := make([]domain.Chat, 0, len(r.chats))
+	for _, chat := range r.chats {
+		if !keep(chat) {
+			continue
+		}
+		chat.Messages = nil
+		chats = append(chats, chat)
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i].ID > chats[j].ID })
+
+	if offset >= len(chats) {
+		return []domain.Chat{}, nil
+	}
+	chats = chats[offset:]
+	if limit < len(chats) {
+		chats = chats[:limit]
+	}
+	return chats, nil
+}
+
+func (r *ChatRepoMemory) GetOrCreateDirectChat(ctx context.Context, userA, userB string) (domain.Chat, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := domain.DirectChatKey(userA, userB)
+	if chatId, exists := r.directKeys[key]; exists {
+		chat := r.chats[chatId]
+		chat.Messages = nil
+		return chat, false, nil
+	}
+
+	now := r.now()
+	chat := domain.Chat{
+		ID:        int(uuid.New().ID()),
+		Type:      domain.ChatTypeDirect,
+		Version:   1,
+		CreatedAt: now,
+	}
+	r.chats[chat.ID] = chat
+	r.directKeys[key] = chat.ID
+	r.members[chat.ID] = []domain.ChatMember{
+		{ChatId: chat.ID, UserId: userA, Role: domain.ChatRoleMember, JoinedAt: now},
+		{ChatId: chat.ID, UserId: userB, Role: domain.ChatRoleMember, JoinedAt: now},
+	}
+	return chat, true, nil
+}
+
+func (r *ChatRepoMemory) AddMember(ctx context.Context, member domain.ChatMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.chats[member.ChatId]; !exists {
+		return domain.NotFound(domain.EntityChat, member.ChatId)
+	}
+	r.addMember(member)
+	return nil
+}
+
+// addMember must be called with r.mu held
+func (r *ChatRepoMemory) addMember(member domain.ChatMember) (domain.ChatMember, bool) {
+	for _, existing := range r.members[member.ChatId] {
+		if existing.UserId == member.UserId {
+			return existing, false
+		}
+	}
+	if member.JoinedAt.IsZero() {
+		member.JoinedAt = r.now()
+	}
+	r.members[member.ChatId] = append(r.members[member.ChatId], member)
+	return member, true
+}
+
+func (r *ChatRepoMemory) GetMember(ctx context.Context, chatId int, userId string) (domain.ChatMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, member := range r.members[chatId] {
+		if member.UserId == userId {
+			return member, nil
+		}
+	}
+	return domain.ChatMember{}, domain.NotFound(domain.EntityMember, userId)
+}
+
+func (r *ChatRepoMemory) HasManager(ctx context.Context, chatId int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, member := range r.members[chatId] {
+		if member.CanManage() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (r *ChatRepoMemory) DeleteChat(ctx context.Context, chatId int) error {
-	_, exists := r.chats[chatId]
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chat, exists := r.chats[chatId]
 	if !exists {
-		return storage.ChatNotFoundError
+		return domain.NotFound(domain.EntityChat, chatId)
+	}
+	if chat.Type == domain.ChatTypeDirect {
+		for key, id := range r.directKeys {
+			if id == chatId {
+				delete(r.directKeys, key)
+			}
+		}
 	}
 	delete(r.chats, chatId)
+	delete(r.members, chatId)
 	return nil
 }
@@ -0,0 +1,53 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"chat-project/internal/storage"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/storage/storagetest"
+)
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (storage.ChatRepo, storage.InviteRepo) {
+		chats := memory.NewUserRepoMemory()
+		return chats, memory.NewInviteRepoMemory(chats)
+	})
+}
+
+func TestWebhookContract(t *testing.T) {
+	storagetest.RunWebhooks(t, func(t *testing.T) (storage.ChatRepo, storage.WebhookRepo) {
+		chats := memory.NewUserRepoMemory()
+		return chats, memory.NewWebhookRepoMemory(chats)
+	})
+}
+
+func TestIncomingWebhookContract(t *testing.T) {
+	storagetest.RunIncomingWebhooks(t, func(t *testing.T) (storage.ChatRepo, storage.IncomingWebhookRepo) {
+		chats := memory.NewUserRepoMemory()
+		return chats, memory.NewIncomingWebhookRepoMemory(chats)
+	})
+}
+
+func TestScheduledContract(t *testing.T) {
+	storagetest.RunScheduled(t, func(t *testing.T) (storage.ChatRepo, storage.ScheduledMessageRepo) {
+		chats := memory.NewUserRepoMemory()
+		return chats, memory.NewScheduledMessageRepoMemory(chats)
+	})
+}
+
+func TestRetentionContract(t *testing.T) {
+	storagetest.RunRetention(t, func(t *testing.T) (storage.ChatRepo, storage.RetentionRepo) {
+		chats := memory.NewUserRepoMemory()
+		return chats, memory.NewRetentionRepoMemory(chats)
+	})
+}
+
+func TestExpiryContract(t *testing.T) {
+	storagetest.RunExpiry(t, func(t *testing.T, now func() time.Time) (storage.ChatRepo, storage.RetentionRepo, storage.ExpiredMessageRepo) {
+		chats := memory.NewUserRepoMemory()
+		chats.SetClock(now)
+		return chats, memory.NewRetentionRepoMemory(chats), memory.NewExpiredMessageRepoMemory(chats)
+	})
+}
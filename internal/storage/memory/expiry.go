@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"chat-project/internal/domain"
+	"context"
+	"sort"
+	"time"
+)
+
+// ExpiredMessageRepoMemory удаляет сообщения прямо из ChatRepoMemory
+type ExpiredMessageRepoMemory struct {
+	chats *ChatRepoMemory
+}
+
+func NewExpiredMessageRepoMemory(chats *ChatRepoMemory) *ExpiredMessageRepoMemory {
+	return &ExpiredMessageRepoMemory{chats: chats}
+}
+
+func (r *ExpiredMessageRepoMemory) DeleteExpiredMessages(ctx context.Context, now time.Time, limit int) ([]domain.Message, error) {
+	r.chats.mu.Lock()
+	defer r.chats.mu.Unlock()
+
+	expired := make([]domain.Message, 0)
+	for _, chat := range r.chats.chats {
+		if chat.LegalHold {
+			continue
+		}
+		for _, msg := range chat.Messages {
+			if msg.Expired(now) {
+				expired = append(expired, msg)
+			}
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		if expired[i].ExpiresAt.Equal(*expired[j].ExpiresAt) {
+			return expired[i].ID < expired[j].ID
+		}
+		return expired[i].ExpiresAt.Before(*expired[j].ExpiresAt)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	deleted := make(map[int]bool, len(expired))
+	for _, msg := range expired {
+		deleted[msg.ID] = true
+	}
+	for chatId, chat := range r.chats.chats {
+		kept := make([]domain.Message, 0, len(chat.Messages))
+		for _, msg := range chat.Messages {
+			if !deleted[msg.ID] {
+				kept = append(kept, msg)
+			}
+		}
+		if len(kept) != len(chat.Messages) {
+			chat.Messages = kept
+			r.chats.chats[chatId] = chat
+		}
+	}
+	return expired, nil
+}
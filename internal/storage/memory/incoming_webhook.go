@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"bytes"
+	"chat-project/internal/domain"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type IncomingWebhookRepoMemory struct {
+	mu       sync.Mutex
+	chats    *ChatRepoMemory
+	webhooks map[int]domain.IncomingWebhook
+	lastId   int
+}
+
+func NewIncomingWebhookRepoMemory(chats *ChatRepoMemory) *IncomingWebhookRepoMemory {
+	return &IncomingWebhookRepoMemory{
+		chats:    chats,
+		webhooks: make(map[int]domain.IncomingWebhook),
+	}
+}
+
+func (r *IncomingWebhookRepoMemory) CreateIncomingWebhook(ctx context.Context, webhook domain.IncomingWebhook) (domain.IncomingWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.chats.GetChatByID(ctx, webhook.ChatId); err != nil {
+		return domain.IncomingWebhook{}, err
+	}
+	r.lastId++
+	webhook.ID = r.lastId
+	webhook.CreatedAt = time.Now()
+	r.webhooks[webhook.ID] = webhook
+	return webhook, nil
+}
+
+func (r *IncomingWebhookRepoMemory) ListIncomingWebhooks(ctx context.Context, chatId int) ([]domain.IncomingWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhooks := make([]domain.IncomingWebhook, 0)
+	if !r.chatExists(ctx, chatId) {
+		return webhooks, nil
+	}
+	for _, webhook := range r.webhooks {
+		if webhook.ChatId == chatId {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, nil
+}
+
+// chatExists заменяет каскадное удаление: вебхуки удаленного чата не видны
+func (r *IncomingWebhookRepoMemory) chatExists(ctx context.Context, chatId int) bool {
+	_, err := r.chats.GetChatByID(ctx, chatId)
+	return err == nil
+}
+
+func (r *IncomingWebhookRepoMemory) DeleteIncomingWebhook(ctx context.Context, chatId, webhookId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook, exists := r.webhooks[webhookId]
+	if !exists || webhook.ChatId != chatId || !r.chatExists(ctx, chatId) {
+		return domain.NotFound(domain.EntityIncomingWebhook, webhookId)
+	}
+	delete(r.webhooks, webhookId)
+	return nil
+}
+
+func (r *IncomingWebhookRepoMemory) GetIncomingWebhookByToken(ctx context.Context, tokenHash []byte) (domain.IncomingWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, webhook := range r.webhooks {
+		if bytes.Equal(webhook.TokenHash, tokenHash) && r.chatExists(ctx, webhook.ChatId) {
+			return webhook, nil
+		}
+	}
+	return domain.IncomingWebhook{}, domain.NotFound(domain.EntityIncomingWebhook, nil)
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"bytes"
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type InviteRepoMemory struct {
+	mu      sync.Mutex
+	chats   *ChatRepoMemory
+	invites map[int]domain.Invite
+	lastId  int
+}
+
+func NewInviteRepoMemory(chats *ChatRepoMemory) *InviteRepoMemory {
+	return &InviteRepoMemory{
+		chats:   chats,
+		invites: make(map[int]domain.Invite),
+	}
+}
+
+func (r *InviteRepoMemory) CreateInvite(ctx context.Context, invite domain.Invite) (domain.Invite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.chats.GetChatByID(ctx, invite.ChatId); err != nil {
+		return domain.Invite{}, err
+	}
+	r.lastId++
+	invite.ID = r.lastId
+	invite.CreatedAt = time.Now()
+	r.invites[invite.ID] = invite
+	return invite, nil
+}
+
+func (r *InviteRepoMemory) ListInvites(ctx context.Context, chatId int) ([]domain.Invite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invites := make([]domain.Invite, 0)
+	for _, invite := range r.invites {
+		if invite.ChatId == chatId {
+			invites = append(invites, invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].ID < invites[j].ID })
+	return invites, nil
+}
+
+func (r *InviteRepoMemory) RevokeInvite(ctx context.Context, chatId, inviteId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invite, exists := r.invites[inviteId]
+	if !exists || invite.ChatId != chatId {
+		return domain.NotFound(domain.EntityInvite, inviteId)
+	}
+	if invite.RevokedAt == nil {
+		now := time.Now()
+		invite.RevokedAt = &now
+		r.invites[inviteId] = invite
+	}
+	return nil
+}
+
+func (r *InviteRepoMemory) AcceptInvite(ctx context.Context, tokenHash []byte, userId string) (domain.ChatMember, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, invite := range r.invites {
+		if !bytes.Equal(invite.TokenHash, tokenHash) {
+			continue
+		}
+		if invite.RevokedAt != nil ||
+			(invite.ExpiresAt != nil && !invite.ExpiresAt.After(time.Now())) ||
+			(invite.MaxUses > 0 && invite.Uses >= invite.MaxUses) {
+			return domain.ChatMember{}, false, domain.Conflict(domain.EntityInvite, id, storage.InviteUnavailableError)
+		}
+
+		r.chats.mu.Lock()
+		defer r.chats.mu.Unlock()
+		if _, exists := r.chats.chats[invite.ChatId]; !exists {
+			return domain.ChatMember{}, false, domain.NotFound(domain.EntityInvite, nil)
+		}
+		member, joined := r.chats.addMember(domain.ChatMember{ChatId: invite.ChatId, UserId: userId, Role: invite.Role})
+		if joined {
+			invite.Uses++
+			r.invites[id] = invite
+		}
+		return member, joined, nil
+	}
+	return domain.ChatMember{}, false, domain.NotFound(domain.EntityInvite, nil)
+}
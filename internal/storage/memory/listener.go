@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"chat-project/internal/domain"
+	"context"
+	"sync"
+)
+
+// ListenerMemory is an in-process pub/sub used instead of redis for tests and single instance runs
+type ListenerMemory struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan domain.Event]context.Context
+}
+
+func NewListenerMemory() *ListenerMemory {
+	return &ListenerMemory{
+		subscribers: make(map[int]map[chan domain.Event]context.Context),
+	}
+}
+
+func (l *ListenerMemory) Subscribe(ctx context.Context, chatId int) <-chan domain.Event {
+	ch := make(chan domain.Event, 16)
+
+	l.mu.Lock()
+	if l.subscribers[chatId] == nil {
+		l.subscribers[chatId] = make(map[chan domain.Event]context.Context)
+	}
+	l.subscribers[chatId][ch] = ctx
+	l.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		l.mu.Lock()
+		delete(l.subscribers[chatId], ch)
+		if len(l.subscribers[chatId]) == 0 {
+			delete(l.subscribers, chatId)
+		}
+		close(ch)
+		l.mu.Unlock()
+	}()
+
+	return ch
+}
+
+func (l *ListenerMemory) Publish(ctx context.Context, chatId int, event domain.Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch, subCtx := range l.subscribers[chatId] {
+		select {
+		case ch <- event:
+		case <-subCtx.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// SubscribersCount returns number of active subscriptions for chat
+func (l *ListenerMemory) SubscribersCount(chatId int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.subscribers[chatId])
+}
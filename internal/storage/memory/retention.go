@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"chat-project/internal/domain"
+	"context"
+	"sort"
+	"time"
+)
+
+// RetentionRepoMemory меняет сообщения и удержание прямо в ChatRepoMemory
+type RetentionRepoMemory struct {
+	chats *ChatRepoMemory
+}
+
+func NewRetentionRepoMemory(chats *ChatRepoMemory) *RetentionRepoMemory {
+	return &RetentionRepoMemory{chats: chats}
+}
+
+func (r *RetentionRepoMemory) SetLegalHold(ctx context.Context, chatId int, hold bool) (domain.Chat, error) {
+	r.chats.mu.Lock()
+	defer r.chats.mu.Unlock()
+
+	chat, exists := r.chats.chats[chatId]
+	if !exists {
+		return domain.Chat{}, domain.NotFound(domain.EntityChat, chatId)
+	}
+	chat.LegalHold = hold
+	r.chats.chats[chatId] = chat
+
+	chat.Messages = nil
+	return chat, nil
+}
+
+func (r *RetentionRepoMemory) CountMessagesBefore(ctx context.Context, chatId int, before time.Time) (int64, error) {
+	r.chats.mu.RLock()
+	defer r.chats.mu.RUnlock()
+
+	var count int64
+	for _, message := range r.chats.chats[chatId].Messages {
+		if message.CreatedAt.Before(before) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *RetentionRepoMemory) PurgeMessagesBatch(ctx context.Context, chatId int, before time.Time, limit int) ([]int, error) {
+	r.chats.mu.Lock()
+	defer r.chats.mu.Unlock()
+
+	chat, exists := r.chats.chats[chatId]
+	if !exists || chat.LegalHold {
+		return []int{}, nil
+	}
+
+	expired := make([]domain.Message, 0)
+	for _, message := range chat.Messages {
+		if message.CreatedAt.Before(before) {
+			expired = append(expired, message)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		if expired[i].CreatedAt.Equal(expired[j].CreatedAt) {
+			return expired[i].ID < expired[j].ID
+		}
+		return expired[i].CreatedAt.Before(expired[j].CreatedAt)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	ids := make([]int, 0, len(expired))
+	purged := make(map[int]bool, len(expired))
+	for _, message := range expired {
+		ids = append(ids, message.ID)
+		purged[message.ID] = true
+	}
+	kept := make([]domain.Message, 0, len(chat.Messages)-len(ids))
+	for _, message := range chat.Messages {
+		if !purged[message.ID] {
+			kept = append(kept, message)
+		}
+	}
+	chat.Messages = kept
+	r.chats.chats[chatId] = chat
+	return ids, nil
+}
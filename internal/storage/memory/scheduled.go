@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type scheduledEntry struct {
+	msg         domain.ScheduledMessage
+	lockedUntil time.Time
+}
+
+type ScheduledMessageRepoMemory struct {
+	mu       sync.Mutex
+	chats    *ChatRepoMemory
+	messages map[int]scheduledEntry
+	lastId   int
+	now      func() time.Time
+}
+
+func NewScheduledMessageRepoMemory(chats *ChatRepoMemory) *ScheduledMessageRepoMemory {
+	return &ScheduledMessageRepoMemory{
+		chats:    chats,
+		messages: make(map[int]scheduledEntry),
+		now:      time.Now,
+	}
+}
+
+func (r *ScheduledMessageRepoMemory) CreateScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) (domain.ScheduledMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.chats.GetChatByID(ctx, msg.ChatId); err != nil {
+		return domain.ScheduledMessage{}, err
+	}
+	r.lastId++
+	msg.ID = r.lastId
+	msg.Status = domain.ScheduledPending
+	msg.Attempts = 0
+	msg.NextAttemptAt = msg.SendAt
+	msg.LastError = ""
+	msg.CreatedAt = r.now()
+	r.messages[msg.ID] = scheduledEntry{msg: msg}
+	return msg, nil
+}
+
+// find возвращает сообщение автора. Сообщения удаленного чата не видны, как после каскадного удаления
+func (r *ScheduledMessageRepoMemory) find(ctx context.Context, chatId, id int, createdBy string) (scheduledEntry, error) {
+	entry, exists := r.messages[id]
+	if !exists || entry.msg.ChatId != chatId || entry.msg.CreatedBy != createdBy || !r.chatExists(ctx, chatId) {
+		return scheduledEntry{}, domain.NotFound(domain.EntityScheduled, id)
+	}
+	return entry, nil
+}
+
+func (r *ScheduledMessageRepoMemory) chatExists(ctx context.Context, chatId int) bool {
+	_, err := r.chats.GetChatByID(ctx, chatId)
+	return err == nil
+}
+
+func (r *ScheduledMessageRepoMemory) GetScheduledMessage(ctx context.Context, chatId, id int, createdBy string) (domain.ScheduledMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, err := r.find(ctx, chatId, id, createdBy)
+	return entry.msg, err
+}
+
+func (r *ScheduledMessageRepoMemory) ListScheduledMessages(ctx context.Context, chatId int, createdBy string) ([]domain.ScheduledMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := make([]domain.ScheduledMessage, 0)
+	if !r.chatExists(ctx, chatId) {
+		return messages, nil
+	}
+	for _, entry := range r.messages {
+		if entry.msg.ChatId == chatId && entry.msg.CreatedBy == createdBy {
+			messages = append(messages, entry.msg)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].SendAt.Equal(messages[j].SendAt) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].SendAt.Before(messages[j].SendAt)
+	})
+	return messages, nil
+}
+
+func (r *ScheduledMessageRepoMemory) UpdateScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) (domain.ScheduledMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, err := r.find(ctx, msg.ChatId, msg.ID, msg.CreatedBy)
+	if err != nil {
+		return domain.ScheduledMessage{}, err
+	}
+	if entry.lockedUntil.After(r.now()) {
+		return domain.ScheduledMessage{}, domain.Conflict(domain.EntityScheduled, msg.ID, storage.ScheduledMessageSendingError)
+	}
+	entry.msg.Text = msg.Text
+	entry.msg.SendAt = msg.SendAt
+	entry.msg.NextAttemptAt = msg.SendAt
+	entry.msg.Status = domain.ScheduledPending
+	entry.msg.Attempts = 0
+	entry.msg.LastError = ""
+	r.messages[msg.ID] = entry
+	return entry.msg, nil
+}
+
+func (r *ScheduledMessageRepoMemory) DeleteScheduledMessage(ctx context.Context, chatId, id int, createdBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, err := r.find(ctx, chatId, id, createdBy)
+	if err != nil {
+		return err
+	}
+	if entry.lockedUntil.After(r.now()) {
+		return domain.Conflict(domain.EntityScheduled, id, storage.ScheduledMessageSendingError)
+	}
+	delete(r.messages, id)
+	return nil
+}
+
+func (r *ScheduledMessageRepoMemory) ClaimScheduledMessages(ctx context.Context, limit int, lease time.Duration) ([]domain.ScheduledMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	due := make([]domain.ScheduledMessage, 0)
+	for id, entry := range r.messages {
+		if !r.chatExists(ctx, entry.msg.ChatId) {
+			delete(r.messages, id)
+			continue
+		}
+		if entry.msg.Status == domain.ScheduledPending && !entry.msg.NextAttemptAt.After(now) && !entry.lockedUntil.After(now) {
+			due = append(due, entry.msg)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].ID < due[j].ID
+		}
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for _, msg := range due {
+		r.messages[msg.ID] = scheduledEntry{msg: msg, lockedUntil: now.Add(lease)}
+	}
+	return due, nil
+}
+
+func (r *ScheduledMessageRepoMemory) CompleteScheduledMessage(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.messages, id)
+	return nil
+}
+
+func (r *ScheduledMessageRepoMemory) FailScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.messages[msg.ID]
+	if !exists {
+		return nil
+	}
+	entry.msg.Status = msg.Status
+	entry.msg.Attempts = msg.Attempts
+	entry.msg.NextAttemptAt = msg.NextAttemptAt
+	entry.msg.LastError = msg.LastError
+	entry.lockedUntil = time.Time{}
+	r.messages[msg.ID] = entry
+	return nil
+}
@@ -0,0 +1,15 @@
+package memory
+
+import "context"
+
+// TxManagerMemory выполняет fn без транзакции: каждый репозиторий в памяти меняет данные под своей блокировкой,
+// а ошибки записи очереди вебхуков в памяти не бывает
+type TxManagerMemory struct{}
+
+func NewTxManagerMemory() *TxManagerMemory {
+	return &TxManagerMemory{}
+}
+
+func (m TxManagerMemory) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type WebhookRepoMemory struct {
+	mu         sync.Mutex
+	chats      *ChatRepoMemory
+	webhooks   map[int]domain.Webhook
+	deliveries map[int]domain.WebhookDelivery
+	lastId     int
+	now        func() time.Time
+}
+
+func NewWebhookRepoMemory(chats *ChatRepoMemory) *WebhookRepoMemory {
+	return &WebhookRepoMemory{
+		chats:      chats,
+		webhooks:   make(map[int]domain.Webhook),
+		deliveries: make(map[int]domain.WebhookDelivery),
+		now:        time.Now,
+	}
+}
+
+func (r *WebhookRepoMemory) nextId() int {
+	r.lastId++
+	return r.lastId
+}
+
+func (r *WebhookRepoMemory) CreateWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.chats.GetChatByID(ctx, webhook.ChatId); err != nil {
+		return domain.Webhook{}, err
+	}
+	webhook.ID = r.nextId()
+	webhook.CreatedAt = r.now()
+	r.webhooks[webhook.ID] = webhook
+	return webhook, nil
+}
+
+func (r *WebhookRepoMemory) ListWebhooks(ctx context.Context, chatId int) ([]domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhooks := make([]domain.Webhook, 0)
+	for _, webhook := range r.webhooks {
+		if webhook.ChatId == chatId && r.chatExists(ctx, chatId) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, nil
+}
+
+// chatExists заменяет каскадное удаление: вебхуки удаленного чата не видны
+func (r *WebhookRepoMemory) chatExists(ctx context.Context, chatId int) bool {
+	_, err := r.chats.GetChatByID(ctx, chatId)
+	return err == nil
+}
+
+func (r *WebhookRepoMemory) DeleteWebhook(ctx context.Context, chatId, webhookId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook, exists := r.webhooks[webhookId]
+	if !exists || webhook.ChatId != chatId || !r.chatExists(ctx, chatId) {
+		return domain.NotFound(domain.EntityWebhook, webhookId)
+	}
+	delete(r.webhooks, webhookId)
+	for id, d := range r.deliveries {
+		if d.WebhookId != webhookId {
+			continue
+		}
+		if d.Status == domain.DeliveryPending {
+			delete(r.deliveries, id)
+			continue
+		}
+		d.WebhookId = 0
+		r.deliveries[id] = d
+	}
+	return nil
+}
+
+func (r *WebhookRepoMemory) EnqueueEvent(ctx context.Context, event domain.Event, payload []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.chatExists(ctx, event.ChatId) {
+		return 0, nil
+	}
+	now := r.now()
+	count := 0
+	for _, webhook := range r.webhooks {
+		if webhook.ChatId != event.ChatId || !webhook.Subscribed(event.Type) {
+			continue
+		}
+		d := domain.WebhookDelivery{
+			ID:            r.nextId(),
+			WebhookId:     webhook.ID,
+			ChatId:        webhook.ChatId,
+			URL:           webhook.URL,
+			Secret:        webhook.Secret,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		r.deliveries[d.ID] = d
+		count++
+	}
+	return count, nil
+}
+
+func (r *WebhookRepoMemory) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	due := make([]domain.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.Status == domain.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].ID < due[j].ID
+		}
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].NextAttemptAt = now.Add(lease)
+		r.deliveries[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+func (r *WebhookRepoMemory) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, exists := r.deliveries[delivery.ID]
+	if !exists {
+		// Вебхук удален, пока шла попытка
+		return nil
+	}
+	d.Status = delivery.Status
+	d.Attempts = delivery.Attempts
+	d.NextAttemptAt = delivery.NextAttemptAt
+	d.LastStatusCode = delivery.LastStatusCode
+	d.LastError = delivery.LastError
+	d.DeliveredAt = delivery.DeliveredAt
+	r.deliveries[d.ID] = d
+	return nil
+}
+
+func (r *WebhookRepoMemory) ListDeliveries(ctx context.Context, chatId, webhookId int, status domain.DeliveryStatus, limit, offset int) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.WebhookId == webhookId && d.ChatId == chatId && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	if offset >= len(deliveries) {
+		return []domain.WebhookDelivery{}, nil
+	}
+	deliveries = deliveries[offset:]
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepoMemory) RetryDelivery(ctx context.Context, chatId, webhookId, deliveryId int) (domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, exists := r.deliveries[deliveryId]
+	if !exists || d.WebhookId != webhookId || d.ChatId != chatId {
+		return domain.WebhookDelivery{}, domain.NotFound(domain.EntityDelivery, deliveryId)
+	}
+	if d.Status != domain.DeliveryDead {
+		return domain.WebhookDelivery{}, domain.Conflict(domain.EntityDelivery, deliveryId, storage.DeliveryNotDeadError)
+	}
+	d.Status = domain.DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = r.now()
+	r.deliveries[d.ID] = d
+	return d, nil
+}
+
+func (r *WebhookRepoMemory) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, d := range r.deliveries {
+		if d.Status != domain.DeliveryPending && d.CreatedAt.Before(before) {
+			delete(r.deliveries, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"chat-project/internal/domain"
@@ -13,36 +17,91 @@ import (
 
 type ChatRepoPostgres struct {
 	pool *pgxpool.Pool
+	log  *slog.Logger
+	now  func() time.Time
 }
 
-func NewChatRepoPostgres(pgpool *pgxpool.Pool) *ChatRepoPostgres {
+func NewChatRepoPostgres(pgpool *pgxpool.Pool, log *slog.Logger) *ChatRepoPostgres {
 	return &ChatRepoPostgres{
 		pool: pgpool,
+		log:  log,
+		now:  time.Now,
 	}
 }
 
-func (r ChatRepoPostgres) CreateChat(ctx context.Context, chat domain.Chat) (domain.Chat, error) {
-	var id int
-	err := r.pool.QueryRow(
-		ctx, "INSERT INTO chats (title, created_at) VALUES ($1, $2) RETURNING id", chat.Title, chat.CreatedAt,
-	).Scan(&id)
+// SetClock подменяет часы, по которым скрываются сообщения с истекшим TTL
+func (r *ChatRepoPostgres) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// Коды ошибок Postgres при нарушении внешнего ключа и уникальности
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// isForeignKeyViolation сообщает, что запись ссылается на несуществующую строку, например удаленный чат
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// Колонки чата в порядке, ожидаемом scanChat
+const chatColumns = "id, type, title, private, description, avatar_url, settings, version, created_at, legal_hold"
+
+func scanChat(row pgx.Row, chat *domain.Chat, extra ...any) error {
+	dest := []any{
+		&chat.ID, &chat.Type, &chat.Title, &chat.Private, &chat.Description, &chat.AvatarURL, &chat.Settings, &chat.Version, &chat.CreatedAt, &chat.LegalHold,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (r ChatRepoPostgres) CreateChat(ctx context.Context, chat domain.Chat, ownerId string) (domain.Chat, error) {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return domain.Chat{}, fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer rollback(ctx, tx, r.log)
+
+	err = tx.QueryRow(
+		ctx,
+		`INSERT INTO chats (title, private, description, avatar_url, settings, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, type, version`,
+		chat.Title, chat.Private, chat.Description, chat.AvatarURL, chat.Settings, chat.CreatedAt,
+	).Scan(&chat.ID, &chat.Type, &chat.Version)
 
 	if err != nil {
 		return domain.Chat{}, err
 	}
 
-	chat.ID = id
+	if ownerId != "" {
+		_, err = tx.Exec(
+			ctx,
+			"INSERT INTO chat_members (chat_id, user_id, role) VALUES ($1, $2, $3)",
+			chat.ID, ownerId, domain.ChatRoleOwner,
+		)
+		if err != nil {
+			return domain.Chat{}, fmt.Errorf("error while adding chat owner: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Chat{}, fmt.Errorf("error while committing chat: %w", err)
+	}
 	return chat, nil
 }
 
 func (r ChatRepoPostgres) GetChatByID(ctx context.Context, chatId int) (domain.Chat, error) {
 	var chat domain.Chat
-	err := r.pool.QueryRow(ctx, "SELECT id, title, created_at FROM chats WHERE id = $1", chatId).Scan(
-		&chat.ID, &chat.Title, &chat.CreatedAt,
-	)
+	err := scanChat(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+chatColumns+" FROM chats WHERE id = $1", chatId), &chat)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return domain.Chat{}, storage.ChatNotFoundError
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Chat{}, domain.NotFound(domain.EntityChat, chatId)
 		}
 		return domain.Chat{}, fmt.Errorf("error while getting chat: %w", err,)
 	}
@@ -52,12 +111,23 @@ func (r ChatRepoPostgres) GetChatByID(ctx context.Context, chatId int) (domain.C
 
 func (r ChatRepoPostgres) AddMessage(ctx context.Context, message domain.Message, chatId int) (domain.Message, error) {
 	var id int
-	err := r.pool.QueryRow(
+	err := conn(ctx, r.pool).QueryRow(
 		ctx,
-		"INSERT INTO messages (chat_id, text) VALUES ($1, $2) RETURNING id", chatId, message.Text,
+		"INSERT INTO messages (chat_id, kind, text, author_id, client_nonce, invoked_by, scheduled_message_id, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		chatId, message.Kind, message.Text, message.AuthorId, message.ClientNonce, message.InvokedBy, message.ScheduledId, message.ExpiresAt,
 	).Scan(&id)
+	if isForeignKeyViolation(err) {
+		return domain.Message{}, domain.NotFound(domain.EntityChat, chatId)
+	}
+	// У запланированного сообщения нет ClientNonce, поэтому нарушить оно может только свой индекс
+	if isUniqueViolation(err) && message.ScheduledId != nil {
+		return domain.Message{}, domain.Conflict(domain.EntityMessage, *message.ScheduledId, storage.ScheduledMessageSentError)
+	}
+	if isUniqueViolation(err) {
+		return domain.Message{}, domain.Conflict(domain.EntityMessage, message.ClientNonce, storage.MessageNonceConflictError)
+	}
 	if err != nil {
-		return domain.Message{}, err
+		return domain.Message{}, fmt.Errorf("error while adding message: %w", err)
 	}
 	message.ID = id
 
@@ -69,31 +139,277 @@ func (r ChatRepoPostgres) GetWithMessages(ctx context.Context, chatId int) (doma
 	query := `
 	SELECT
 	c.id,
+	c.type,
 	c.title,
+	c.private,
+	c.description,
+	c.avatar_url,
+	c.settings,
+	c.version,
 	c.created_at,
+	c.legal_hold,
 	COALESCE(
 		jsonb_agg(
 			jsonb_build_object(
 				'id', m.id,
+				'chatId', m.chat_id,
+				'kind', m.kind,
 				'text', m.text,
-				'created_at',  m.created_at
+				'authorId', m.author_id,
+				'clientNonce', m.client_nonce,
+				'createdAt', m.created_at,
+				'expiresAt', m.expires_at
 			)
 		) FILTER (WHERE m.id IS NOT NULL), '[]') AS messages
 	FROM chats c
-	LEFT JOIN messages m ON c.id = m.chat_id
+	LEFT JOIN messages m ON c.id = m.chat_id AND (m.expires_at IS NULL OR m.expires_at > $2)
 	WHERE c.id = $1
-	GROUP BY c.id, c.created_at, c.title
+	GROUP BY c.id
 	`
-	err := r.pool.QueryRow(ctx, query, chatId).Scan(&chat.ID, &chat.Title, &chat.CreatedAt, &chat.Messages)
+	err := scanChat(conn(ctx, r.pool).QueryRow(ctx, query, chatId, r.now()), &chat, &chat.Messages)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Chat{}, domain.NotFound(domain.EntityChat, chatId)
+	}
 	if err != nil {
-		return domain.Chat{}, fmt.Errorf("error while getting chat with messages: %s %w", err, storage.ChatNotFoundError)
+		return domain.Chat{}, fmt.Errorf("error while getting chat with messages: %w", err)
 	}
 
 	return chat, nil
 }
 
+// Колонки сообщения в порядке, ожидаемом scanMessage
+const messageColumns = "id, chat_id, kind, text, author_id, client_nonce, invoked_by, scheduled_message_id, created_at, expires_at"
+
+func scanMessage(row pgx.Row, msg *domain.Message) error {
+	return row.Scan(&msg.ID, &msg.ChatId, &msg.Kind, &msg.Text, &msg.AuthorId, &msg.ClientNonce, &msg.InvokedBy, &msg.ScheduledId, &msg.CreatedAt, &msg.ExpiresAt)
+}
+
+func (r ChatRepoPostgres) GetMessageByNonce(ctx context.Context, chatId int, authorId, invokedBy, nonce string) (domain.Message, error) {
+	var msg domain.Message
+	err := scanMessage(conn(ctx, r.pool).QueryRow(
+		ctx,
+		"SELECT "+messageColumns+" FROM messages WHERE chat_id = $1 AND author_id = $2 AND invoked_by = $3 AND client_nonce = $4 AND client_nonce <> ''",
+		chatId, authorId, invokedBy, nonce,
+	), &msg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Message{}, domain.NotFound(domain.EntityMessage, nonce)
+	}
+	if err != nil {
+		return domain.Message{}, fmt.Errorf("error while getting message by client nonce: %w", err)
+	}
+	return msg, nil
+}
+
+func (r ChatRepoPostgres) GetMessageByScheduledID(ctx context.Context, chatId, scheduledId int) (domain.Message, error) {
+	var msg domain.Message
+	err := scanMessage(conn(ctx, r.pool).QueryRow(
+		ctx,
+		"SELECT "+messageColumns+" FROM messages WHERE chat_id = $1 AND scheduled_message_id = $2",
+		chatId, scheduledId,
+	), &msg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Message{}, domain.NotFound(domain.EntityMessage, scheduledId)
+	}
+	if err != nil {
+		return domain.Message{}, fmt.Errorf("error while getting message by scheduled message id: %w", err)
+	}
+	return msg, nil
+}
+
+func (r ChatRepoPostgres) GetMessagesAfter(ctx context.Context, chatId, afterId, limit int) ([]domain.Message, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx,
+		`SELECT `+messageColumns+` FROM messages
+		WHERE chat_id = $1 AND id > $2 AND (expires_at IS NULL OR expires_at > $4)
+		ORDER BY id LIMIT $3`,
+		chatId, afterId, limit, r.now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]domain.Message, 0)
+	for rows.Next() {
+		var msg domain.Message
+		if err := scanMessage(rows, &msg); err != nil {
+			return nil, fmt.Errorf("error while scanning message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while getting messages: %w", err)
+	}
+	// Пустой результат может означать, что чата нет
+	if len(messages) == 0 {
+		if _, err := r.GetChatByID(ctx, chatId); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+func (r ChatRepoPostgres) UpdateChat(ctx context.Context, chat domain.Chat) (domain.Chat, error) {
+	err := conn(ctx, r.pool).QueryRow(
+		ctx,
+		`UPDATE chats SET title = $2, private = $3, description = $4, avatar_url = $5, settings = $6, version = version + 1
+		WHERE id = $1 AND version = $7
+		RETURNING type, version, created_at, legal_hold`,
+		chat.ID, chat.Title, chat.Private, chat.Description, chat.AvatarURL, chat.Settings, chat.Version,
+	).Scan(&chat.Type, &chat.Version, &chat.CreatedAt, &chat.LegalHold)
+	if err == nil {
+		chat.Messages = nil
+		return chat, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return domain.Chat{}, fmt.Errorf("error while updating chat: %w", err)
+	}
+
+	// Строка не обновилась: либо чата нет, либо версия уже изменилась
+	if _, err := r.GetChatByID(ctx, chat.ID); err != nil {
+		return domain.Chat{}, err
+	}
+	return domain.Chat{}, domain.Conflict(domain.EntityChat, chat.ID, storage.ChatVersionConflictError)
+}
+
+// ListChats возвращает публичные групповые чаты, личные и приватные чаты в список не попадают
+func (r ChatRepoPostgres) ListChats(ctx context.Context, limit, offset int) ([]domain.Chat, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx,
+		"SELECT "+chatColumns+" FROM chats WHERE type = $1 AND NOT private ORDER BY id DESC LIMIT $2 OFFSET $3",
+		domain.ChatTypeGroup, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing chats: %w", err)
+	}
+	return collectChats(rows, limit)
+}
+
+func (r ChatRepoPostgres) ListAllChats(ctx context.Context, limit, offset int) ([]domain.Chat, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx, "SELECT "+chatColumns+" FROM chats ORDER BY id DESC LIMIT $1 OFFSET $2", limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing chats: %w", err)
+	}
+	return collectChats(rows, limit)
+}
+
+func collectChats(rows pgx.Rows, limit int) ([]domain.Chat, error) {
+	defer rows.Close()
+
+	chats := make([]domain.Chat, 0, limit)
+	for rows.Next() {
+		var chat domain.Chat
+		if err := scanChat(rows, &chat); err != nil {
+			return nil, fmt.Errorf("error while scanning chat: %w", err)
+		}
+		chats = append(chats, chat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while listing chats: %w", err)
+	}
+
+	return chats, nil
+}
+
+// GetOrCreateDirectChat возвращает личный чат пары пользователей, создавая его при первом обращении.
+// Уникальный direct_key делает создание идемпотентным при конкурентных запросах.
+func (r ChatRepoPostgres) GetOrCreateDirectChat(ctx context.Context, userA, userB string) (domain.Chat, bool, error) {
+	key := domain.DirectChatKey(userA, userB)
+
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return domain.Chat{}, false, fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer rollback(ctx, tx, r.log)
+
+	var chat domain.Chat
+	err = scanChat(tx.QueryRow(
+		ctx,
+		`INSERT INTO chats (type, title, direct_key) VALUES ($1, '', $2)
+		ON CONFLICT (direct_key) DO NOTHING
+		RETURNING `+chatColumns,
+		domain.ChatTypeDirect, key,
+	), &chat)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = scanChat(tx.QueryRow(ctx, "SELECT "+chatColumns+" FROM chats WHERE direct_key = $1", key), &chat)
+		if err != nil {
+			return domain.Chat{}, false, fmt.Errorf("error while getting direct chat: %w", err)
+		}
+		return chat, false, nil
+	}
+	if err != nil {
+		return domain.Chat{}, false, fmt.Errorf("error while creating direct chat: %w", err)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		"INSERT INTO chat_members (chat_id, user_id, role) VALUES ($1, $2, $4), ($1, $3, $4)",
+		chat.ID, userA, userB, domain.ChatRoleMember,
+	)
+	if err != nil {
+		return domain.Chat{}, false, fmt.Errorf("error while adding direct chat members: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Chat{}, false, fmt.Errorf("error while committing direct chat: %w", err)
+	}
+	return chat, true, nil
+}
+
+func (r ChatRepoPostgres) AddMember(ctx context.Context, member domain.ChatMember) error {
+	_, err := conn(ctx, r.pool).Exec(
+		ctx,
+		`INSERT INTO chat_members (chat_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (chat_id, user_id) DO NOTHING`,
+		member.ChatId, member.UserId, member.Role,
+	)
+	if isForeignKeyViolation(err) {
+		return domain.NotFound(domain.EntityChat, member.ChatId)
+	}
+	if err != nil {
+		return fmt.Errorf("error while adding chat member: %w", err)
+	}
+	return nil
+}
+
+func (r ChatRepoPostgres) GetMember(ctx context.Context, chatId int, userId string) (domain.ChatMember, error) {
+	var member domain.ChatMember
+	err := conn(ctx, r.pool).QueryRow(
+		ctx,
+		"SELECT chat_id, user_id, role, joined_at FROM chat_members WHERE chat_id = $1 AND user_id = $2",
+		chatId, userId,
+	).Scan(&member.ChatId, &member.UserId, &member.Role, &member.JoinedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ChatMember{}, domain.NotFound(domain.EntityMember, userId)
+		}
+		return domain.ChatMember{}, fmt.Errorf("error while getting chat member: %w", err)
+	}
+	return member, nil
+}
+
+func (r ChatRepoPostgres) HasManager(ctx context.Context, chatId int) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.pool).QueryRow(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM chat_members WHERE chat_id = $1 AND role IN ($2, $3))",
+		chatId, domain.ChatRoleOwner, domain.ChatRoleAdmin,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error while checking chat managers: %w", err)
+	}
+	return exists, nil
+}
+
 func (r ChatRepoPostgres) DeleteChat(ctx context.Context, chatId int) error {
-	//
-	_, err := r.pool.Exec(ctx, "DELETE FROM chats WHERE id = $1", chatId)
-	return err
+	tag, err := conn(ctx, r.pool).Exec(ctx, "DELETE FROM chats WHERE id = $1", chatId)
+	if err != nil {
+		return fmt.Errorf("error while deleting chat: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.NotFound(domain.EntityChat, chatId)
+	}
+	return nil
 }
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"chat-project/internal/domain"
+)
+
+type ExpiredMessageRepoPostgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewExpiredMessageRepoPostgres(pgpool *pgxpool.Pool) *ExpiredMessageRepoPostgres {
+	return &ExpiredMessageRepoPostgres{
+		pool: pgpool,
+	}
+}
+
+// DeleteExpiredMessages ищет сообщения по частичному индексу messages_expires_at_idx. SKIP LOCKED
+// пропускает строки, которые удаляет другой инстанс, поэтому каждое сообщение возвращается один раз
+func (r ExpiredMessageRepoPostgres) DeleteExpiredMessages(ctx context.Context, now time.Time, limit int) ([]domain.Message, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx,
+		`DELETE FROM messages WHERE id IN (
+			SELECT m.id FROM messages m
+			JOIN chats c ON c.id = m.chat_id
+			WHERE m.expires_at <= $1 AND NOT c.legal_hold
+			ORDER BY m.expires_at, m.id
+			LIMIT $2
+			FOR UPDATE OF m SKIP LOCKED
+		)
+		RETURNING `+messageColumns,
+		now, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while deleting expired messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]domain.Message, 0)
+	for rows.Next() {
+		var msg domain.Message
+		if err := scanMessage(rows, &msg); err != nil {
+			return nil, fmt.Errorf("error while scanning expired message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while deleting expired messages: %w", err)
+	}
+	return messages, nil
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"chat-project/internal/domain"
+)
+
+type IncomingWebhookRepoPostgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewIncomingWebhookRepoPostgres(pgpool *pgxpool.Pool) *IncomingWebhookRepoPostgres {
+	return &IncomingWebhookRepoPostgres{
+		pool: pgpool,
+	}
+}
+
+const incomingWebhookColumns = "id, chat_id, name, token_hash, created_by, created_at"
+
+func scanIncomingWebhook(row pgx.Row, webhook *domain.IncomingWebhook) error {
+	return row.Scan(&webhook.ID, &webhook.ChatId, &webhook.Name, &webhook.TokenHash, &webhook.CreatedBy, &webhook.CreatedAt)
+}
+
+func (r IncomingWebhookRepoPostgres) CreateIncomingWebhook(ctx context.Context, webhook domain.IncomingWebhook) (domain.IncomingWebhook, error) {
+	err := conn(ctx, r.pool).QueryRow(
+		ctx,
+		`INSERT INTO chat_incoming_webhooks (chat_id, name, token_hash, created_by)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		webhook.ChatId, webhook.Name, webhook.TokenHash, webhook.CreatedBy,
+	).Scan(&webhook.ID, &webhook.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return domain.IncomingWebhook{}, domain.NotFound(domain.EntityChat, webhook.ChatId)
+	}
+	if err != nil {
+		return domain.IncomingWebhook{}, fmt.Errorf("error while creating incoming webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (r IncomingWebhookRepoPostgres) ListIncomingWebhooks(ctx context.Context, chatId int) ([]domain.IncomingWebhook, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx, "SELECT "+incomingWebhookColumns+" FROM chat_incoming_webhooks WHERE chat_id = $1 ORDER BY id", chatId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing incoming webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]domain.IncomingWebhook, 0)
+	for rows.Next() {
+		var webhook domain.IncomingWebhook
+		if err := scanIncomingWebhook(rows, &webhook); err != nil {
+			return nil, fmt.Errorf("error while scanning incoming webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while listing incoming webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (r IncomingWebhookRepoPostgres) DeleteIncomingWebhook(ctx context.Context, chatId, webhookId int) error {
+	tag, err := conn(ctx, r.pool).Exec(
+		ctx, "DELETE FROM chat_incoming_webhooks WHERE id = $1 AND chat_id = $2", webhookId, chatId,
+	)
+	if err != nil {
+		return fmt.Errorf("error while deleting incoming webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.NotFound(domain.EntityIncomingWebhook, webhookId)
+	}
+	return nil
+}
+
+func (r IncomingWebhookRepoPostgres) GetIncomingWebhookByToken(ctx context.Context, tokenHash []byte) (domain.IncomingWebhook, error) {
+	var webhook domain.IncomingWebhook
+	err := scanIncomingWebhook(conn(ctx, r.pool).QueryRow(
+		ctx, "SELECT "+incomingWebhookColumns+" FROM chat_incoming_webhooks WHERE token_hash = $1", tokenHash,
+	), &webhook)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.IncomingWebhook{}, domain.NotFound(domain.EntityIncomingWebhook, nil)
+	}
+	if err != nil {
+		return domain.IncomingWebhook{}, fmt.Errorf("error while getting incoming webhook: %w", err)
+	}
+	return webhook, nil
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+type InviteRepoPostgres struct {
+	pool *pgxpool.Pool
+	log  *slog.Logger
+}
+
+func NewInviteRepoPostgres(pgpool *pgxpool.Pool, log *slog.Logger) *InviteRepoPostgres {
+	return &InviteRepoPostgres{
+		pool: pgpool,
+		log:  log,
+	}
+}
+
+const inviteColumns = "id, chat_id, role, coalesce(max_uses, 0), uses, expires_at, created_by, created_at, revoked_at"
+
+func scanInvite(row pgx.Row, invite *domain.Invite) error {
+	return row.Scan(
+		&invite.ID, &invite.ChatId, &invite.Role, &invite.MaxUses, &invite.Uses,
+		&invite.ExpiresAt, &invite.CreatedBy, &invite.CreatedAt, &invite.RevokedAt,
+	)
+}
+
+// maxUses хранится как NULL, если количество использований не ограничено
+func nullableMaxUses(maxUses int) *int {
+	if maxUses <= 0 {
+		return nil
+	}
+	return &maxUses
+}
+
+func (r InviteRepoPostgres) CreateInvite(ctx context.Context, invite domain.Invite) (domain.Invite, error) {
+	err := conn(ctx, r.pool).QueryRow(
+		ctx,
+		`INSERT INTO chat_invites (chat_id, token_hash, role, max_uses, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		invite.ChatId, invite.TokenHash, invite.Role, nullableMaxUses(invite.MaxUses), invite.ExpiresAt, invite.CreatedBy,
+	).Scan(&invite.ID, &invite.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return domain.Invite{}, domain.NotFound(domain.EntityChat, invite.ChatId)
+	}
+	if err != nil {
+		return domain.Invite{}, fmt.Errorf("error while creating invite: %w", err)
+	}
+	return invite, nil
+}
+
+func (r InviteRepoPostgres) ListInvites(ctx context.Context, chatId int) ([]domain.Invite, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx, "SELECT "+inviteColumns+" FROM chat_invites WHERE chat_id = $1 ORDER BY id", chatId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing invites: %w", err)
+	}
+	defer rows.Close()
+
+	invites := make([]domain.Invite, 0)
+	for rows.Next() {
+		var invite domain.Invite
+		if err := scanInvite(rows, &invite); err != nil {
+			return nil, fmt.Errorf("error while scanning invite: %w", err)
+		}
+		invites = append(invites, invite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while listing invites: %w", err)
+	}
+	return invites, nil
+}
+
+func (r InviteRepoPostgres) RevokeInvite(ctx context.Context, chatId, inviteId int) error {
+	tag, err := conn(ctx, r.pool).Exec(
+		ctx,
+		"UPDATE chat_invites SET revoked_at = coalesce(revoked_at, now()) WHERE id = $1 AND chat_id = $2",
+		inviteId, chatId,
+	)
+	if err != nil {
+		return fmt.Errorf("error while revoking invite: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.NotFound(domain.EntityInvite, inviteId)
+	}
+	return nil
+}
+
+func (r InviteRepoPostgres) AcceptInvite(ctx context.Context, tokenHash []byte, userId string) (domain.ChatMember, bool, error) {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return domain.ChatMember{}, false, fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer rollback(ctx, tx, r.log)
+
+	// Условие перепроверяется после ожидания блокировки строки,
+	// поэтому конкурентные принятия не превысят max_uses
+	member := domain.ChatMember{UserId: userId}
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE chat_invites SET uses = uses + 1
+		WHERE token_hash = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > now())
+			AND (max_uses IS NULL OR uses < max_uses)
+		RETURNING chat_id, role`,
+		tokenHash,
+	).Scan(&member.ChatId, &member.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ChatMember{}, false, r.unavailableReason(ctx, tokenHash)
+	}
+	if err != nil {
+		return domain.ChatMember{}, false, fmt.Errorf("error while accepting invite: %w", err)
+	}
+
+	err = tx.QueryRow(
+		ctx,
+		`INSERT INTO chat_members (chat_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (chat_id, user_id) DO NOTHING
+		RETURNING joined_at`,
+		member.ChatId, member.UserId, member.Role,
+	).Scan(&member.JoinedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Уже участник: откатываем расход приглашения и возвращаем текущее членство
+		if err := tx.Rollback(ctx); err != nil {
+			return domain.ChatMember{}, false, fmt.Errorf("error while rolling back invite: %w", err)
+		}
+		existing, err := ChatRepoPostgres{pool: r.pool, log: r.log}.GetMember(ctx, member.ChatId, userId)
+		if err != nil {
+			return domain.ChatMember{}, false, err
+		}
+		return existing, false, nil
+	}
+	if err != nil {
+		return domain.ChatMember{}, false, fmt.Errorf("error while adding chat member: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.ChatMember{}, false, fmt.Errorf("error while committing invite: %w", err)
+	}
+	return member, true, nil
+}
+
+// unavailableReason отличает несуществующее приглашение от просроченного или исчерпанного
+func (r InviteRepoPostgres) unavailableReason(ctx context.Context, tokenHash []byte) error {
+	var id int
+	err := conn(ctx, r.pool).QueryRow(ctx, "SELECT id FROM chat_invites WHERE token_hash = $1", tokenHash).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.NotFound(domain.EntityInvite, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("error while getting invite: %w", err)
+	}
+	return domain.Conflict(domain.EntityInvite, id, storage.InviteUnavailableError)
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"chat-project/internal/domain"
+)
+
+type RetentionRepoPostgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewRetentionRepoPostgres(pgpool *pgxpool.Pool) *RetentionRepoPostgres {
+	return &RetentionRepoPostgres{
+		pool: pgpool,
+	}
+}
+
+func (r RetentionRepoPostgres) SetLegalHold(ctx context.Context, chatId int, hold bool) (domain.Chat, error) {
+	var chat domain.Chat
+	err := scanChat(conn(ctx, r.pool).QueryRow(
+		ctx, "UPDATE chats SET legal_hold = $2 WHERE id = $1 RETURNING "+chatColumns, chatId, hold,
+	), &chat)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Chat{}, domain.NotFound(domain.EntityChat, chatId)
+	}
+	if err != nil {
+		return domain.Chat{}, fmt.Errorf("error while setting legal hold: %w", err)
+	}
+	return chat, nil
+}
+
+func (r RetentionRepoPostgres) CountMessagesBefore(ctx context.Context, chatId int, before time.Time) (int64, error) {
+	var count int64
+	err := conn(ctx, r.pool).QueryRow(
+		ctx, "SELECT count(*) FROM messages WHERE chat_id = $1 AND created_at < $2", chatId, before,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error while counting messages: %w", err)
+	}
+	return count, nil
+}
+
+// PurgeMessagesBatch блокирует только удаляемые строки. SKIP LOCKED пропускает строки, занятые
+// другим инстансом, поэтому параллельные запуски не ждут друг друга
+func (r RetentionRepoPostgres) PurgeMessagesBatch(ctx context.Context, chatId int, before time.Time, limit int) ([]int, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx,
+		`DELETE FROM messages WHERE id IN (
+			SELECT m.id FROM messages m
+			JOIN chats c ON c.id = m.chat_id
+			WHERE m.chat_id = $1 AND m.created_at < $2 AND NOT c.legal_hold
+			ORDER BY m.created_at, m.id
+			LIMIT $3
+			FOR UPDATE OF m SKIP LOCKED
+		)
+		RETURNING id`,
+		chatId, before, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while purging messages: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error while scanning purged message id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while purging messages: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+type ScheduledMessageRepoPostgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewScheduledMessageRepoPostgres(pgpool *pgxpool.Pool) *ScheduledMessageRepoPostgres {
+	return &ScheduledMessageRepoPostgres{
+		pool: pgpool,
+	}
+}
+
+const scheduledColumns = `id, chat_id, kind, author_id, created_by, text, send_at, status, attempts,
+	next_attempt_at, last_error, created_at`
+
+// Сообщение заблокировано, пока планировщик его отправляет
+const scheduledUnlocked = "(locked_until IS NULL OR locked_until <= now())"
+
+func scanScheduled(row pgx.Row, msg *domain.ScheduledMessage) error {
+	return row.Scan(
+		&msg.ID, &msg.ChatId, &msg.Kind, &msg.AuthorId, &msg.CreatedBy, &msg.Text, &msg.SendAt, &msg.Status, &msg.Attempts,
+		&msg.NextAttemptAt, &msg.LastError, &msg.CreatedAt,
+	)
+}
+
+func collectScheduled(rows pgx.Rows) ([]domain.ScheduledMessage, error) {
+	defer rows.Close()
+	messages := make([]domain.ScheduledMessage, 0)
+	for rows.Next() {
+		var msg domain.ScheduledMessage
+		if err := scanScheduled(rows, &msg); err != nil {
+			return nil, fmt.Errorf("error while scanning scheduled message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading scheduled messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (r ScheduledMessageRepoPostgres) CreateScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) (domain.ScheduledMessage, error) {
+	var created domain.ScheduledMessage
+	err := scanScheduled(conn(ctx, r.pool).QueryRow(
+		ctx,
+		`INSERT INTO scheduled_messages (chat_id, kind, author_id, created_by, text, send_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6) RETURNING `+scheduledColumns,
+		msg.ChatId, msg.Kind, msg.AuthorId, msg.CreatedBy, msg.Text, msg.SendAt,
+	), &created)
+	if isForeignKeyViolation(err) {
+		return domain.ScheduledMessage{}, domain.NotFound(domain.EntityChat, msg.ChatId)
+	}
+	if err != nil {
+		return domain.ScheduledMessage{}, fmt.Errorf("error while creating scheduled message: %w", err)
+	}
+	return created, nil
+}
+
+func (r ScheduledMessageRepoPostgres) GetScheduledMessage(ctx context.Context, chatId, id int, createdBy string) (domain.ScheduledMessage, error) {
+	var msg domain.ScheduledMessage
+	err := scanScheduled(conn(ctx, r.pool).QueryRow(
+		ctx,
+		"SELECT "+scheduledColumns+" FROM scheduled_messages WHERE id = $1 AND chat_id = $2 AND created_by = $3",
+		id, chatId, createdBy,
+	), &msg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ScheduledMessage{}, domain.NotFound(domain.EntityScheduled, id)
+	}
+	if err != nil {
+		return domain.ScheduledMessage{}, fmt.Errorf("error while getting scheduled message %d: %w", id, err)
+	}
+	return msg, nil
+}
+
+func (r ScheduledMessageRepoPostgres) ListScheduledMessages(ctx context.Context, chatId int, createdBy string) ([]domain.ScheduledMessage, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx,
+		"SELECT "+scheduledColumns+" FROM scheduled_messages WHERE chat_id = $1 AND created_by = $2 ORDER BY send_at, id",
+		chatId, createdBy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing scheduled messages: %w", err)
+	}
+	return collectScheduled(rows)
+}
+
+func (r ScheduledMessageRepoPostgres) UpdateScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) (domain.ScheduledMessage, error) {
+	var updated domain.ScheduledMessage
+	err := scanScheduled(conn(ctx, r.pool).QueryRow(
+		ctx,
+		`UPDATE scheduled_messages
+		SET text = $4, send_at = $5, next_attempt_at = $5, status = 'pending', attempts = 0, last_error = ''
+		WHERE id = $1 AND chat_id = $2 AND created_by = $3 AND `+scheduledUnlocked+`
+		RETURNING `+scheduledColumns,
+		msg.ID, msg.ChatId, msg.CreatedBy, msg.Text, msg.SendAt,
+	), &updated)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ScheduledMessage{}, r.lockedOrMissing(ctx, msg.ChatId, msg.ID, msg.CreatedBy)
+	}
+	if err != nil {
+		return domain.ScheduledMessage{}, fmt.Errorf("error while updating scheduled message %d: %w", msg.ID, err)
+	}
+	return updated, nil
+}
+
+func (r ScheduledMessageRepoPostgres) DeleteScheduledMessage(ctx context.Context, chatId, id int, createdBy string) error {
+	tag, err := conn(ctx, r.pool).Exec(
+		ctx,
+		"DELETE FROM scheduled_messages WHERE id = $1 AND chat_id = $2 AND created_by = $3 AND "+scheduledUnlocked,
+		id, chatId, createdBy,
+	)
+	if err != nil {
+		return fmt.Errorf("error while deleting scheduled message %d: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return r.lockedOrMissing(ctx, chatId, id, createdBy)
+	}
+	return nil
+}
+
+// lockedOrMissing объясняет, почему изменение не затронуло строк: сообщения нет или оно отправляется
+func (r ScheduledMessageRepoPostgres) lockedOrMissing(ctx context.Context, chatId, id int, createdBy string) error {
+	if _, err := r.GetScheduledMessage(ctx, chatId, id, createdBy); err != nil {
+		return err
+	}
+	return domain.Conflict(domain.EntityScheduled, id, storage.ScheduledMessageSendingError)
+}
+
+func (r ScheduledMessageRepoPostgres) ClaimScheduledMessages(ctx context.Context, limit int, lease time.Duration) ([]domain.ScheduledMessage, error) {
+	// SKIP LOCKED позволяет нескольким инстансам разбирать очередь, не дожидаясь друг друга
+	rows, err := conn(ctx, r.pool).Query(
+		ctx,
+		`WITH due AS (
+			SELECT id AS due_id FROM scheduled_messages
+			WHERE status = 'pending' AND next_attempt_at <= now() AND `+scheduledUnlocked+`
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE scheduled_messages s SET locked_until = now() + make_interval(secs => $2)
+		FROM due WHERE s.id = due.due_id
+		RETURNING `+scheduledColumns,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while claiming scheduled messages: %w", err)
+	}
+	messages, err := collectScheduled(rows)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING не сохраняет порядок подзапроса, а сообщения одного чата должны уходить по очереди
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].NextAttemptAt.Equal(messages[j].NextAttemptAt) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].NextAttemptAt.Before(messages[j].NextAttemptAt)
+	})
+	return messages, nil
+}
+
+func (r ScheduledMessageRepoPostgres) CompleteScheduledMessage(ctx context.Context, id int) error {
+	if _, err := conn(ctx, r.pool).Exec(ctx, "DELETE FROM scheduled_messages WHERE id = $1", id); err != nil {
+		return fmt.Errorf("error while completing scheduled message %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r ScheduledMessageRepoPostgres) FailScheduledMessage(ctx context.Context, msg domain.ScheduledMessage) error {
+	_, err := conn(ctx, r.pool).Exec(
+		ctx,
+		`UPDATE scheduled_messages
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, locked_until = NULL
+		WHERE id = $1`,
+		msg.ID, msg.Status, msg.Attempts, msg.NextAttemptAt, msg.LastError,
+	)
+	if err != nil {
+		return fmt.Errorf("error while saving failed attempt of scheduled message %d: %w", msg.ID, err)
+	}
+	return nil
+}
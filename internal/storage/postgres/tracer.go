@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"chat-project/internal/tracing"
+)
+
+// integrityViolationClass класс ошибок Postgres о нарушении ограничений
+const integrityViolationClass = "23"
+
+// sqlKey ключ текста запроса в контексте между TraceQueryStart и TraceQueryEnd
+type sqlKey struct{}
+
+// QueryTracer создает спан на каждый запрос pgx и пишет в лог ошибки запросов
+// вместе с атрибутами запроса из контекста, подключается через ConnConfig.Tracer
+type QueryTracer struct {
+	tracer trace.Tracer
+	log    *slog.Logger
+}
+
+func NewQueryTracer(log *slog.Logger) *QueryTracer {
+	return &QueryTracer{
+		tracer: tracing.Tracer("chat-project/internal/storage/postgres"),
+		log:    log,
+	}
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "postgres.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", data.SQL),
+		),
+	)
+	return context.WithValue(ctx, sqlKey{}, data.SQL)
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	} else {
+		sql, _ := ctx.Value(sqlKey{}).(string)
+		t.log.Log(ctx, queryErrorLevel(data.Err), "postgres query failed", slog.String("sql", sql), slog.Any("error", data.Err))
+	}
+	tracing.End(span, data.Err)
+}
+
+// queryErrorLevel понижает уровень для ожидаемых ошибок: нарушения ограничений
+// репозитории превращают в доменные ошибки, а отмена приходит от клиента
+func queryErrorLevel(err error) slog.Level {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, integrityViolationClass) {
+		return slog.LevelDebug
+	}
+	if errors.Is(err, context.Canceled) {
+		return slog.LevelDebug
+	}
+	return slog.LevelWarn
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"chat-project/config"
+	"chat-project/internal/logger"
+)
+
+func TestQueryTracerLogsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		level string
+	}{
+		{"connection error", errors.New("connection reset by peer"), "WARN"},
+		{"unique violation", &pgconn.PgError{Code: uniqueViolation}, "DEBUG"},
+		{"canceled", context.Canceled, "DEBUG"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log, err := logger.NewWithWriter(config.Log{Level: "debug", Format: logger.FormatText}, &buf)
+			if err != nil {
+				t.Fatalf("logger: %v", err)
+			}
+			tracer := NewQueryTracer(log)
+
+			ctx := logger.WithAttrs(context.Background(), slog.String("request_id", "req-1"))
+			ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: tt.err})
+
+			out := buf.String()
+			for _, want := range []string{"level=" + tt.level, `sql="SELECT 1"`, "request_id=req-1"} {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected %s in log: %s", want, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// txKey ключ транзакции TxManagerPostgres в контексте
+type txKey struct{}
+
+// querier общие методы пула и транзакции
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// conn возвращает транзакцию из контекста, если репозиторий вызван внутри WithinTx, иначе пул.
+// Собственные транзакции репозиториев внутри WithinTx становятся точками сохранения
+func conn(ctx context.Context, pool *pgxpool.Pool) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
+
+// rollback откатывает транзакцию в defer и пишет в лог ошибку отката с атрибутами запроса.
+// После Commit откат возвращает pgx.ErrTxClosed, это не ошибка
+func rollback(ctx context.Context, tx pgx.Tx, log *slog.Logger) {
+	if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		log.ErrorContext(ctx, "error while rolling back transaction", slog.Any("error", err))
+	}
+}
+
+type TxManagerPostgres struct {
+	pool *pgxpool.Pool
+	log  *slog.Logger
+}
+
+func NewTxManagerPostgres(pgpool *pgxpool.Pool, log *slog.Logger) *TxManagerPostgres {
+	return &TxManagerPostgres{
+		pool: pgpool,
+		log:  log,
+	}
+}
+
+func (m TxManagerPostgres) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer rollback(ctx, tx, m.log)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error while committing transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+type WebhookRepoPostgres struct {
+	pool *pgxpool.Pool
+	log  *slog.Logger
+}
+
+func NewWebhookRepoPostgres(pgpool *pgxpool.Pool, log *slog.Logger) *WebhookRepoPostgres {
+	return &WebhookRepoPostgres{
+		pool: pgpool,
+		log:  log,
+	}
+}
+
+const webhookColumns = "id, chat_id, url, secret, events, created_by, created_at"
+
+func scanWebhook(row pgx.Row, webhook *domain.Webhook) error {
+	var events []string
+	err := row.Scan(&webhook.ID, &webhook.ChatId, &webhook.URL, &webhook.Secret, &events, &webhook.CreatedBy, &webhook.CreatedAt)
+	webhook.Events = toEventTypes(events)
+	return err
+}
+
+// Колонки доставки в порядке, ожидаемом scanDelivery
+const deliveryColumns = `id, coalesce(webhook_id, 0), chat_id, url, secret, event_type, payload, status, attempts,
+	next_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+func scanDelivery(row pgx.Row, d *domain.WebhookDelivery) error {
+	return row.Scan(
+		&d.ID, &d.WebhookId, &d.ChatId, &d.URL, &d.Secret, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+	)
+}
+
+func collectDeliveries(rows pgx.Rows) ([]domain.WebhookDelivery, error) {
+	defer rows.Close()
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("error while scanning delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func toEventTypes(events []string) []domain.EventType {
+	types := make([]domain.EventType, 0, len(events))
+	for _, e := range events {
+		types = append(types, domain.EventType(e))
+	}
+	return types
+}
+
+func fromEventTypes(types []domain.EventType) []string {
+	events := make([]string, 0, len(types))
+	for _, t := range types {
+		events = append(events, string(t))
+	}
+	return events
+}
+
+func (r WebhookRepoPostgres) CreateWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	err := conn(ctx, r.pool).QueryRow(
+		ctx,
+		`INSERT INTO chat_webhooks (chat_id, url, secret, events, created_by)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		webhook.ChatId, webhook.URL, webhook.Secret, fromEventTypes(webhook.Events), webhook.CreatedBy,
+	).Scan(&webhook.ID, &webhook.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return domain.Webhook{}, domain.NotFound(domain.EntityChat, webhook.ChatId)
+	}
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("error while creating webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (r WebhookRepoPostgres) ListWebhooks(ctx context.Context, chatId int) ([]domain.Webhook, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx, "SELECT "+webhookColumns+" FROM chat_webhooks WHERE chat_id = $1 ORDER BY id", chatId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]domain.Webhook, 0)
+	for rows.Next() {
+		var webhook domain.Webhook
+		if err := scanWebhook(rows, &webhook); err != nil {
+			return nil, fmt.Errorf("error while scanning webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while listing webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (r WebhookRepoPostgres) DeleteWebhook(ctx context.Context, chatId, webhookId int) error {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer rollback(ctx, tx, r.log)
+
+	// Ожидающие доставки удаляются первыми: после удаления вебхука их webhook_id обнулится
+	_, err = tx.Exec(
+		ctx,
+		`DELETE FROM webhook_deliveries d USING chat_webhooks w
+		WHERE d.webhook_id = w.id AND w.id = $1 AND w.chat_id = $2 AND d.status = 'pending'`,
+		webhookId, chatId,
+	)
+	if err != nil {
+		return fmt.Errorf("error while deleting pending deliveries: %w", err)
+	}
+	tag, err := tx.Exec(ctx, "DELETE FROM chat_webhooks WHERE id = $1 AND chat_id = $2", webhookId, chatId)
+	if err != nil {
+		return fmt.Errorf("error while deleting webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.NotFound(domain.EntityWebhook, webhookId)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error while committing webhook deletion: %w", err)
+	}
+	return nil
+}
+
+func (r WebhookRepoPostgres) EnqueueEvent(ctx context.Context, event domain.Event, payload []byte) (int, error) {
+	tag, err := conn(ctx, r.pool).Exec(
+		ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, chat_id, url, secret, event_type, payload)
+		SELECT id, chat_id, url, secret, $2::text, $3 FROM chat_webhooks
+		WHERE chat_id = $1 AND (cardinality(events) = 0 OR $2::text = ANY(events))`,
+		event.ChatId, string(event.Type), payload,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error while enqueuing %s deliveries: %w", event.Type, err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (r WebhookRepoPostgres) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	// SKIP LOCKED позволяет нескольким инстансам разбирать очередь, не дожидаясь друг друга
+	rows, err := conn(ctx, r.pool).Query(
+		ctx,
+		`WITH due AS (
+			SELECT id AS due_id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM due WHERE d.id = due.due_id
+		RETURNING `+deliveryColumns,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while claiming deliveries: %w", err)
+	}
+	return collectDeliveries(rows)
+}
+
+func (r WebhookRepoPostgres) UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	_, err := conn(ctx, r.pool).Exec(
+		ctx,
+		`UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+		WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error while updating delivery %d: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (r WebhookRepoPostgres) ListDeliveries(ctx context.Context, chatId, webhookId int, status domain.DeliveryStatus, limit, offset int) ([]domain.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.pool).Query(
+		ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = $1 AND chat_id = $2 AND ($3::text = '' OR status = $3::text)
+		ORDER BY id DESC LIMIT $4 OFFSET $5`,
+		webhookId, chatId, string(status), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing deliveries: %w", err)
+	}
+	return collectDeliveries(rows)
+}
+
+func (r WebhookRepoPostgres) RetryDelivery(ctx context.Context, chatId, webhookId, deliveryId int) (domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	err := scanDelivery(conn(ctx, r.pool).QueryRow(
+		ctx,
+		`UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = now()
+		WHERE id = $1 AND webhook_id = $2 AND chat_id = $3 AND status = 'dead'
+		RETURNING `+deliveryColumns,
+		deliveryId, webhookId, chatId,
+	), &d)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.WebhookDelivery{}, r.notRetryableReason(ctx, chatId, webhookId, deliveryId)
+	}
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("error while retrying delivery %d: %w", deliveryId, err)
+	}
+	return d, nil
+}
+
+// notRetryableReason отличает несуществующую доставку от доставки не в статусе dead
+func (r WebhookRepoPostgres) notRetryableReason(ctx context.Context, chatId, webhookId, deliveryId int) error {
+	var id int
+	err := conn(ctx, r.pool).QueryRow(
+		ctx,
+		"SELECT id FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2 AND chat_id = $3",
+		deliveryId, webhookId, chatId,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.NotFound(domain.EntityDelivery, deliveryId)
+	}
+	if err != nil {
+		return fmt.Errorf("error while getting delivery %d: %w", deliveryId, err)
+	}
+	return domain.Conflict(domain.EntityDelivery, deliveryId, storage.DeliveryNotDeadError)
+}
+
+func (r WebhookRepoPostgres) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := conn(ctx, r.pool).Exec(
+		ctx, "DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1", before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error while purging deliveries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
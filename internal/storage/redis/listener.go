@@ -2,55 +2,104 @@ package redis
 
 import (
 	"chat-project/internal/domain"
+	"chat-project/internal/tracing"
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const _receiveRetryDelay = time.Second
+
+// Версия формата событий в канале. Старые инстансы публикуют domain.Message без версии,
+// такие сообщения принимаются как message.created, пока при обновлении работают обе версии
+const _eventVersion = 1
+
+// envelope событие в канале redis
+type envelope struct {
+	Version int `json:"v"`
+	domain.Event
+}
+
 type ListenerRedis struct {
 	client *redis.Client
+	log    *slog.Logger
+	tracer trace.Tracer
 }
 
-func NewListener(client *redis.Client) ListenerRedis {
+func NewListener(client *redis.Client, log *slog.Logger) ListenerRedis {
 	return ListenerRedis{
 		client: client,
+		log:    log,
+		tracer: tracing.Tracer("chat-project/internal/storage/redis"),
 	}
 }
 
-func (l ListenerRedis) Subscribe(ctx context.Context, chatId int) <-chan domain.Message {
+func (l ListenerRedis) Subscribe(ctx context.Context, chatId int) <-chan domain.Event {
 	chatStr := fmt.Sprintf("%d", chatId)
 	pubsub := l.client.Subscribe(ctx, chatStr)
 
-	ch := make(chan domain.Message)
+	ch := make(chan domain.Event)
 
 	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+
 		for {
 			newMsg, err := pubsub.ReceiveMessage(ctx)
 			if err != nil {
-				fmt.Printf("Error while receiving message from channel %d: %v", chatId, err)
+				if ctx.Err() != nil {
+					return
+				}
+				l.log.ErrorContext(ctx, "error while receiving message from channel", slog.Int("chat_id", chatId), slog.Any("error", err))
+				// Не крутить цикл, пока redis недоступен
+				select {
+				case <-time.After(_receiveRetryDelay):
+				case <-ctx.Done():
+					return
+				}
 				continue
 			}
 
-			var msg domain.Message
-			err = json.Unmarshal([]byte(newMsg.Payload), &msg)
+			event, err := decodeEvent([]byte(newMsg.Payload))
 			if err != nil {
-				fmt.Printf("Error while unmarshall msg %v from channel %d", newMsg.Payload, chatId)
+				// Содержимое сообщений в лог не пишется, только размер
+				l.log.WarnContext(
+					ctx, "error while unmarshalling event from channel",
+					slog.Int("chat_id", chatId), slog.Int("payload_bytes", len(newMsg.Payload)), slog.Any("error", err),
+				)
 				continue
 			}
 
-			ch <- msg
-
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
 	return ch
 }
 
-func (l ListenerRedis) Publish(ctx context.Context, chatId int, message domain.Message) error {
+func (l ListenerRedis) Publish(ctx context.Context, chatId int, event domain.Event) (err error) {
 	chatStr := fmt.Sprintf("%d", chatId)
-	decodedMsg, err := json.Marshal(message)
+	ctx, span := l.tracer.Start(ctx, "redis.publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", chatStr),
+			attribute.String("chat.event", string(event.Type)),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	decodedMsg, err := json.Marshal(envelope{Version: _eventVersion, Event: event})
 	if err != nil {
 		return err
 	}
@@ -62,3 +111,23 @@ func (l ListenerRedis) Publish(ctx context.Context, chatId int, message domain.M
 
 	return nil
 }
+
+// decodeEvent разбирает событие из канала. Сообщение без версии и типа события считается
+// domain.Message, которое публиковали инстансы до появления событий
+func decodeEvent(payload []byte) (domain.Event, error) {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return domain.Event{}, err
+	}
+	if env.Version > _eventVersion {
+		return domain.Event{}, fmt.Errorf("unsupported event version %d", env.Version)
+	}
+	if env.Version == 0 && env.Type == "" {
+		var message domain.Message
+		if err := json.Unmarshal(payload, &message); err != nil {
+			return domain.Event{}, err
+		}
+		return domain.NewMessageCreatedEvent(message), nil
+	}
+	return env.Event, nil
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	message := domain.Message{ID: 7, ChatId: 3, Kind: domain.MessageKindUser, Text: "hi", AuthorId: "alice", CreatedAt: time.Now().UTC()}
+
+	current, err := json.Marshal(envelope{Version: _eventVersion, Event: domain.NewMessageCreatedEvent(message)})
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+	// Так публиковали инстансы до появления событий
+	legacy, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("marshal message: %v", err)
+	}
+	unversioned, err := json.Marshal(domain.NewMessageCreatedEvent(message))
+	if err != nil {
+		t.Fatalf("marshal unversioned event: %v", err)
+	}
+
+	for name, payload := range map[string][]byte{"current": current, "legacy": legacy, "unversioned": unversioned} {
+		event, err := decodeEvent(payload)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+		if event.Type != domain.EventMessageCreated || event.ChatId != 3 || event.Message == nil ||
+			event.Message.ID != 7 || event.Message.Text != "hi" || event.Message.AuthorId != "alice" {
+			t.Fatalf("%s: unexpected event %+v", name, event)
+		}
+	}
+
+	deleted, err := json.Marshal(envelope{Version: _eventVersion, Event: domain.Event{Type: domain.EventChatDeleted, ChatId: 3}})
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+	if event, err := decodeEvent(deleted); err != nil || event.Type != domain.EventChatDeleted || event.Message != nil {
+		t.Fatalf("unexpected chat.deleted event: %+v, %v", event, err)
+	}
+
+	if _, err := decodeEvent([]byte(`{"v":2,"type":"message.created"}`)); err == nil {
+		t.Fatal("expected error for unsupported version")
+	}
+	if _, err := decodeEvent([]byte(`not json`)); err == nil {
+		t.Fatal("expected error for malformed payload")
+	}
+}
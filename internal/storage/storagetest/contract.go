@@ -0,0 +1,473 @@
+// Package storagetest контрактные тесты хранилищ. Каждая реализация storage запускает их,
+// чтобы все бэкенды возвращали одинаковые данные и одинаковые доменные ошибки.
+package storagetest
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+// Factory создает пустые репозитории для одного теста
+type Factory func(t *testing.T) (storage.ChatRepo, storage.InviteRepo)
+
+// Run проверяет контракт ChatRepo и InviteRepo
+func Run(t *testing.T, newRepos Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, chats storage.ChatRepo, invites storage.InviteRepo)
+	}{
+		{"MissingChat", testMissingChat},
+		{"ChatOwner", testChatOwner},
+		{"MissingMember", testMissingMember},
+		{"VersionConflict", testVersionConflict},
+		{"ChatWithMessages", testChatWithMessages},
+		{"MessagesAfter", testMessagesAfter},
+		{"ClientNonce", testClientNonce},
+		{"ScheduledID", testScheduledID},
+		{"MissingInvite", testMissingInvite},
+		{"UnavailableInvite", testUnavailableInvite},
+		{"ParallelInviteAccepts", testParallelInviteAccepts},
+		{"ParallelDirectChats", testParallelDirectChats},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats, invites := newRepos(t)
+			tt.run(t, chats, invites)
+		})
+	}
+}
+
+func createChat(t *testing.T, repo storage.ChatRepo) domain.Chat {
+	t.Helper()
+	chat, err := repo.CreateChat(context.Background(), domain.Chat{Title: "contract", CreatedAt: time.Now()}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	return chat
+}
+
+func addMessage(t *testing.T, repo storage.ChatRepo, chatId int, text string) domain.Message {
+	t.Helper()
+	msg, err := repo.AddMessage(context.Background(), domain.Message{
+		ChatId:    chatId,
+		Kind:      domain.MessageKindUser,
+		Text:      text,
+		CreatedAt: time.Now(),
+	}, chatId)
+	if err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	return msg
+}
+
+// requireNotFound проверяет класс ошибки, сущность и идентификатор
+func requireNotFound(t *testing.T, op string, err error, entity domain.Entity, id string) {
+	t.Helper()
+	var domainErr *domain.Error
+	if !errors.Is(err, domain.NotFoundError) || !errors.As(err, &domainErr) {
+		t.Fatalf("%s: expected not found error, got %v", op, err)
+	}
+	if domainErr.Entity != entity || domainErr.ID != id {
+		t.Fatalf("%s: expected %s %q not found, got %s %q", op, entity, id, domainErr.Entity, domainErr.ID)
+	}
+}
+
+func testChatOwner(t *testing.T, chats storage.ChatRepo, invites storage.InviteRepo) {
+	ctx := context.Background()
+	chat, err := chats.CreateChat(ctx, domain.Chat{Title: "owned", Private: true, CreatedAt: time.Now()}, "alice")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	member, err := chats.GetMember(ctx, chat.ID, "alice")
+	if err != nil {
+		t.Fatalf("get owner: %v", err)
+	}
+	if member.Role != domain.ChatRoleOwner {
+		t.Fatalf("expected owner role, got %q", member.Role)
+	}
+	if managed, err := chats.HasManager(ctx, chat.ID); err != nil || !managed {
+		t.Fatalf("expected owned chat to have a manager, got %v, %v", managed, err)
+	}
+
+	// У анонимного чата нет управляющих, обычные участники их не добавляют
+	anonymous := createChat(t, chats)
+	if err := chats.AddMember(ctx, domain.ChatMember{ChatId: anonymous.ID, UserId: "bob", Role: domain.ChatRoleMember}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if managed, err := chats.HasManager(ctx, anonymous.ID); err != nil || managed {
+		t.Fatalf("expected anonymous chat without managers, got %v, %v", managed, err)
+	}
+}
+
+func testMissingChat(t *testing.T, chats storage.ChatRepo, invites storage.InviteRepo) {
+	ctx := context.Background()
+	// id удаленного чата гарантированно не занят ни в одном бэкенде
+	chat := createChat(t, chats)
+	if err := chats.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+	id := strconv.Itoa(chat.ID)
+
+	_, err := chats.GetChatByID(ctx, chat.ID)
+	requireNotFound(t, "GetChatByID", err, domain.EntityChat, id)
+
+	_, err = chats.GetWithMessages(ctx, chat.ID)
+	requireNotFound(t, "GetWithMessages", err, domain.EntityChat, id)
+
+	_, err = chats.AddMessage(ctx, domain.Message{ChatId: chat.ID, Text: "lost", CreatedAt: time.Now()}, chat.ID)
+	requireNotFound(t, "AddMessage", err, domain.EntityChat, id)
+
+	_, err = chats.GetMessagesAfter(ctx, chat.ID, 0, 10)
+	requireNotFound(t, "GetMessagesAfter", err, domain.EntityChat, id)
+
+	_, err = chats.UpdateChat(ctx, chat)
+	requireNotFound(t, "UpdateChat", err, domain.EntityChat, id)
+
+	err = chats.DeleteChat(ctx, chat.ID)
+	requireNotFound(t, "DeleteChat", err, domain.EntityChat, id)
+
+	err = chats.AddMember(ctx, domain.ChatMember{ChatId: chat.ID, UserId: "alice", Role: domain.ChatRoleMember})
+	requireNotFound(t, "AddMember", err, domain.EntityChat, id)
+
+	_, err = invites.CreateInvite(ctx, domain.Invite{
+		ChatId:    chat.ID,
+		TokenHash: tokenHash("missing"),
+		Role:      domain.ChatRoleMember,
+		CreatedBy: "owner",
+	})
+	requireNotFound(t, "CreateInvite", err, domain.EntityChat, id)
+}
+
+func testMissingMember(t *testing.T, chats storage.ChatRepo, _ storage.InviteRepo) {
+	chat := createChat(t, chats)
+
+	_, err := chats.GetMember(context.Background(), chat.ID, "nobody")
+	requireNotFound(t, "GetMember", err, domain.EntityMember, "nobody")
+}
+
+func testVersionConflict(t *testing.T, chats storage.ChatRepo, _ storage.InviteRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+
+	updated, err := chats.UpdateChat(ctx, chat)
+	if err != nil {
+		t.Fatalf("update chat: %v", err)
+	}
+	if updated.Version != chat.Version+1 {
+		t.Fatalf("expected version %d, got %d", chat.Version+1, updated.Version)
+	}
+
+	// Повторное обновление со старой версией
+	_, err = chats.UpdateChat(ctx, chat)
+	if !errors.Is(err, domain.ConflictError) || !errors.Is(err, storage.ChatVersionConflictError) {
+		t.Fatalf("expected version conflict, got %v", err)
+	}
+}
+
+func testChatWithMessages(t *testing.T, chats storage.ChatRepo, _ storage.InviteRepo) {
+	chat := createChat(t, chats)
+	sent := addMessage(t, chats, chat.ID, "hello")
+
+	got, err := chats.GetWithMessages(context.Background(), chat.ID)
+	if err != nil {
+		t.Fatalf("get chat with messages: %v", err)
+	}
+	if len(got.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(got.Messages))
+	}
+	msg := got.Messages[0]
+	if msg.ID != sent.ID || msg.ChatId != chat.ID || msg.Text != "hello" || msg.Kind != domain.MessageKindUser {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if msg.CreatedAt.IsZero() {
+		t.Fatal("message created at is not set")
+	}
+}
+
+func testMessagesAfter(t *testing.T, chats storage.ChatRepo, _ storage.InviteRepo) {
+	chat := createChat(t, chats)
+	first := addMessage(t, chats, chat.ID, "one")
+	addMessage(t, chats, chat.ID, "two")
+	addMessage(t, chats, chat.ID, "three")
+
+	messages, err := chats.GetMessagesAfter(context.Background(), chat.ID, first.ID, 10)
+	if err != nil {
+		t.Fatalf("get messages after: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Text != "two" || messages[1].Text != "three" {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+
+	messages, err = chats.GetMessagesAfter(context.Background(), chat.ID, first.ID, 1)
+	if err != nil {
+		t.Fatalf("get messages after with limit: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Text != "two" {
+		t.Fatalf("unexpected messages with limit: %+v", messages)
+	}
+
+	// Сообщения afterId в чате нет, например оно удалено: возвращается все, что добавлено после него
+	messages, err = chats.GetMessagesAfter(context.Background(), chat.ID, first.ID-1, 10)
+	if err != nil {
+		t.Fatalf("get messages after missing message: %v", err)
+	}
+	if len(messages) != 3 || messages[0].ID != first.ID || messages[2].Text != "three" {
+		t.Fatalf("unexpected messages after missing message: %+v", messages)
+	}
+}
+
+func testClientNonce(t *testing.T, chats storage.ChatRepo, _ storage.InviteRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	msg := domain.Message{
+		ChatId:      chat.ID,
+		Kind:        domain.MessageKindUser,
+		Text:        "draft",
+		AuthorId:    "alice",
+		ClientNonce: "n-1",
+		CreatedAt:   time.Now(),
+	}
+
+	sent, err := chats.AddMessage(ctx, msg, chat.ID)
+	if err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	if sent.AuthorId != "alice" || sent.ClientNonce != "n-1" {
+		t.Fatalf("author or nonce not saved: %+v", sent)
+	}
+
+	_, err = chats.AddMessage(ctx, msg, chat.ID)
+	if !errors.Is(err, domain.ConflictError) || !errors.Is(err, storage.MessageNonceConflictError) {
+		t.Fatalf("expected nonce conflict, got %v", err)
+	}
+
+	// Nonce уникален только для автора
+	other := msg
+	other.AuthorId = "bob"
+	if _, err := chats.AddMessage(ctx, other, chat.ID); err != nil {
+		t.Fatalf("same nonce from another author: %v", err)
+	}
+
+	// Ответы бота на команды разных пользователей не конфликтуют
+	reply := msg
+	reply.Kind = domain.MessageKindBot
+	reply.AuthorId = "roll"
+	reply.InvokedBy = "alice"
+	if _, err := chats.AddMessage(ctx, reply, chat.ID); err != nil {
+		t.Fatalf("bot reply with nonce: %v", err)
+	}
+	reply.InvokedBy = "bob"
+	if _, err := chats.AddMessage(ctx, reply, chat.ID); err != nil {
+		t.Fatalf("same nonce invoked by another user: %v", err)
+	}
+	if _, err := chats.AddMessage(ctx, reply, chat.ID); !errors.Is(err, storage.MessageNonceConflictError) {
+		t.Fatalf("expected nonce conflict for the same invoker, got %v", err)
+	}
+	gotReply, err := chats.GetMessageByNonce(ctx, chat.ID, "roll", "bob", "n-1")
+	if err != nil || gotReply.InvokedBy != "bob" || gotReply.Kind != domain.MessageKindBot {
+		t.Fatalf("unexpected bot reply by nonce: %+v, %v", gotReply, err)
+	}
+
+	got, err := chats.GetMessageByNonce(ctx, chat.ID, "alice", "", "n-1")
+	if err != nil {
+		t.Fatalf("get message by nonce: %v", err)
+	}
+	if got.ID != sent.ID || got.Text != "draft" {
+		t.Fatalf("unexpected message by nonce: %+v", got)
+	}
+
+	_, err = chats.GetMessageByNonce(ctx, chat.ID, "alice", "", "n-2")
+	requireNotFound(t, "GetMessageByNonce", err, domain.EntityMessage, "n-2")
+
+	// Без nonce сообщения не конфликтуют
+	addMessage(t, chats, chat.ID, "plain")
+	addMessage(t, chats, chat.ID, "plain")
+}
+
+func testScheduledID(t *testing.T, chats storage.ChatRepo, _ storage.InviteRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	scheduledId := 42
+	msg := domain.Message{ChatId: chat.ID, Kind: domain.MessageKindUser, Text: "later", AuthorId: "alice", ScheduledId: &scheduledId}
+
+	_, err := chats.GetMessageByScheduledID(ctx, chat.ID, scheduledId)
+	requireNotFound(t, "GetMessageByScheduledID", err, domain.EntityMessage, "42")
+
+	sent, err := chats.AddMessage(ctx, msg, chat.ID)
+	if err != nil {
+		t.Fatalf("add scheduled message: %v", err)
+	}
+	_, err = chats.AddMessage(ctx, msg, chat.ID)
+	if !errors.Is(err, domain.ConflictError) || !errors.Is(err, storage.ScheduledMessageSentError) {
+		t.Fatalf("expected scheduled message conflict, got %v", err)
+	}
+
+	got, err := chats.GetMessageByScheduledID(ctx, chat.ID, scheduledId)
+	if err != nil || got.ID != sent.ID || got.ScheduledId == nil || *got.ScheduledId != scheduledId {
+		t.Fatalf("unexpected message by scheduled id: %+v, %v", got, err)
+	}
+
+	// Пользовательский nonce не пересекается с запланированными сообщениями
+	forged := domain.Message{ChatId: chat.ID, Kind: domain.MessageKindUser, Text: "later", AuthorId: "alice", ClientNonce: "scheduled-43"}
+	if _, err := chats.AddMessage(ctx, forged, chat.ID); err != nil {
+		t.Fatalf("add message with scheduled-like nonce: %v", err)
+	}
+	_, err = chats.GetMessageByScheduledID(ctx, chat.ID, 43)
+	requireNotFound(t, "GetMessageByScheduledID", err, domain.EntityMessage, "43")
+}
+
+func testMissingInvite(t *testing.T, chats storage.ChatRepo, invites storage.InviteRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+
+	err := invites.RevokeInvite(ctx, chat.ID, 404)
+	requireNotFound(t, "RevokeInvite", err, domain.EntityInvite, "404")
+
+	_, _, err = invites.AcceptInvite(ctx, tokenHash("unknown"), "alice")
+	requireNotFound(t, "AcceptInvite", err, domain.EntityInvite, "")
+}
+
+func testUnavailableInvite(t *testing.T, chats storage.ChatRepo, invites storage.InviteRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+
+	invite, err := invites.CreateInvite(ctx, domain.Invite{
+		ChatId:    chat.ID,
+		TokenHash: tokenHash("revoked"),
+		Role:      domain.ChatRoleMember,
+		CreatedBy: "owner",
+	})
+	if err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+	if err := invites.RevokeInvite(ctx, chat.ID, invite.ID); err != nil {
+		t.Fatalf("revoke invite: %v", err)
+	}
+
+	_, _, err = invites.AcceptInvite(ctx, tokenHash("revoked"), "alice")
+	if !errors.Is(err, domain.ConflictError) || !errors.Is(err, storage.InviteUnavailableError) {
+		t.Fatalf("expected unavailable invite conflict, got %v", err)
+	}
+}
+
+// testParallelInviteAccepts параллельные принятия не расходуют приглашение больше MaxUses раз
+func testParallelInviteAccepts(t *testing.T, chats storage.ChatRepo, invites storage.InviteRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+
+	const maxUses, users = 3, 20
+	invite, err := invites.CreateInvite(ctx, domain.Invite{
+		ChatId:    chat.ID,
+		TokenHash: tokenHash("parallel"),
+		Role:      domain.ChatRoleMember,
+		MaxUses:   maxUses,
+		CreatedBy: "owner",
+	})
+	if err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, users)
+	for i := range users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, errs[i] = invites.AcceptInvite(ctx, tokenHash("parallel"), "user-"+strconv.Itoa(i))
+		}()
+	}
+	wg.Wait()
+
+	accepted := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			accepted++
+		case !errors.Is(err, storage.InviteUnavailableError):
+			t.Fatalf("user-%d: expected unavailable invite, got %v", i, err)
+		}
+	}
+	if accepted != maxUses {
+		t.Fatalf("expected %d accepts, got %d", maxUses, accepted)
+	}
+
+	listed, err := invites.ListInvites(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("list invites: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != invite.ID || listed[0].Uses != maxUses {
+		t.Fatalf("expected invite used %d times, got %+v", maxUses, listed)
+	}
+
+	members := 0
+	for i := range users {
+		_, err := chats.GetMember(ctx, chat.ID, "user-"+strconv.Itoa(i))
+		if err == nil {
+			members++
+		} else if !domain.IsNotFound(err, domain.EntityMember) {
+			t.Fatalf("get member: %v", err)
+		}
+	}
+	if members != maxUses {
+		t.Fatalf("expected %d members, got %d", maxUses, members)
+	}
+}
+
+func tokenHash(code string) []byte {
+	sum := sha256.Sum256([]byte(code))
+	return sum[:]
+}
+
+// testParallelDirectChats параллельные запросы обоих собеседников создают один личный чат
+func testParallelDirectChats(t *testing.T, chats storage.ChatRepo, invites storage.InviteRepo) {
+	ctx := context.Background()
+
+	const requests = 20
+	var wg sync.WaitGroup
+	results := make([]domain.Chat, requests)
+	created := make([]bool, requests)
+	errs := make([]error, requests)
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			userA, userB := "alice", "bob"
+			if i%2 == 1 {
+				userA, userB = userB, userA
+			}
+			results[i], created[i], errs[i] = chats.GetOrCreateDirectChat(ctx, userA, userB)
+		}()
+	}
+	wg.Wait()
+
+	creations := 0
+	for i := range requests {
+		if errs[i] != nil {
+			t.Fatalf("request %d: %v", i, errs[i])
+		}
+		if results[i].ID != results[0].ID || results[i].Type != domain.ChatTypeDirect {
+			t.Fatalf("request %d: expected direct chat %d, got %+v", i, results[0].ID, results[i])
+		}
+		if created[i] {
+			creations++
+		}
+	}
+	if creations != 1 {
+		t.Fatalf("expected one request to create the chat, got %d", creations)
+	}
+
+	for _, userId := range []string{"alice", "bob"} {
+		member, err := chats.GetMember(ctx, results[0].ID, userId)
+		if err != nil || member.Role != domain.ChatRoleMember {
+			t.Fatalf("expected %s to be a member, got %+v, %v", userId, member, err)
+		}
+	}
+}
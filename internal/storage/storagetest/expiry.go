@@ -0,0 +1,141 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+// ExpiryFactory создает пустые репозитории для одного теста самоуничтожающихся сообщений.
+// ChatRepo должен скрывать сообщения по часам now
+type ExpiryFactory func(t *testing.T, now func() time.Time) (storage.ChatRepo, storage.RetentionRepo, storage.ExpiredMessageRepo)
+
+// RunExpiry проверяет скрытие и удаление сообщений с истекшим TTL
+func RunExpiry(t *testing.T, newRepos ExpiryFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, clock *testClock, chats storage.ChatRepo, retention storage.RetentionRepo, expired storage.ExpiredMessageRepo)
+	}{
+		{"HiddenAfterExpiry", testHiddenAfterExpiry},
+		{"DeleteExpired", testDeleteExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Время в базе хранится с точностью до микросекунд
+			clock := &testClock{now: time.Now().Truncate(time.Millisecond)}
+			chats, retention, expired := newRepos(t, clock.Now)
+			tt.run(t, clock, chats, retention, expired)
+		})
+	}
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func addExpiringMessage(t *testing.T, repo storage.ChatRepo, chatId int, text string, expiresAt time.Time) domain.Message {
+	t.Helper()
+	msg, err := repo.AddMessage(context.Background(), domain.Message{
+		ChatId:    chatId,
+		Kind:      domain.MessageKindUser,
+		Text:      text,
+		CreatedAt: time.Now(),
+		ExpiresAt: &expiresAt,
+	}, chatId)
+	if err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	return msg
+}
+
+func testHiddenAfterExpiry(t *testing.T, clock *testClock, chats storage.ChatRepo, _ storage.RetentionRepo, expired storage.ExpiredMessageRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	first := addMessage(t, chats, chat.ID, "plain")
+	expiresAt := clock.now.Add(time.Minute)
+	secret := addExpiringMessage(t, chats, chat.ID, "secret", expiresAt)
+	addMessage(t, chats, chat.ID, "after")
+
+	got, err := chats.GetWithMessages(ctx, chat.ID)
+	if err != nil || len(got.Messages) != 3 {
+		t.Fatalf("messages before expiry: %+v, %v", got.Messages, err)
+	}
+	for _, msg := range got.Messages {
+		if msg.ID == secret.ID && (msg.ExpiresAt == nil || !msg.ExpiresAt.Equal(expiresAt)) {
+			t.Fatalf("expiresAt not stored: %+v", msg)
+		}
+	}
+	if deleted, err := expired.DeleteExpiredMessages(ctx, clock.now, 10); err != nil || len(deleted) != 0 {
+		t.Fatalf("deleted before expiry: %+v, %v", deleted, err)
+	}
+
+	// Истекшее сообщение скрыто еще до удаления
+	clock.now = expiresAt
+	got, err = chats.GetWithMessages(ctx, chat.ID)
+	if err != nil || len(got.Messages) != 2 {
+		t.Fatalf("messages after expiry: %+v, %v", got.Messages, err)
+	}
+	for _, msg := range got.Messages {
+		if msg.ID == secret.ID {
+			t.Fatalf("expired message returned: %+v", msg)
+		}
+	}
+	after, err := chats.GetMessagesAfter(ctx, chat.ID, first.ID, 10)
+	if err != nil || len(after) != 1 || after[0].Text != "after" {
+		t.Fatalf("messages after first: %+v, %v", after, err)
+	}
+}
+
+func testDeleteExpired(t *testing.T, clock *testClock, chats storage.ChatRepo, retention storage.RetentionRepo, expired storage.ExpiredMessageRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	other := createChat(t, chats)
+	held := createChat(t, chats)
+	want := make(map[int]int)
+	for i, chatId := range []int{chat.ID, chat.ID, other.ID} {
+		msg := addExpiringMessage(t, chats, chatId, "secret", clock.now.Add(time.Duration(i+1)*time.Second))
+		want[msg.ID] = chatId
+	}
+	addExpiringMessage(t, chats, chat.ID, "later", clock.now.Add(time.Hour))
+	addExpiringMessage(t, chats, held.ID, "evidence", clock.now.Add(time.Second))
+	addMessage(t, chats, chat.ID, "plain")
+	if _, err := retention.SetLegalHold(ctx, held.ID, true); err != nil {
+		t.Fatalf("set legal hold: %v", err)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	deleted := make([]domain.Message, 0, 3)
+	for _, batch := range []int{2, 1, 0} {
+		msgs, err := expired.DeleteExpiredMessages(ctx, clock.now, 2)
+		if err != nil {
+			t.Fatalf("delete expired: %v", err)
+		}
+		if len(msgs) != batch {
+			t.Fatalf("deleted %d messages, want %d", len(msgs), batch)
+		}
+		deleted = append(deleted, msgs...)
+	}
+	for _, msg := range deleted {
+		if want[msg.ID] != msg.ChatId {
+			t.Fatalf("deleted unexpected message %+v", msg)
+		}
+	}
+
+	// Удаленные сообщения не возвращаются даже по старым часам
+	clock.now = clock.now.Add(-time.Hour)
+	got, err := chats.GetWithMessages(ctx, chat.ID)
+	if err != nil || len(got.Messages) != 2 {
+		t.Fatalf("messages left: %+v, %v", got.Messages, err)
+	}
+	got, err = chats.GetWithMessages(ctx, held.ID)
+	if err != nil || len(got.Messages) != 1 {
+		t.Fatalf("held chat must keep expired messages: %+v, %v", got.Messages, err)
+	}
+}
@@ -0,0 +1,117 @@
+package storagetest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+// IncomingWebhookFactory создает пустые репозитории для одного теста входящих вебхуков
+type IncomingWebhookFactory func(t *testing.T) (storage.ChatRepo, storage.IncomingWebhookRepo)
+
+// RunIncomingWebhooks проверяет контракт IncomingWebhookRepo
+func RunIncomingWebhooks(t *testing.T, newRepos IncomingWebhookFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, chats storage.ChatRepo, hooks storage.IncomingWebhookRepo)
+	}{
+		{"MissingIncomingWebhook", testMissingIncomingWebhook},
+		{"IncomingWebhookByToken", testIncomingWebhookByToken},
+		{"IncomingWebhookChatDeleted", testIncomingWebhookChatDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats, hooks := newRepos(t)
+			tt.run(t, chats, hooks)
+		})
+	}
+}
+
+func createIncomingWebhook(t *testing.T, repo storage.IncomingWebhookRepo, chatId int, token string) domain.IncomingWebhook {
+	t.Helper()
+	hook, err := repo.CreateIncomingWebhook(context.Background(), domain.IncomingWebhook{
+		ChatId:    chatId,
+		Name:      "ci",
+		TokenHash: []byte(token),
+		CreatedBy: "owner",
+	})
+	if err != nil {
+		t.Fatalf("create incoming webhook: %v", err)
+	}
+	return hook
+}
+
+func testMissingIncomingWebhook(t *testing.T, chats storage.ChatRepo, hooks storage.IncomingWebhookRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	if err := chats.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+
+	_, err := hooks.CreateIncomingWebhook(ctx, domain.IncomingWebhook{ChatId: chat.ID, Name: "ci", TokenHash: []byte("t"), CreatedBy: "owner"})
+	requireNotFound(t, "CreateIncomingWebhook", err, domain.EntityChat, strconv.Itoa(chat.ID))
+
+	chat = createChat(t, chats)
+	err = hooks.DeleteIncomingWebhook(ctx, chat.ID, 42)
+	requireNotFound(t, "DeleteIncomingWebhook", err, domain.EntityIncomingWebhook, "42")
+
+	_, err = hooks.GetIncomingWebhookByToken(ctx, []byte("unknown"))
+	requireNotFound(t, "GetIncomingWebhookByToken", err, domain.EntityIncomingWebhook, "")
+}
+
+func testIncomingWebhookByToken(t *testing.T, chats storage.ChatRepo, hooks storage.IncomingWebhookRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	other := createChat(t, chats)
+	first := createIncomingWebhook(t, hooks, chat.ID, "first")
+	second := createIncomingWebhook(t, hooks, chat.ID, "second")
+	foreign := createIncomingWebhook(t, hooks, other.ID, "foreign")
+
+	list, err := hooks.ListIncomingWebhooks(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("list incoming webhooks: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != first.ID || list[1].ID != second.ID {
+		t.Fatalf("unexpected incoming webhooks: %+v", list)
+	}
+
+	found, err := hooks.GetIncomingWebhookByToken(ctx, []byte("second"))
+	if err != nil {
+		t.Fatalf("get by token: %v", err)
+	}
+	if found.ID != second.ID || found.ChatId != chat.ID || found.Name != "ci" || found.CreatedBy != "owner" {
+		t.Fatalf("unexpected incoming webhook: %+v", found)
+	}
+
+	// Чужой чат не может удалить вебхук
+	err = hooks.DeleteIncomingWebhook(ctx, chat.ID, foreign.ID)
+	requireNotFound(t, "DeleteIncomingWebhook", err, domain.EntityIncomingWebhook, strconv.Itoa(foreign.ID))
+
+	if err := hooks.DeleteIncomingWebhook(ctx, chat.ID, second.ID); err != nil {
+		t.Fatalf("delete incoming webhook: %v", err)
+	}
+	_, err = hooks.GetIncomingWebhookByToken(ctx, []byte("second"))
+	requireNotFound(t, "GetIncomingWebhookByToken", err, domain.EntityIncomingWebhook, "")
+}
+
+func testIncomingWebhookChatDeleted(t *testing.T, chats storage.ChatRepo, hooks storage.IncomingWebhookRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	createIncomingWebhook(t, hooks, chat.ID, "token")
+	if err := chats.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+
+	_, err := hooks.GetIncomingWebhookByToken(ctx, []byte("token"))
+	requireNotFound(t, "GetIncomingWebhookByToken", err, domain.EntityIncomingWebhook, "")
+	list, err := hooks.ListIncomingWebhooks(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("list incoming webhooks: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("incoming webhooks of deleted chat are visible: %+v", list)
+	}
+}
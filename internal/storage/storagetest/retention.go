@@ -0,0 +1,119 @@
+package storagetest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+// RetentionFactory создает пустые репозитории для одного теста сроков хранения
+type RetentionFactory func(t *testing.T) (storage.ChatRepo, storage.RetentionRepo)
+
+// RunRetention проверяет контракт RetentionRepo
+func RunRetention(t *testing.T, newRepos RetentionFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, chats storage.ChatRepo, retention storage.RetentionRepo)
+	}{
+		{"PurgeBatches", testPurgeBatches},
+		{"LegalHold", testLegalHold},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats, retention := newRepos(t)
+			tt.run(t, chats, retention)
+		})
+	}
+}
+
+func testPurgeBatches(t *testing.T, chats storage.ChatRepo, retention storage.RetentionRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	other := createChat(t, chats)
+	sent := make(map[int]bool)
+	for i := 0; i < 3; i++ {
+		sent[addMessage(t, chats, chat.ID, "old "+strconv.Itoa(i)).ID] = true
+	}
+	addMessage(t, chats, other.ID, "other chat")
+
+	ids, err := retention.PurgeMessagesBatch(ctx, chat.ID, time.Now().Add(-time.Hour), 10)
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("purge before messages: %v, %v", ids, err)
+	}
+
+	before := time.Now().Add(time.Minute)
+	if count, err := retention.CountMessagesBefore(ctx, chat.ID, before); err != nil || count != 3 {
+		t.Fatalf("count: %d, %v", count, err)
+	}
+	purged := make([]int, 0, 3)
+	for _, want := range []int{2, 1, 0} {
+		ids, err := retention.PurgeMessagesBatch(ctx, chat.ID, before, 2)
+		if err != nil {
+			t.Fatalf("purge batch: %v", err)
+		}
+		if len(ids) != want {
+			t.Fatalf("purged %d messages, want %d", len(ids), want)
+		}
+		purged = append(purged, ids...)
+	}
+	for _, id := range purged {
+		if !sent[id] {
+			t.Fatalf("purged unexpected message %d", id)
+		}
+	}
+
+	got, err := chats.GetWithMessages(ctx, chat.ID)
+	if err != nil || len(got.Messages) != 0 {
+		t.Fatalf("messages left after purge: %+v, %v", got.Messages, err)
+	}
+	got, err = chats.GetWithMessages(ctx, other.ID)
+	if err != nil || len(got.Messages) != 1 {
+		t.Fatalf("other chat must keep messages: %+v, %v", got.Messages, err)
+	}
+}
+
+func testLegalHold(t *testing.T, chats storage.ChatRepo, retention storage.RetentionRepo) {
+	ctx := context.Background()
+	missing := createChat(t, chats)
+	if err := chats.DeleteChat(ctx, missing.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+	_, err := retention.SetLegalHold(ctx, missing.ID, true)
+	requireNotFound(t, "SetLegalHold", err, domain.EntityChat, strconv.Itoa(missing.ID))
+
+	chat := createChat(t, chats)
+	addMessage(t, chats, chat.ID, "evidence")
+	held, err := retention.SetLegalHold(ctx, chat.ID, true)
+	if err != nil || !held.LegalHold || held.ID != chat.ID {
+		t.Fatalf("set legal hold: %+v, %v", held, err)
+	}
+
+	// Обновление чата не снимает удержание
+	held.Title = "renamed"
+	updated, err := chats.UpdateChat(ctx, held)
+	if err != nil || !updated.LegalHold {
+		t.Fatalf("update must keep legal hold: %+v, %v", updated, err)
+	}
+	if got, err := chats.GetChatByID(ctx, chat.ID); err != nil || !got.LegalHold {
+		t.Fatalf("get: %+v, %v", got, err)
+	}
+
+	before := time.Now().Add(time.Minute)
+	if ids, err := retention.PurgeMessagesBatch(ctx, chat.ID, before, 10); err != nil || len(ids) != 0 {
+		t.Fatalf("held chat purged: %v, %v", ids, err)
+	}
+	if count, err := retention.CountMessagesBefore(ctx, chat.ID, before); err != nil || count != 1 {
+		t.Fatalf("count in held chat: %d, %v", count, err)
+	}
+
+	if _, err = retention.SetLegalHold(ctx, chat.ID, false); err != nil {
+		t.Fatalf("release legal hold: %v", err)
+	}
+	if ids, err := retention.PurgeMessagesBatch(ctx, chat.ID, before, 10); err != nil || len(ids) != 1 {
+		t.Fatalf("released chat not purged: %v, %v", ids, err)
+	}
+}
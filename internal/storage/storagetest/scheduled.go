@@ -0,0 +1,214 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+// ScheduledFactory создает пустые репозитории для одного теста запланированных сообщений
+type ScheduledFactory func(t *testing.T) (storage.ChatRepo, storage.ScheduledMessageRepo)
+
+// RunScheduled проверяет контракт ScheduledMessageRepo
+func RunScheduled(t *testing.T, newRepos ScheduledFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, chats storage.ChatRepo, scheduled storage.ScheduledMessageRepo)
+	}{
+		{"MissingScheduled", testMissingScheduled},
+		{"ScheduledByAuthor", testScheduledByAuthor},
+		{"ClaimScheduled", testClaimScheduled},
+		{"ScheduledChatDeleted", testScheduledChatDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats, scheduled := newRepos(t)
+			tt.run(t, chats, scheduled)
+		})
+	}
+}
+
+func createScheduled(t *testing.T, repo storage.ScheduledMessageRepo, chatId int, createdBy string, sendAt time.Time) domain.ScheduledMessage {
+	t.Helper()
+	msg, err := repo.CreateScheduledMessage(context.Background(), domain.ScheduledMessage{
+		ChatId:    chatId,
+		Kind:      domain.MessageKindUser,
+		AuthorId:  createdBy,
+		CreatedBy: createdBy,
+		Text:      "scheduled",
+		SendAt:    sendAt,
+	})
+	if err != nil {
+		t.Fatalf("create scheduled message: %v", err)
+	}
+	return msg
+}
+
+func claimScheduled(t *testing.T, repo storage.ScheduledMessageRepo) []domain.ScheduledMessage {
+	t.Helper()
+	messages, err := repo.ClaimScheduledMessages(context.Background(), 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim scheduled messages: %v", err)
+	}
+	return messages
+}
+
+func testMissingScheduled(t *testing.T, chats storage.ChatRepo, scheduled storage.ScheduledMessageRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	if err := chats.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+
+	_, err := scheduled.CreateScheduledMessage(ctx, domain.ScheduledMessage{ChatId: chat.ID, Kind: domain.MessageKindUser, Text: "t", SendAt: time.Now()})
+	requireNotFound(t, "CreateScheduledMessage", err, domain.EntityChat, strconv.Itoa(chat.ID))
+
+	chat = createChat(t, chats)
+	_, err = scheduled.GetScheduledMessage(ctx, chat.ID, 42, "alice")
+	requireNotFound(t, "GetScheduledMessage", err, domain.EntityScheduled, "42")
+	_, err = scheduled.UpdateScheduledMessage(ctx, domain.ScheduledMessage{ID: 42, ChatId: chat.ID, CreatedBy: "alice", Text: "t", SendAt: time.Now()})
+	requireNotFound(t, "UpdateScheduledMessage", err, domain.EntityScheduled, "42")
+	err = scheduled.DeleteScheduledMessage(ctx, chat.ID, 42, "alice")
+	requireNotFound(t, "DeleteScheduledMessage", err, domain.EntityScheduled, "42")
+}
+
+func testScheduledByAuthor(t *testing.T, chats storage.ChatRepo, scheduled storage.ScheduledMessageRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	later := createScheduled(t, scheduled, chat.ID, "alice", time.Now().Add(2*time.Hour))
+	sooner := createScheduled(t, scheduled, chat.ID, "alice", time.Now().Add(time.Hour))
+	foreign := createScheduled(t, scheduled, chat.ID, "bob", time.Now().Add(time.Hour))
+
+	if sooner.Status != domain.ScheduledPending || sooner.Attempts != 0 || !sooner.NextAttemptAt.Equal(sooner.SendAt) {
+		t.Fatalf("unexpected created message: %+v", sooner)
+	}
+	list, err := scheduled.ListScheduledMessages(ctx, chat.ID, "alice")
+	if err != nil {
+		t.Fatalf("list scheduled messages: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != sooner.ID || list[1].ID != later.ID {
+		t.Fatalf("expected messages ordered by SendAt, got %+v", list)
+	}
+
+	// Чужие сообщения не видны и не меняются
+	_, err = scheduled.GetScheduledMessage(ctx, chat.ID, foreign.ID, "alice")
+	requireNotFound(t, "GetScheduledMessage", err, domain.EntityScheduled, strconv.Itoa(foreign.ID))
+	err = scheduled.DeleteScheduledMessage(ctx, chat.ID, foreign.ID, "alice")
+	requireNotFound(t, "DeleteScheduledMessage", err, domain.EntityScheduled, strconv.Itoa(foreign.ID))
+
+	sendAt := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+	later.Text = "edited"
+	later.SendAt = sendAt
+	updated, err := scheduled.UpdateScheduledMessage(ctx, later)
+	if err != nil {
+		t.Fatalf("update scheduled message: %v", err)
+	}
+	if updated.Text != "edited" || !updated.SendAt.Equal(sendAt) || !updated.NextAttemptAt.Equal(sendAt) {
+		t.Fatalf("unexpected updated message: %+v", updated)
+	}
+
+	if err := scheduled.DeleteScheduledMessage(ctx, chat.ID, sooner.ID, "alice"); err != nil {
+		t.Fatalf("delete scheduled message: %v", err)
+	}
+	list, err = scheduled.ListScheduledMessages(ctx, chat.ID, "alice")
+	if err != nil {
+		t.Fatalf("list scheduled messages: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != later.ID || list[0].Text != "edited" {
+		t.Fatalf("unexpected messages after delete: %+v", list)
+	}
+}
+
+func testClaimScheduled(t *testing.T, chats storage.ChatRepo, scheduled storage.ScheduledMessageRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	second := createScheduled(t, scheduled, chat.ID, "alice", time.Now().Add(-time.Minute))
+	first := createScheduled(t, scheduled, chat.ID, "alice", time.Now().Add(-time.Hour))
+	createScheduled(t, scheduled, chat.ID, "alice", time.Now().Add(time.Hour))
+
+	claimed := claimScheduled(t, scheduled)
+	if len(claimed) != 2 || claimed[0].ID != first.ID || claimed[1].ID != second.ID {
+		t.Fatalf("expected due messages in send order, got %+v", claimed)
+	}
+	if again := claimScheduled(t, scheduled); len(again) != 0 {
+		t.Fatalf("claimed messages were issued twice: %+v", again)
+	}
+
+	// Отправляемое сообщение нельзя изменить или отменить
+	_, err := scheduled.UpdateScheduledMessage(ctx, first)
+	if !errors.Is(err, domain.ConflictError) || !errors.Is(err, storage.ScheduledMessageSendingError) {
+		t.Fatalf("expected sending conflict on update, got %v", err)
+	}
+	err = scheduled.DeleteScheduledMessage(ctx, chat.ID, first.ID, "alice")
+	if !errors.Is(err, storage.ScheduledMessageSendingError) {
+		t.Fatalf("expected sending conflict on delete, got %v", err)
+	}
+
+	if err := scheduled.CompleteScheduledMessage(ctx, first.ID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	_, err = scheduled.GetScheduledMessage(ctx, chat.ID, first.ID, "alice")
+	requireNotFound(t, "GetScheduledMessage", err, domain.EntityScheduled, strconv.Itoa(first.ID))
+
+	// Неудачная попытка снимает блокировку, повтор выдается после NextAttemptAt
+	second.Status = domain.ScheduledPending
+	second.Attempts = 1
+	second.NextAttemptAt = time.Now().Add(time.Hour)
+	second.LastError = "unavailable"
+	if err := scheduled.FailScheduledMessage(ctx, second); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+	if again := claimScheduled(t, scheduled); len(again) != 0 {
+		t.Fatalf("message claimed before its next attempt: %+v", again)
+	}
+	got, err := scheduled.GetScheduledMessage(ctx, chat.ID, second.ID, "alice")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Attempts != 1 || got.LastError != "unavailable" {
+		t.Fatalf("attempt not saved: %+v", got)
+	}
+
+	second.Status = domain.ScheduledFailed
+	second.NextAttemptAt = time.Now().Add(-time.Minute)
+	if err := scheduled.FailScheduledMessage(ctx, second); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+	if again := claimScheduled(t, scheduled); len(again) != 0 {
+		t.Fatalf("failed message was claimed: %+v", again)
+	}
+
+	// Изменение возвращает неудачное сообщение в очередь
+	second.SendAt = time.Now().Add(-time.Second)
+	if _, err := scheduled.UpdateScheduledMessage(ctx, second); err != nil {
+		t.Fatalf("update failed message: %v", err)
+	}
+	if again := claimScheduled(t, scheduled); len(again) != 1 || again[0].ID != second.ID || again[0].Attempts != 0 {
+		t.Fatalf("edited message was not queued again: %+v", again)
+	}
+}
+
+func testScheduledChatDeleted(t *testing.T, chats storage.ChatRepo, scheduled storage.ScheduledMessageRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	createScheduled(t, scheduled, chat.ID, "alice", time.Now().Add(-time.Minute))
+	if err := chats.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+
+	if claimed := claimScheduled(t, scheduled); len(claimed) != 0 {
+		t.Fatalf("messages of deleted chat were claimed: %+v", claimed)
+	}
+	list, err := scheduled.ListScheduledMessages(ctx, chat.ID, "alice")
+	if err != nil {
+		t.Fatalf("list scheduled messages: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("messages of deleted chat are visible: %+v", list)
+	}
+}
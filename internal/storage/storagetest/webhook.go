@@ -0,0 +1,241 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+	"chat-project/internal/storage"
+)
+
+// WebhookFactory создает пустые репозитории для одного теста вебхуков
+type WebhookFactory func(t *testing.T) (storage.ChatRepo, storage.WebhookRepo)
+
+// RunWebhooks проверяет контракт WebhookRepo
+func RunWebhooks(t *testing.T, newRepos WebhookFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, chats storage.ChatRepo, webhooks storage.WebhookRepo)
+	}{
+		{"MissingWebhook", testMissingWebhook},
+		{"EnqueueBySubscription", testEnqueueBySubscription},
+		{"ClaimAndUpdate", testClaimAndUpdate},
+		{"RetryDead", testRetryDead},
+		{"DeleteDropsPending", testDeleteDropsPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats, webhooks := newRepos(t)
+			tt.run(t, chats, webhooks)
+		})
+	}
+}
+
+func createWebhook(t *testing.T, repo storage.WebhookRepo, chatId int, events ...domain.EventType) domain.Webhook {
+	t.Helper()
+	webhook, err := repo.CreateWebhook(context.Background(), domain.Webhook{
+		ChatId:    chatId,
+		URL:       "http://receiver.test/hook",
+		Secret:    "secret",
+		Events:    events,
+		CreatedBy: "owner",
+	})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	return webhook
+}
+
+func enqueue(t *testing.T, repo storage.WebhookRepo, chatId int, eventType domain.EventType) int {
+	t.Helper()
+	count, err := repo.EnqueueEvent(context.Background(), domain.Event{Type: eventType, ChatId: chatId}, []byte(`{"type":"`+string(eventType)+`"}`))
+	if err != nil {
+		t.Fatalf("enqueue %s: %v", eventType, err)
+	}
+	return count
+}
+
+func claim(t *testing.T, repo storage.WebhookRepo) []domain.WebhookDelivery {
+	t.Helper()
+	deliveries, err := repo.ClaimDeliveries(context.Background(), 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim deliveries: %v", err)
+	}
+	return deliveries
+}
+
+func testMissingWebhook(t *testing.T, chats storage.ChatRepo, webhooks storage.WebhookRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	if err := chats.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+
+	_, err := webhooks.CreateWebhook(ctx, domain.Webhook{ChatId: chat.ID, URL: "http://receiver.test", Secret: "s", CreatedBy: "owner"})
+	requireNotFound(t, "CreateWebhook", err, domain.EntityChat, strconv.Itoa(chat.ID))
+
+	chat = createChat(t, chats)
+	err = webhooks.DeleteWebhook(ctx, chat.ID, 42)
+	requireNotFound(t, "DeleteWebhook", err, domain.EntityWebhook, "42")
+
+	_, err = webhooks.RetryDelivery(ctx, chat.ID, 42, 7)
+	requireNotFound(t, "RetryDelivery", err, domain.EntityDelivery, "7")
+}
+
+func testEnqueueBySubscription(t *testing.T, chats storage.ChatRepo, webhooks storage.WebhookRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	other := createChat(t, chats)
+	all := createWebhook(t, webhooks, chat.ID)
+	messages := createWebhook(t, webhooks, chat.ID, domain.EventMessageCreated)
+	createWebhook(t, webhooks, other.ID)
+
+	list, err := webhooks.ListWebhooks(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("list webhooks: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != all.ID || list[1].ID != messages.ID {
+		t.Fatalf("unexpected webhooks: %+v", list)
+	}
+	if len(list[1].Events) != 1 || list[1].Events[0] != domain.EventMessageCreated {
+		t.Fatalf("events not saved: %+v", list[1])
+	}
+
+	if n := enqueue(t, webhooks, chat.ID, domain.EventMessageCreated); n != 2 {
+		t.Fatalf("message.created enqueued %d deliveries, want 2", n)
+	}
+	if n := enqueue(t, webhooks, chat.ID, domain.EventChatUpdated); n != 1 {
+		t.Fatalf("chat.updated enqueued %d deliveries, want 1", n)
+	}
+
+	deliveries, err := webhooks.ListDeliveries(ctx, chat.ID, all.ID, "", 10, 0)
+	if err != nil {
+		t.Fatalf("list deliveries: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].EventType != domain.EventChatUpdated || deliveries[1].EventType != domain.EventMessageCreated {
+		t.Fatalf("expected newest deliveries first, got %+v", deliveries)
+	}
+	d := deliveries[1]
+	if d.Status != domain.DeliveryPending || d.URL != all.URL || d.Secret != all.Secret || string(d.Payload) == "" {
+		t.Fatalf("unexpected delivery: %+v", d)
+	}
+}
+
+func testClaimAndUpdate(t *testing.T, chats storage.ChatRepo, webhooks storage.WebhookRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	webhook := createWebhook(t, webhooks, chat.ID)
+	enqueue(t, webhooks, chat.ID, domain.EventMessageCreated)
+
+	claimed := claim(t, webhooks)
+	if len(claimed) != 1 || claimed[0].WebhookId != webhook.ID {
+		t.Fatalf("unexpected claimed deliveries: %+v", claimed)
+	}
+	// Взятая доставка отложена на lease и не выдается повторно
+	if again := claim(t, webhooks); len(again) != 0 {
+		t.Fatalf("claimed delivery returned again: %+v", again)
+	}
+
+	d := claimed[0]
+	d.Attempts = 1
+	d.LastStatusCode = 500
+	d.LastError = "internal error"
+	d.NextAttemptAt = time.Now().Add(-time.Second)
+	if err := webhooks.UpdateDelivery(ctx, d); err != nil {
+		t.Fatalf("update delivery: %v", err)
+	}
+	retried := claim(t, webhooks)
+	if len(retried) != 1 || retried[0].Attempts != 1 || retried[0].LastStatusCode != 500 || retried[0].LastError != "internal error" {
+		t.Fatalf("unexpected retried delivery: %+v", retried)
+	}
+
+	d = retried[0]
+	deliveredAt := time.Now()
+	d.Status = domain.DeliveryDelivered
+	d.Attempts = 2
+	d.LastStatusCode = 200
+	d.LastError = ""
+	d.DeliveredAt = &deliveredAt
+	if err := webhooks.UpdateDelivery(ctx, d); err != nil {
+		t.Fatalf("update delivery: %v", err)
+	}
+	delivered, err := webhooks.ListDeliveries(ctx, chat.ID, webhook.ID, domain.DeliveryDelivered, 10, 0)
+	if err != nil {
+		t.Fatalf("list deliveries: %v", err)
+	}
+	if len(delivered) != 1 || delivered[0].DeliveredAt == nil || delivered[0].Attempts != 2 {
+		t.Fatalf("unexpected delivered deliveries: %+v", delivered)
+	}
+
+	purged, err := webhooks.PurgeDeliveries(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("purge deliveries: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged %d deliveries, want 1", purged)
+	}
+}
+
+func testRetryDead(t *testing.T, chats storage.ChatRepo, webhooks storage.WebhookRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	webhook := createWebhook(t, webhooks, chat.ID)
+	enqueue(t, webhooks, chat.ID, domain.EventChatUpdated)
+	d := claim(t, webhooks)[0]
+
+	_, err := webhooks.RetryDelivery(ctx, chat.ID, webhook.ID, d.ID)
+	if !errors.Is(err, domain.ConflictError) || !errors.Is(err, storage.DeliveryNotDeadError) {
+		t.Fatalf("expected not dead conflict, got %v", err)
+	}
+
+	d.Status = domain.DeliveryDead
+	d.Attempts = 5
+	if err := webhooks.UpdateDelivery(ctx, d); err != nil {
+		t.Fatalf("update delivery: %v", err)
+	}
+	retried, err := webhooks.RetryDelivery(ctx, chat.ID, webhook.ID, d.ID)
+	if err != nil {
+		t.Fatalf("retry delivery: %v", err)
+	}
+	if retried.Status != domain.DeliveryPending || retried.Attempts != 0 {
+		t.Fatalf("unexpected retried delivery: %+v", retried)
+	}
+	if claimed := claim(t, webhooks); len(claimed) != 1 || claimed[0].ID != d.ID {
+		t.Fatalf("retried delivery not claimed: %+v", claimed)
+	}
+}
+
+func testDeleteDropsPending(t *testing.T, chats storage.ChatRepo, webhooks storage.WebhookRepo) {
+	ctx := context.Background()
+	chat := createChat(t, chats)
+	webhook := createWebhook(t, webhooks, chat.ID)
+	enqueue(t, webhooks, chat.ID, domain.EventMessageCreated)
+
+	if err := webhooks.DeleteWebhook(ctx, chat.ID, webhook.ID); err != nil {
+		t.Fatalf("delete webhook: %v", err)
+	}
+	if claimed := claim(t, webhooks); len(claimed) != 0 {
+		t.Fatalf("pending delivery of deleted webhook claimed: %+v", claimed)
+	}
+	list, err := webhooks.ListWebhooks(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("list webhooks: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("deleted webhook listed: %+v", list)
+	}
+
+	// Доставка, поставленная до удаления чата, остается в очереди
+	createWebhook(t, webhooks, chat.ID)
+	enqueue(t, webhooks, chat.ID, domain.EventChatDeleted)
+	if err := chats.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+	claimed := claim(t, webhooks)
+	if len(claimed) != 1 || claimed[0].EventType != domain.EventChatDeleted || claimed[0].ChatId != chat.ID {
+		t.Fatalf("chat.deleted delivery lost: %+v", claimed)
+	}
+}
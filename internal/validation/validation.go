@@ -0,0 +1,213 @@
+// Package validation нормализует и проверяет входные DTO по тегам normalize и validate.
+// Сервисы вызывают его перед обращением к хранилищу, поэтому правила одинаковы для всех транспортов.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/unicode/norm"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+)
+
+// Имена ограничений длины для тега maxlen, значения берутся из config.Validation
+const (
+	LimitTitle       = "title"
+	LimitDescription = "description"
+	LimitAvatarURL   = "avatar_url"
+	LimitMessage     = "message"
+)
+
+// Коды ошибок полей входят в контракт API и не меняются
+const (
+	CodeRequired          = "required"
+	CodeTooLong           = "too_long"
+	CodeControlCharacters = "control_characters"
+	CodeInvalidURL        = "invalid_url"
+	CodeOutOfRange        = "out_of_range"
+	CodeInvalid           = "invalid"
+)
+
+// Ограничения по умолчанию, если в конфиге указан 0
+var defaultLimits = map[string]int{
+	LimitTitle:       255,
+	LimitDescription: 1000,
+	LimitAvatarURL:   2048,
+	LimitMessage:     4000,
+}
+
+// Errors ошибки отдельных полей, возвращаются внутри domain.Validation
+type Errors []dto.FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, field := range e {
+		parts = append(parts, field.Field+": "+field.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+type Validator struct {
+	validate *validator.Validate
+	limits   map[string]int
+}
+
+func New(cfg config.Validation) *Validator {
+	v := &Validator{
+		validate: validator.New(validator.WithRequiredStructEnabled()),
+		limits: map[string]int{
+			LimitTitle:       cfg.TitleMaxLength,
+			LimitDescription: cfg.DescriptionMaxLength,
+			LimitAvatarURL:   cfg.AvatarURLMaxLength,
+			LimitMessage:     cfg.MessageMaxLength,
+		},
+	}
+	for name, limit := range v.limits {
+		if limit <= 0 {
+			v.limits[name] = defaultLimits[name]
+		}
+	}
+
+	// В ошибках поля называются так же, как в JSON
+	v.validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	// Ошибки регистрации возможны только при пустом имени тега
+	_ = v.validate.RegisterValidation("notempty", notEmpty)
+	_ = v.validate.RegisterValidation("maxlen", v.maxLen)
+	_ = v.validate.RegisterValidation("singleline", singleLine)
+	_ = v.validate.RegisterValidation("nocontrol", noControl)
+	return v
+}
+
+// Validate нормализует поля структуры по указателю s и проверяет ее.
+// Ошибки полей возвращаются как domain.Validation для сущности entity.
+func (v *Validator) Validate(entity domain.Entity, s any) error {
+	normalize(reflect.ValueOf(s))
+
+	err := v.validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	errs := make(Errors, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		errs = append(errs, v.fieldError(fieldErr))
+	}
+	return domain.Validation(entity, errs)
+}
+
+func (v *Validator) fieldError(err validator.FieldError) dto.FieldError {
+	// Namespace начинается с имени типа: ChatIn.Settings.SlowModeSeconds
+	_, field, _ := strings.Cut(err.Namespace(), ".")
+	fieldErr := dto.FieldError{Field: field}
+
+	switch err.Tag() {
+	case "notempty":
+		fieldErr.Code, fieldErr.Message = CodeRequired, "must not be empty"
+	case "maxlen":
+		fieldErr.Code, fieldErr.Message = CodeTooLong, fmt.Sprintf("must be at most %d characters", v.limits[err.Param()])
+	case "singleline":
+		fieldErr.Code, fieldErr.Message = CodeControlCharacters, "must not contain control characters or line breaks"
+	case "nocontrol":
+		fieldErr.Code, fieldErr.Message = CodeControlCharacters, "must not contain control characters"
+	case "http_url":
+		fieldErr.Code, fieldErr.Message = CodeInvalidURL, "must be an http or https URL"
+	case "max":
+		fieldErr.Code, fieldErr.Message = CodeTooLong, "must be at most "+err.Param()+" characters"
+	case "printascii":
+		fieldErr.Code, fieldErr.Message = CodeInvalid, "must contain only printable ASCII characters"
+	case "gte":
+		fieldErr.Code, fieldErr.Message = CodeOutOfRange, "must be at least "+err.Param()
+	case "lte":
+		fieldErr.Code, fieldErr.Message = CodeOutOfRange, "must be at most "+err.Param()
+	default:
+		fieldErr.Code, fieldErr.Message = CodeInvalid, "is invalid"
+	}
+	return fieldErr
+}
+
+// notEmpty строка не пустая после нормализации. Стандартный required для указателя проверяет только nil
+func notEmpty(fl validator.FieldLevel) bool {
+	return fl.Field().String() != ""
+}
+
+// maxLen длина строки в символах не больше ограничения с именем из параметра тега
+func (v *Validator) maxLen(fl validator.FieldLevel) bool {
+	limit, ok := v.limits[fl.Param()]
+	if !ok {
+		panic("validation: unknown maxlen limit " + fl.Param())
+	}
+	return utf8.RuneCountInString(fl.Field().String()) <= limit
+}
+
+// singleLine строка без управляющих символов, включая переводы строки
+func singleLine(fl validator.FieldLevel) bool {
+	return !strings.ContainsFunc(fl.Field().String(), unicode.IsControl)
+}
+
+// noControl строка без управляющих символов, кроме перевода строки и табуляции
+func noControl(fl validator.FieldLevel) bool {
+	return !strings.ContainsFunc(fl.Field().String(), func(r rune) bool {
+		return r != '\n' && r != '\t' && unicode.IsControl(r)
+	})
+}
+
+// normalize приводит строковые поля с тегом normalize к NFC, заменяет CRLF на LF
+// и обрезает пробелы по краям. Вложенные структуры обрабатываются рекурсивно.
+func normalize(value reflect.Value) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		if _, ok := value.Type().Field(i).Tag.Lookup("normalize"); !ok {
+			normalize(field)
+			continue
+		}
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.String {
+			field.SetString(normalizeString(field.String()))
+		}
+	}
+}
+
+func normalizeString(s string) string {
+	s = norm.NFC.String(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.TrimSpace(s)
+}
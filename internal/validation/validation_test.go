@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/dto"
+)
+
+func requireFieldErrors(t *testing.T, err error, want ...dto.FieldError) {
+	t.Helper()
+	if !errors.Is(err, domain.ValidationError) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected field errors, got %v", err)
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), errs)
+	}
+	for i := range want {
+		if errs[i].Field != want[i].Field || errs[i].Code != want[i].Code || errs[i].Message == "" {
+			t.Fatalf("expected %s %s, got %+v", want[i].Field, want[i].Code, errs[i])
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	v := New(config.Validation{})
+	// "Е" и комбинирующий знак U+0308 после NFC становятся одним символом "Ё"
+	chat := dto.ChatIn{Title: "  \u0415\u0308лка\t", Description: "line\r\nnext "}
+
+	if err := v.Validate(domain.EntityChat, &chat); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if chat.Title != "\u0401лка" {
+		t.Fatalf("unexpected title %q", chat.Title)
+	}
+	if chat.Description != "line\nnext" {
+		t.Fatalf("unexpected description %q", chat.Description)
+	}
+}
+
+func TestChatFieldErrors(t *testing.T) {
+	v := New(config.Validation{TitleMaxLength: 5})
+	settings := dto.ChatSettings{SlowModeSeconds: -1}
+	chat := dto.ChatIn{
+		Title:       "шесть!",
+		Description: "bell\a",
+		AvatarURL:   "ftp://example.com/a.png",
+		Settings:    &settings,
+	}
+
+	err := v.Validate(domain.EntityChat, &chat)
+	requireFieldErrors(t, err,
+		dto.FieldError{Field: "Title", Code: CodeTooLong},
+		dto.FieldError{Field: "Description", Code: CodeControlCharacters},
+		dto.FieldError{Field: "AvatarUrl", Code: CodeInvalidURL},
+		dto.FieldError{Field: "Settings.SlowModeSeconds", Code: CodeOutOfRange},
+	)
+}
+
+func TestChatPatch(t *testing.T) {
+	v := New(config.Validation{})
+
+	if err := v.Validate(domain.EntityChat, &dto.ChatPatchIn{}); err != nil {
+		t.Fatalf("empty patch: %v", err)
+	}
+
+	blank, title := "   ", "two\nlines"
+	err := v.Validate(domain.EntityChat, &dto.ChatPatchIn{Title: &blank})
+	requireFieldErrors(t, err, dto.FieldError{Field: "Title", Code: CodeRequired})
+
+	err = v.Validate(domain.EntityChat, &dto.ChatPatchIn{Title: &title})
+	requireFieldErrors(t, err, dto.FieldError{Field: "Title", Code: CodeControlCharacters})
+}
+
+func TestMessage(t *testing.T) {
+	v := New(config.Validation{MessageMaxLength: 3})
+
+	message := dto.MessageIn{Text: " \n "}
+	requireFieldErrors(t, v.Validate(domain.EntityMessage, &message), dto.FieldError{Field: "Text", Code: CodeRequired})
+
+	// Длина считается в символах, а не в байтах
+	message = dto.MessageIn{Text: "ёёё"}
+	if err := v.Validate(domain.EntityMessage, &message); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	message = dto.MessageIn{Text: strings.Repeat("ё", 4)}
+	err := v.Validate(domain.EntityMessage, &message)
+	requireFieldErrors(t, err, dto.FieldError{Field: "Text", Code: CodeTooLong})
+	if !strings.Contains(err.Error(), "at most 3 characters") {
+		t.Fatalf("unexpected error text %q", err.Error())
+	}
+}
+
+func TestMessageTTL(t *testing.T) {
+	v := New(config.Validation{})
+
+	message := dto.MessageIn{Text: "secret", TtlSeconds: 60}
+	if err := v.Validate(domain.EntityMessage, &message); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	message = dto.MessageIn{Text: "secret", TtlSeconds: 8 * 24 * 3600}
+	err := v.Validate(domain.EntityMessage, &message)
+	requireFieldErrors(t, err, dto.FieldError{Field: "TtlSeconds", Code: CodeOutOfRange})
+	if !strings.Contains(err.Error(), "at most 604800") {
+		t.Fatalf("unexpected error text %q", err.Error())
+	}
+
+	message = dto.MessageIn{Text: "secret", TtlSeconds: -1}
+	requireFieldErrors(t, v.Validate(domain.EntityMessage, &message), dto.FieldError{Field: "TtlSeconds", Code: CodeOutOfRange})
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// PrivateAddressError получатель вебхука во внутренней сети: запросы туда позволили бы обращаться к сервисам за периметром
+var PrivateAddressError = errors.New("webhook URL must not resolve to a loopback, link-local or private address")
+
+// UnresolvableHostError хост вебхука не разрешился. Ошибка резолвера не отдается клиенту: в ней адреса DNS-серверов
+var UnresolvableHostError = errors.New("unable to resolve webhook host")
+
+// Внутренние диапазоны, которых нет среди проверок netip.Addr: shared address space (CGNAT) и IETF protocol assignments
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+}
+
+// privateAddr адреса, на которые вебхуки не отправляются
+func privateAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckURL разрешает хост адреса вебхука и возвращает PrivateAddressError, если хотя бы один из адресов внутренний.
+// Имя может начать указывать на другой адрес после проверки, поэтому Dispatcher проверяет адрес еще и при соединении
+func CheckURL(ctx context.Context, resolver *net.Resolver, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if privateAddr(addr) {
+			return PrivateAddressError
+		}
+		return nil
+	}
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return UnresolvableHostError
+	}
+	for _, addr := range addrs {
+		if privateAddr(addr) {
+			return PrivateAddressError
+		}
+	}
+	return nil
+}
+
+// dialControl не дает соединиться с внутренним адресом, даже если имя получателя разрешилось в него после CheckURL
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if privateAddr(addrPort.Addr()) {
+		return PrivateAddressError
+	}
+	return nil
+}
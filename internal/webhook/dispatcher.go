@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/metrics"
+	"chat-project/internal/queue"
+	"chat-project/internal/storage"
+)
+
+const (
+	// Сколько байт ответа получателя читать, чтобы соединение можно было переиспользовать
+	_maxResponseBody = 64 << 10
+	// Как часто удалять старые доставки из журнала
+	_purgeInterval = time.Hour
+)
+
+// Dispatcher забирает доставки из очереди и отправляет их получателям.
+// Несколько инстансов могут работать с одной очередью: ClaimDeliveries не выдает одну доставку дважды
+type Dispatcher struct {
+	repo    storage.WebhookRepo
+	client  *http.Client
+	cfg     config.Webhook
+	log     *slog.Logger
+	metrics *metrics.Metrics
+	now     func() time.Time
+}
+
+func NewDispatcher(repo storage.WebhookRepo, cfg config.Webhook, log *slog.Logger, m *metrics.Metrics) *Dispatcher {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivateAddresses {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialControl}
+		transport.DialContext = dialer.DialContext
+		// Через прокси dialControl увидел бы только адрес прокси, а не получателя
+		transport.Proxy = nil
+	}
+	return &Dispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			// Редирект считается неудачной попыткой: подписанное тело не должно уходить на другой адрес
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		cfg:     cfg,
+		log:     log.With(slog.String("component", "webhook")),
+		metrics: m,
+		now:     time.Now,
+	}
+}
+
+// Run разбирает очередь, пока не отменен ctx, и дожидается начатых попыток
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.cfg.Workers <= 0 {
+		return
+	}
+	var lastPurge time.Time
+	queue.Poll(ctx, d.cfg.PollInterval, d.cfg.BatchSize, d.dispatch, func(ctx context.Context) {
+		if d.now().Sub(lastPurge) >= _purgeInterval {
+			d.purge(ctx)
+			lastPurge = d.now()
+		}
+	})
+}
+
+// dispatch отправляет одну пачку доставок и возвращает ее размер
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	deliveries, err := d.repo.ClaimDeliveries(ctx, d.cfg.BatchSize, Lease(d.cfg))
+	if err != nil {
+		if ctx.Err() == nil {
+			d.log.ErrorContext(ctx, "unable to claim webhook deliveries", slog.Any("error", err))
+		}
+		return 0
+	}
+
+	// Взятые доставки отправляются и при остановке, иначе они ждали бы окончания lease
+	sendCtx := context.WithoutCancel(ctx)
+	sem := make(chan struct{}, d.cfg.Workers)
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			d.deliver(sendCtx, delivery)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries)
+}
+
+// Lease время, на которое берется пачка. Доставка возвращается в очередь, если инстанс не сохранил результат за lease.
+// Workers отправляют пачку волнами по Workers доставок, каждая волна может занять до Timeout,
+// поэтому lease покрывает все волны с запасом в одну волну и PollInterval
+func Lease(cfg config.Webhook) time.Duration {
+	workers := max(cfg.Workers, 1)
+	waves := (cfg.BatchSize + workers - 1) / workers
+	return time.Duration(waves+1)*cfg.Timeout + cfg.PollInterval
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery domain.WebhookDelivery) {
+	start := d.now()
+	status, err := d.send(ctx, delivery)
+	duration := d.now().Sub(start)
+
+	delivery.Attempts++
+	delivery.LastStatusCode = status
+	delivery.LastError = ""
+	log := d.log.With(
+		slog.Int("delivery_id", delivery.ID),
+		slog.Int("webhook_id", delivery.WebhookId),
+		slog.Int("chat_id", delivery.ChatId),
+		slog.String("event", string(delivery.EventType)),
+		slog.Int("attempt", delivery.Attempts),
+	)
+
+	var result string
+	switch {
+	case err == nil:
+		result = "delivered"
+		deliveredAt := d.now()
+		delivery.Status = domain.DeliveryDelivered
+		delivery.DeliveredAt = &deliveredAt
+		log.DebugContext(ctx, "webhook delivered", slog.Int("status", status))
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		result = "dead"
+		delivery.Status = domain.DeliveryDead
+		delivery.LastError = queue.ErrorText(err)
+		log.WarnContext(ctx, "webhook delivery failed, giving up", slog.Any("error", err))
+	default:
+		result = "retry"
+		delivery.NextAttemptAt = d.now().Add(Backoff(delivery.Attempts, d.cfg.BackoffBase, d.cfg.BackoffMax))
+		delivery.LastError = queue.ErrorText(err)
+		log.InfoContext(ctx, "webhook delivery failed, will retry", slog.Any("error", err), slog.Time("next_attempt_at", delivery.NextAttemptAt))
+	}
+	d.metrics.WebhookAttempt(string(delivery.EventType), result, duration)
+
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.ErrorContext(ctx, "unable to save webhook delivery result", slog.Any("error", err))
+	}
+}
+
+// send отправляет подписанное событие и возвращает статус ответа. Ошибка, если ответ не 2xx
+func (d *Dispatcher) send(ctx context.Context, delivery domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, d.now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, _maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) purge(ctx context.Context) {
+	if d.cfg.Retention <= 0 {
+		return
+	}
+	purged, err := d.repo.PurgeDeliveries(ctx, d.now().Add(-d.cfg.Retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			d.log.ErrorContext(ctx, "unable to purge webhook deliveries", slog.Any("error", err))
+		}
+		return
+	}
+	if purged > 0 {
+		d.log.InfoContext(ctx, "webhook deliveries purged", slog.Int64("count", purged))
+	}
+}
+
+// Backoff задержка перед повтором после attempt неудачных попыток: base, 2*base, 4*base... но не больше max
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/domain"
+	"chat-project/internal/logger"
+	"chat-project/internal/storage/memory"
+	"chat-project/pkg/client"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver запускает получателя, который отвечает status и передает запросы в канал
+func newReceiver(t *testing.T, status *atomic.Int32) (*httptest.Server, <-chan received) {
+	t.Helper()
+	requests := make(chan received, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func setup(t *testing.T, url string, cfg config.Webhook) (*Dispatcher, *memory.WebhookRepoMemory, domain.Webhook) {
+	t.Helper()
+	ctx := context.Background()
+	chats := memory.NewUserRepoMemory()
+	repo := memory.NewWebhookRepoMemory(chats)
+
+	chat, err := chats.CreateChat(ctx, domain.Chat{Title: "hooks"}, "")
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	webhook, err := repo.CreateWebhook(ctx, domain.Webhook{ChatId: chat.ID, URL: url, Secret: "whsec_test", CreatedBy: "owner"})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	return NewDispatcher(repo, cfg, logger.Discard(), nil), repo, webhook
+}
+
+func testConfig() config.Webhook {
+	// Получатели в тестах слушают loopback
+	return config.Webhook{Workers: 2, BatchSize: 10, Timeout: time.Second, MaxAttempts: 2, AllowPrivateAddresses: true}
+}
+
+func TestDispatcherDeliversSignedEvent(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusNoContent)
+	server, requests := newReceiver(t, &status)
+	d, repo, webhook := setup(t, server.URL, testConfig())
+	ctx := context.Background()
+
+	payload := []byte(`{"type":"message.created","chatId":1}`)
+	if _, err := repo.EnqueueEvent(ctx, domain.Event{Type: domain.EventMessageCreated, ChatId: webhook.ChatId}, payload); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if n := d.dispatch(ctx); n != 1 {
+		t.Fatalf("dispatched %d deliveries, want 1", n)
+	}
+
+	req := <-requests
+	if string(req.body) != string(payload) {
+		t.Fatalf("unexpected body %s", req.body)
+	}
+	if req.header.Get(EventHeader) != "message.created" || req.header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers %v", req.header)
+	}
+	if err := client.VerifyWebhookSignature("whsec_test", req.header.Get(SignatureHeader), req.body, time.Minute); err != nil {
+		t.Fatalf("verify signature: %v", err)
+	}
+	if err := client.VerifyWebhookSignature("other", req.header.Get(SignatureHeader), req.body, 0); err != client.InvalidSignatureError {
+		t.Fatalf("signature with wrong secret: %v", err)
+	}
+
+	deliveries, _ := repo.ListDeliveries(ctx, webhook.ChatId, webhook.ID, domain.DeliveryDelivered, 10, 0)
+	if len(deliveries) != 1 || deliveries[0].Attempts != 1 || deliveries[0].LastStatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected deliveries: %+v", deliveries)
+	}
+	if req.header.Get(DeliveryHeader) != strconv.Itoa(deliveries[0].ID) {
+		t.Fatalf("delivery header %q, want %d", req.header.Get(DeliveryHeader), deliveries[0].ID)
+	}
+}
+
+func TestDispatcherRetriesThenDeadLetters(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	server, requests := newReceiver(t, &status)
+	// BackoffBase в testConfig нулевой, поэтому повтор доступен сразу
+	d, repo, webhook := setup(t, server.URL, testConfig())
+	ctx := context.Background()
+
+	if _, err := repo.EnqueueEvent(ctx, domain.Event{Type: domain.EventChatUpdated, ChatId: webhook.ChatId}, []byte(`{}`)); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	d.dispatch(ctx)
+	<-requests
+	pending, _ := repo.ListDeliveries(ctx, webhook.ChatId, webhook.ID, domain.DeliveryPending, 10, 0)
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastStatusCode != 500 || pending[0].LastError == "" {
+		t.Fatalf("expected pending delivery after first failure, got %+v", pending)
+	}
+
+	d.dispatch(ctx)
+	<-requests
+	dead, _ := repo.ListDeliveries(ctx, webhook.ChatId, webhook.ID, domain.DeliveryDead, 10, 0)
+	if len(dead) != 1 || dead[0].Attempts != 2 {
+		t.Fatalf("expected dead delivery after max attempts, got %+v", dead)
+	}
+	if n := d.dispatch(ctx); n != 0 {
+		t.Fatalf("dead delivery dispatched again")
+	}
+
+	status.Store(http.StatusOK)
+	if _, err := repo.RetryDelivery(ctx, webhook.ChatId, webhook.ID, dead[0].ID); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	d.dispatch(ctx)
+	<-requests
+	delivered, _ := repo.ListDeliveries(ctx, webhook.ChatId, webhook.ID, domain.DeliveryDelivered, 10, 0)
+	if len(delivered) != 1 || delivered[0].DeliveredAt == nil {
+		t.Fatalf("expected delivered after manual retry, got %+v", delivered)
+	}
+}
+
+func TestDispatcherDoesNotFollowRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect followed")
+	}))
+	t.Cleanup(target.Close)
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(server.Close)
+
+	d, repo, webhook := setup(t, server.URL, testConfig())
+	ctx := context.Background()
+	if _, err := repo.EnqueueEvent(ctx, domain.Event{Type: domain.EventChatUpdated, ChatId: webhook.ChatId}, []byte(`{}`)); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	d.dispatch(ctx)
+
+	pending, _ := repo.ListDeliveries(ctx, webhook.ChatId, webhook.ID, domain.DeliveryPending, 10, 0)
+	if len(pending) != 1 || pending[0].LastStatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("expected failed attempt on redirect, got %+v", pending)
+	}
+}
+
+func TestDispatcherRejectsPrivateAddresses(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	server, requests := newReceiver(t, &status)
+
+	cfg := testConfig()
+	cfg.AllowPrivateAddresses = false
+	d, repo, webhook := setup(t, server.URL, cfg)
+	ctx := context.Background()
+	if _, err := repo.EnqueueEvent(ctx, domain.Event{Type: domain.EventChatUpdated, ChatId: webhook.ChatId}, []byte(`{}`)); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	d.dispatch(ctx)
+
+	select {
+	case <-requests:
+		t.Fatal("request sent to loopback receiver")
+	default:
+	}
+	pending, _ := repo.ListDeliveries(ctx, webhook.ChatId, webhook.ID, domain.DeliveryPending, 10, 0)
+	if len(pending) != 1 || !strings.Contains(pending[0].LastError, PrivateAddressError.Error()) {
+		t.Fatalf("expected private address error, got %+v", pending)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		private bool
+	}{
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://localhost/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://10.1.2.3/hook", true},
+		{"http://192.168.0.10/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://[::ffff:127.0.0.1]/hook", true},
+		{"http://0.0.0.0/hook", true},
+		{"http://100.64.0.1/hook", true},
+		{"http://100.127.255.254/hook", true},
+		{"http://192.0.0.8/hook", true},
+		{"http://100.128.0.1/hook", false},
+		{"https://203.0.113.10/hook", false},
+		{"https://[2001:db8::1]/hook", false},
+	}
+	for _, tt := range tests {
+		err := CheckURL(context.Background(), net.DefaultResolver, tt.url)
+		if got := errors.Is(err, PrivateAddressError); got != tt.private {
+			t.Errorf("CheckURL(%s) = %v, want private %v", tt.url, err, tt.private)
+		}
+	}
+}
+
+func TestCheckURLUnresolvable(t *testing.T) {
+	err := CheckURL(context.Background(), net.DefaultResolver, "https://webhook.invalid/hook")
+	if !errors.Is(err, UnresolvableHostError) || err.Error() != UnresolvableHostError.Error() {
+		t.Fatalf("expected bare UnresolvableHostError, got %v", err)
+	}
+}
+
+func TestDispatcherIgnoresProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://203.0.113.10:3128")
+	cfg := testConfig()
+	cfg.AllowPrivateAddresses = false
+	d := NewDispatcher(nil, cfg, logger.Discard(), nil)
+	if d.client.Transport.(*http.Transport).Proxy != nil {
+		t.Fatal("expected proxy disabled when private addresses are not allowed")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt, 10*time.Second, time.Hour); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestLeaseCoversWholeBatch(t *testing.T) {
+	tests := []struct {
+		batch, workers int
+		want           time.Duration
+	}{
+		// 50 доставок по 4 параллельно: 13 волн до 10s, плюс запас в волну и PollInterval
+		{50, 4, 141 * time.Second},
+		{4, 4, 21 * time.Second},
+		{5, 4, 31 * time.Second},
+		{1, 0, 21 * time.Second},
+	}
+	for _, tt := range tests {
+		cfg := config.Webhook{BatchSize: tt.batch, Workers: tt.workers, Timeout: 10 * time.Second, PollInterval: time.Second}
+		if got := Lease(cfg); got != tt.want {
+			t.Errorf("Lease(batch=%d, workers=%d) = %s, want %s", tt.batch, tt.workers, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Package webhook доставляет события чатов на адреса вебхуков из очереди в хранилище.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Заголовки запроса с событием. Получатель проверяет подпись и отбрасывает повторы по DeliveryHeader
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Sign возвращает значение SignatureHeader: "t=<unix>,v1=<hex>", где v1 - HMAC-SHA256
+// по секрету от строки "<unix>.<тело запроса>". Время в подписи не дает повторить старый запрос
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,5 @@
+alter table chats
+    drop column if exists version,
+    drop column if exists settings,
+    drop column if exists avatar_url,
+    drop column if exists description;
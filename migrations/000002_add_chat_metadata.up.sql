@@ -0,0 +1,5 @@
+alter table chats
+    add column description text not null default '',
+    add column avatar_url varchar(2048) not null default '',
+    add column settings jsonb not null default '{}',
+    add column version integer not null default 1;
@@ -0,0 +1,5 @@
+drop table if exists chat_members;
+
+alter table chats
+    drop column if exists direct_key,
+    drop column if exists type;
@@ -0,0 +1,13 @@
+alter table chats
+    add column type varchar(16) not null default 'group',
+    add column direct_key varchar(255) unique;
+
+create table chat_members (
+    chat_id integer not null references chats(id) on delete cascade,
+    user_id varchar(64) not null,
+    role varchar(16) not null default 'member',
+    joined_at timestamp with time zone default now(),
+    primary key (chat_id, user_id)
+);
+
+create index chat_members_user_id_idx on chat_members (user_id);
@@ -0,0 +1,4 @@
+drop table if exists chat_invites;
+
+alter table chats
+    drop column if exists private;
@@ -0,0 +1,18 @@
+alter table chats
+    add column private boolean not null default false;
+
+create table chat_invites (
+    id serial primary key,
+    chat_id integer not null references chats(id) on delete cascade,
+    token_hash bytea not null unique,
+    role varchar(16) not null,
+    max_uses integer,
+    uses integer not null default 0,
+    expires_at timestamp with time zone,
+    created_by varchar(64) not null,
+    created_at timestamp with time zone default now(),
+    revoked_at timestamp with time zone,
+    constraint chat_invites_uses_check check (max_uses is null or uses <= max_uses)
+);
+
+create index chat_invites_chat_id_idx on chat_invites (chat_id);
@@ -0,0 +1,2 @@
+drop index if exists messages_created_at_idx;
+alter table messages drop column kind;
@@ -0,0 +1,2 @@
+alter table messages add column kind varchar(16) not null default 'user';
+create index messages_created_at_idx on messages (created_at);
@@ -0,0 +1,4 @@
+drop index if exists messages_client_nonce_idx;
+alter table messages
+    drop column client_nonce,
+    drop column author_id;
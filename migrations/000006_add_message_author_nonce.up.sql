@@ -0,0 +1,4 @@
+alter table messages
+    add column author_id varchar(64) not null default '',
+    add column client_nonce varchar(64) not null default '';
+create unique index messages_client_nonce_idx on messages (chat_id, author_id, client_nonce) where client_nonce <> '';
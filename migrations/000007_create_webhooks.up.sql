@@ -0,0 +1,32 @@
+create table chat_webhooks (
+    id serial primary key,
+    chat_id integer not null references chats(id) on delete cascade,
+    url varchar(2048) not null,
+    secret varchar(128) not null,
+    events text[] not null default '{}',
+    created_by varchar(64) not null,
+    created_at timestamp with time zone default now()
+);
+
+create index chat_webhooks_chat_id_idx on chat_webhooks (chat_id);
+
+-- Адрес и секрет копируются в доставку, чтобы chat.deleted дошел после удаления чата вместе с его вебхуками
+create table webhook_deliveries (
+    id bigserial primary key,
+    webhook_id integer references chat_webhooks(id) on delete set null,
+    chat_id integer not null,
+    url varchar(2048) not null,
+    secret varchar(128) not null,
+    event_type varchar(64) not null,
+    payload jsonb not null,
+    status varchar(16) not null default 'pending',
+    attempts integer not null default 0,
+    next_attempt_at timestamp with time zone not null default now(),
+    last_status_code integer not null default 0,
+    last_error text not null default '',
+    created_at timestamp with time zone not null default now(),
+    delivered_at timestamp with time zone
+);
+
+create index webhook_deliveries_due_idx on webhook_deliveries (next_attempt_at) where status = 'pending';
+create index webhook_deliveries_webhook_id_idx on webhook_deliveries (webhook_id, id);
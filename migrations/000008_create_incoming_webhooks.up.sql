@@ -0,0 +1,10 @@
+create table chat_incoming_webhooks (
+    id serial primary key,
+    chat_id integer not null references chats(id) on delete cascade,
+    name varchar(64) not null,
+    token_hash bytea not null unique,
+    created_by varchar(64) not null,
+    created_at timestamp with time zone default now()
+);
+
+create index chat_incoming_webhooks_chat_id_idx on chat_incoming_webhooks (chat_id);
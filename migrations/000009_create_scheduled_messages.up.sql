@@ -0,0 +1,19 @@
+create table scheduled_messages (
+    id serial primary key,
+    chat_id integer not null references chats(id) on delete cascade,
+    kind varchar(16) not null default 'user',
+    author_id varchar(64) not null default '',
+    created_by varchar(64) not null default '',
+    text text not null,
+    send_at timestamp with time zone not null,
+    status varchar(16) not null default 'pending',
+    attempts integer not null default 0,
+    next_attempt_at timestamp with time zone not null,
+    locked_until timestamp with time zone,
+    last_error text not null default '',
+    created_at timestamp with time zone default now()
+);
+
+-- Очередь планировщика: только ожидающие отправки сообщения
+create index scheduled_messages_due_idx on scheduled_messages (next_attempt_at) where status = 'pending';
+create index scheduled_messages_chat_author_idx on scheduled_messages (chat_id, created_by);
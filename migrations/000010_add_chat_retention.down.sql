@@ -0,0 +1,2 @@
+drop index if exists messages_chat_created_at_idx;
+alter table chats drop column legal_hold;
@@ -0,0 +1,2 @@
+alter table chats add column legal_hold boolean not null default false;
+create index messages_chat_created_at_idx on messages (chat_id, created_at);
@@ -0,0 +1,2 @@
+drop index if exists messages_expires_at_idx;
+alter table messages drop column expires_at;
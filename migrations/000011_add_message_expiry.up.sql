@@ -0,0 +1,2 @@
+alter table messages add column expires_at timestamptz;
+create index messages_expires_at_idx on messages (expires_at) where expires_at is not null;
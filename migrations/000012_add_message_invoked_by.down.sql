@@ -0,0 +1,3 @@
+drop index if exists messages_client_nonce_idx;
+alter table messages drop column invoked_by;
+create unique index messages_client_nonce_idx on messages (chat_id, author_id, client_nonce) where client_nonce <> '';
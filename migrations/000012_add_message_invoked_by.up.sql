@@ -0,0 +1,3 @@
+alter table messages add column invoked_by varchar(64) not null default '';
+drop index if exists messages_client_nonce_idx;
+create unique index messages_client_nonce_idx on messages (chat_id, author_id, invoked_by, client_nonce) where client_nonce <> '';
@@ -0,0 +1,2 @@
+drop index if exists messages_scheduled_message_id_idx;
+alter table messages drop column scheduled_message_id;
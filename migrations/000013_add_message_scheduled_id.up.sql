@@ -0,0 +1,2 @@
+alter table messages add column scheduled_message_id integer;
+create unique index messages_scheduled_message_id_idx on messages (scheduled_message_id) where scheduled_message_id is not null;
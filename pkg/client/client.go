@@ -0,0 +1,165 @@
+// Package client Go клиент REST и SSE API чат-сервиса
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const userIDHeader = "X-User-Id"
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userID     string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient задает http клиент. Для SSE у клиента не должно быть общего Timeout,
+// иначе поток будет обрываться по таймауту
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserID передает идентификатор пользователя в заголовке X-User-Id
+func WithUserID(userID string) Option {
+	return func(c *Client) {
+		c.userID = userID
+	}
+}
+
+// New создает клиент для сервера baseURL, например http://localhost:8001
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreateChat создает чат, пользователь из WithUserID становится владельцем
+func (c *Client) CreateChat(ctx context.Context, input ChatInput) (*Chat, error) {
+	var chat Chat
+	if err := c.do(ctx, http.MethodPost, "/v1/chats/", input, &chat); err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// GetChat возвращает чат с последними сообщениями
+func (c *Client) GetChat(ctx context.Context, chatID int) (*Chat, error) {
+	var chat Chat
+	if err := c.do(ctx, http.MethodGet, chatPath(chatID), nil, &chat); err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// ListChats возвращает страницу публичных чатов
+func (c *Client) ListChats(ctx context.Context, limit, offset int) ([]Chat, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	path := "/v1/chats/"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp struct {
+		Chats []Chat `json:"chats"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Chats, nil
+}
+
+// AddMessage отправляет сообщение в чат
+func (c *Client) AddMessage(ctx context.Context, chatID int, text string) (*Message, error) {
+	return c.SendMessage(ctx, chatID, MessageInput{Text: text})
+}
+
+// SendMessage отправляет сообщение в чат. Повтор с тем же ClientNonce и текстом
+// возвращает уже сохраненное сообщение, с другим текстом - ошибку ConflictError
+func (c *Client) SendMessage(ctx context.Context, chatID int, in MessageInput) (*Message, error) {
+	var msg Message
+	if err := c.do(ctx, http.MethodPost, chatPath(chatID)+"/messages", in, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// DeleteChat удаляет чат, подписчики получают событие chat.deleted
+func (c *Client) DeleteChat(ctx context.Context, chatID int) error {
+	return c.do(ctx, http.MethodDelete, chatPath(chatID), nil, nil)
+}
+
+func chatPath(chatID int) string {
+	return "/v1/chats/" + strconv.Itoa(chatID)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.userID != "" {
+		req.Header.Set(userIDHeader, c.userID)
+	}
+	return req, nil
+}
+
+// do выполняет запрос с JSON телом in и декодирует успешный ответ в out
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("chat api: encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("chat api: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,586 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-project/config"
+	"chat-project/internal/commands"
+	"chat-project/internal/controllers/restapi"
+	"chat-project/internal/controllers/sse"
+	"chat-project/internal/logger"
+	"chat-project/internal/ratelimit"
+	"chat-project/internal/services"
+	"chat-project/internal/storage/memory"
+	"chat-project/internal/validation"
+)
+
+type testServer struct {
+	*Client
+	manager   *services.ChatListenerManager
+	webhooks  *memory.WebhookRepoMemory
+	service   *services.ChatService
+	scheduled *memory.ScheduledMessageRepoMemory
+	listener  *memory.ListenerMemory
+}
+
+// Сколько SSE подключений тестовый сервер разрешает одному адресу
+const maxConnections = 5
+
+// newTestServer запускает настоящие роутеры REST и SSE поверх хранилищ в памяти
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log := logger.Discard()
+	repo := memory.NewUserRepoMemory()
+	listener := memory.NewListenerMemory()
+	webhooks := memory.NewWebhookRepoMemory(repo)
+	scheduled := memory.NewScheduledMessageRepoMemory(repo)
+	validator := validation.New(config.Validation{})
+	registry := commands.NewRegistry()
+	if err := registry.Register(commands.NewHelp(registry), commands.Topic{}, commands.Poll{}, commands.NewRemind()); err != nil {
+		t.Fatalf("register commands: %v", err)
+	}
+	service := services.New(repo, webhooks, scheduled, listener, memory.NewTxManagerMemory(), registry, validator, ratelimit.NewMemory(), log, nil)
+	inviteService := services.NewInviteService(repo, memory.NewInviteRepoMemory(repo), log)
+	webhookService := services.NewWebhookService(repo, webhooks, validator, config.Webhook{AllowPrivateAddresses: true}, log)
+	incomingService := services.NewIncomingWebhookService(repo, memory.NewIncomingWebhookRepoMemory(repo), service, validator, log)
+	manager := services.NewChatListenerManager(repo, listener, config.Listener{GracePeriod: time.Minute, MaxConnectionsPerClient: maxConnections}, log, nil)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	restapi.NewRouter(r, log, service, inviteService, webhookService, incomingService)
+	sse.NewRouter(r, log, nil, manager, service)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	t.Cleanup(manager.Close)
+
+	return &testServer{Client: New(server.URL, WithUserID("alice")), manager: manager, webhooks: webhooks, service: service, scheduled: scheduled, listener: listener}
+}
+
+func (s *testServer) clients(chatID int) int {
+	for _, stats := range s.manager.Stats() {
+		if stats.ChatId == chatID {
+			return stats.Clients
+		}
+	}
+	return 0
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func nextEvent(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatalf("subscription closed: %v", sub.Err())
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+	return Event{}
+}
+
+func TestChatLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "general", Description: "demo"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if chat.ID == 0 || chat.Title != "general" || chat.Version != 1 || chat.CreatedAt.IsZero() {
+		t.Fatalf("unexpected chat: %+v", chat)
+	}
+
+	msg, err := s.AddMessage(ctx, chat.ID, "hello")
+	if err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	if msg.ChatID != chat.ID || msg.Text != "hello" || msg.Kind != MessageKindUser {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	got, err := s.GetChat(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("get chat: %v", err)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].ID != msg.ID {
+		t.Fatalf("unexpected messages: %+v", got.Messages)
+	}
+
+	chats, err := s.ListChats(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("list chats: %v", err)
+	}
+	if len(chats) != 1 || chats[0].ID != chat.ID {
+		t.Fatalf("unexpected chats: %+v", chats)
+	}
+
+	if err := s.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+	if _, err := s.GetChat(ctx, chat.ID); !errors.Is(err, NotFoundError) {
+		t.Fatalf("expected NotFoundError after delete, got %v", err)
+	}
+}
+
+func TestDeleteOwnerlessChat(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	anonymous := New(s.baseURL)
+
+	// Чат, созданный без X-User-Id, удаляет любой клиент, как до появления владельцев
+	chat, err := anonymous.CreateChat(ctx, ChatInput{Title: "ownerless"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if err := anonymous.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("anonymous delete of ownerless chat: %v", err)
+	}
+	if _, err := s.GetChat(ctx, chat.ID); !errors.Is(err, NotFoundError) {
+		t.Fatalf("expected NotFoundError after delete, got %v", err)
+	}
+
+	// У чата с владельцем удалить его может только владелец
+	owned, err := s.CreateChat(ctx, ChatInput{Title: "owned"})
+	if err != nil {
+		t.Fatalf("create owned chat: %v", err)
+	}
+	var apiErr *APIError
+	if err := anonymous.DeleteChat(ctx, owned.ID); !errors.Is(err, ForbiddenError) || !errors.As(err, &apiErr) || apiErr.Code != "not_chat_manager" {
+		t.Fatalf("expected not_chat_manager for anonymous delete, got %v", err)
+	}
+	if err := s.DeleteChat(ctx, owned.ID); err != nil {
+		t.Fatalf("owner delete: %v", err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	_, err := s.CreateChat(ctx, ChatInput{})
+	if !errors.Is(err, BadRequestError) {
+		t.Fatalf("expected BadRequestError, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || apiErr.Code != "validation_failed" || apiErr.Message == "" {
+		t.Fatalf("expected APIError with code and message, got %#v", err)
+	}
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "Title" || apiErr.Fields[0].Code != "required" {
+		t.Fatalf("expected Title field error, got %#v", apiErr.Fields)
+	}
+
+	_, err = s.AddMessage(ctx, 42, "hello")
+	if !errors.Is(err, NotFoundError) || !errors.As(err, &apiErr) || apiErr.Code != "chat_not_found" {
+		t.Fatalf("expected chat_not_found, got %#v", err)
+	}
+
+	if err := s.DeleteChat(ctx, 42); !errors.Is(err, NotFoundError) {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+	if _, err := s.Subscribe(ctx, 42, SubscribeOptions{}); !errors.Is(err, NotFoundError) {
+		t.Fatalf("expected NotFoundError on subscribe, got %v", err)
+	}
+}
+
+func TestSlowMode(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "slow", Settings: &ChatSettings{SlowModeSeconds: 60}})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if _, err := s.AddMessage(ctx, chat.ID, "first"); err != nil {
+		t.Fatalf("first message: %v", err)
+	}
+
+	_, err = s.AddMessage(ctx, chat.ID, "second")
+	var apiErr *APIError
+	if !errors.Is(err, TooManyRequestsError) || !errors.As(err, &apiErr) || apiErr.Code != "slow_mode" {
+		t.Fatalf("expected slow_mode, got %#v", err)
+	}
+	if apiErr.RetryAfter <= 0 || apiErr.RetryAfter > time.Minute {
+		t.Fatalf("unexpected retry after %s", apiErr.RetryAfter)
+	}
+
+	// Медленный режим действует и по адресу, поэтому другой X-User-Id с того же адреса его не обходит
+	if _, err := New(s.baseURL, WithUserID("mallory")).AddMessage(ctx, chat.ID, "third"); !errors.Is(err, TooManyRequestsError) {
+		t.Fatalf("expected slow mode for another user from the same address, got %v", err)
+	}
+}
+
+func TestSubscribeReceivesEvents(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "live"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	sub, err := s.Subscribe(ctx, chat.ID, SubscribeOptions{})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+	waitFor(t, func() bool { return s.clients(chat.ID) == 1 })
+
+	msg, err := s.AddMessage(ctx, chat.ID, "hi")
+	if err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	event := nextEvent(t, sub)
+	if event.Type != EventMessage || event.Message == nil || event.Message.Text != "hi" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if event.ID != strconv.Itoa(msg.ID) || sub.LastEventID() != event.ID {
+		t.Fatalf("event id %q, last event id %q, want %d", event.ID, sub.LastEventID(), msg.ID)
+	}
+
+	if err := s.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+	if event := nextEvent(t, sub); event.Type != EventChatDeleted {
+		t.Fatalf("expected %s, got %+v", EventChatDeleted, event)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Fatal("subscription should end after chat deletion")
+	}
+	if err := sub.Err(); err != nil {
+		t.Fatalf("unexpected subscription error: %v", err)
+	}
+}
+
+func TestSubscribeConnectionLimit(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "crowded"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	// Подключения считаются по адресу, поэтому смена X-User-Id не обходит лимит
+	subs := make([]*Subscription, 0, maxConnections)
+	for i := range maxConnections {
+		sub, err := New(s.baseURL, WithUserID("user-"+strconv.Itoa(i))).Subscribe(ctx, chat.ID, SubscribeOptions{})
+		if err != nil {
+			t.Fatalf("subscribe %d: %v", i, err)
+		}
+		defer sub.Close()
+		subs = append(subs, sub)
+	}
+
+	_, err = New(s.baseURL, WithUserID("mallory")).Subscribe(ctx, chat.ID, SubscribeOptions{})
+	var apiErr *APIError
+	if !errors.Is(err, TooManyRequestsError) || !errors.As(err, &apiErr) || apiErr.Code != "too_many_connections" {
+		t.Fatalf("expected too_many_connections, got %v", err)
+	}
+
+	subs[0].Close()
+	waitFor(t, func() bool {
+		sub, err := s.Subscribe(ctx, chat.ID, SubscribeOptions{})
+		if err != nil {
+			return false
+		}
+		sub.Close()
+		return true
+	})
+}
+
+func TestSendMessageClientNonce(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "nonce"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	sub, err := s.Subscribe(ctx, chat.ID, SubscribeOptions{})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+	waitFor(t, func() bool { return s.clients(chat.ID) == 1 })
+
+	in := MessageInput{Text: "draft", ClientNonce: "n-1"}
+	msg, err := s.SendMessage(ctx, chat.ID, in)
+	if err != nil {
+		t.Fatalf("send message: %v", err)
+	}
+	if msg.ClientNonce != "n-1" || msg.AuthorID != "alice" {
+		t.Fatalf("nonce or author not echoed: %+v", msg)
+	}
+	event := nextEvent(t, sub)
+	if event.Message == nil || event.Message.ClientNonce != "n-1" || event.Message.AuthorID != "alice" {
+		t.Fatalf("nonce or author not in event: %+v", event)
+	}
+
+	retry, err := s.SendMessage(ctx, chat.ID, in)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if retry.ID != msg.ID {
+		t.Fatalf("retry created message %d, want %d", retry.ID, msg.ID)
+	}
+
+	_, err = s.SendMessage(ctx, chat.ID, MessageInput{Text: "other", ClientNonce: "n-1"})
+	var apiErr *APIError
+	if !errors.Is(err, ConflictError) || !errors.As(err, &apiErr) || apiErr.Code != "duplicate_client_nonce" {
+		t.Fatalf("expected duplicate_client_nonce, got %v", err)
+	}
+
+	bob := New(s.baseURL, WithUserID("bob"))
+	if _, err := bob.SendMessage(ctx, chat.ID, MessageInput{Text: "other", ClientNonce: "n-1"}); err != nil {
+		t.Fatalf("same nonce from another author: %v", err)
+	}
+
+	_, err = New(s.baseURL).SendMessage(ctx, chat.ID, MessageInput{Text: "anonymous", ClientNonce: "n-2"})
+	if !errors.Is(err, UnauthorizedError) || !errors.As(err, &apiErr) || apiErr.Code != "user_id_required" {
+		t.Fatalf("expected user_id_required for anonymous nonce, got %v", err)
+	}
+}
+
+func TestSubscribeResumesFromLastEventID(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "resume"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	var ids []int
+	for _, text := range []string{"one", "two", "three"} {
+		msg, err := s.AddMessage(ctx, chat.ID, text)
+		if err != nil {
+			t.Fatalf("add message: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	sub, err := s.Subscribe(ctx, chat.ID, SubscribeOptions{LastEventID: strconv.Itoa(ids[0])})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	for _, want := range []string{"two", "three"} {
+		event := nextEvent(t, sub)
+		if event.Message == nil || event.Message.Text != want {
+			t.Fatalf("expected replayed %q, got %+v", want, event)
+		}
+	}
+}
+
+func TestSubscribeReconnects(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "flaky"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	var disconnects, reconnects atomic.Int32
+	sub, err := s.Subscribe(ctx, chat.ID, SubscribeOptions{
+		ReconnectDelay: 10 * time.Millisecond,
+		OnDisconnect:   func() { disconnects.Add(1) },
+		OnReconnect:    func() { reconnects.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+	waitFor(t, func() bool { return s.clients(chat.ID) == 1 })
+
+	if _, err := s.AddMessage(ctx, chat.ID, "before"); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	nextEvent(t, sub)
+
+	// Сервер обрывает поток, сообщение во время разрыва должно прийти после переподключения
+	if err := s.manager.CloseChat(chat.ID); err != nil {
+		t.Fatalf("close chat: %v", err)
+	}
+	if _, err := s.AddMessage(ctx, chat.ID, "during"); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	if event := nextEvent(t, sub); event.Message == nil || event.Message.Text != "during" {
+		t.Fatalf("expected message sent during disconnect, got %+v", event)
+	}
+
+	waitFor(t, func() bool { return s.clients(chat.ID) == 1 })
+	if _, err := s.AddMessage(ctx, chat.ID, "after"); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	if event := nextEvent(t, sub); event.Message == nil || event.Message.Text != "after" {
+		t.Fatalf("expected message after reconnect, got %+v", event)
+	}
+	if disconnects.Load() != 1 || reconnects.Load() != 1 {
+		t.Fatalf("expected one disconnect and reconnect, got %d and %d", disconnects.Load(), reconnects.Load())
+	}
+}
+
+func TestSubscribeStopsOnForbidden(t *testing.T) {
+	// Первое подключение успешно, затем сервер отвечает 429, который повторяется, и 403 после исключения из чата
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, ": connected\n\n")
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `{"code":"not_chat_member","detail":"user is not a member of the private chat"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	sub, err := New(server.URL).Subscribe(context.Background(), 1, SubscribeOptions{ReconnectDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	select {
+	case _, ok := <-sub.Events():
+		if ok {
+			t.Fatal("unexpected event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription kept reconnecting after 403")
+	}
+	var apiErr *APIError
+	if err := sub.Err(); !errors.Is(err, ForbiddenError) || !errors.As(err, &apiErr) || apiErr.Code != "not_chat_member" {
+		t.Fatalf("expected forbidden APIError, got %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Fatalf("expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestSlashCommands(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "commands"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+
+	help, err := s.AddMessage(ctx, chat.ID, "/help")
+	if err != nil {
+		t.Fatalf("help: %v", err)
+	}
+	if !help.Ephemeral || help.ID != 0 || help.Kind != MessageKindBot || !strings.Contains(help.Text, "/poll") {
+		t.Fatalf("unexpected help reply: %+v", help)
+	}
+	unknown, err := s.AddMessage(ctx, chat.ID, "/deploy prod")
+	if err != nil || !unknown.Ephemeral {
+		t.Fatalf("unexpected reply to unknown command: %+v, %v", unknown, err)
+	}
+
+	poll, err := s.AddMessage(ctx, chat.ID, "/poll Lunch? | Pizza | Sushi")
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if poll.Ephemeral || poll.ID == 0 || poll.Kind != MessageKindBot || poll.AuthorID != "poll" {
+		t.Fatalf("unexpected poll message: %+v", poll)
+	}
+
+	if _, err := s.AddMessage(ctx, chat.ID, "/topic Release v2"); err != nil {
+		t.Fatalf("topic: %v", err)
+	}
+	escaped, err := s.AddMessage(ctx, chat.ID, "//help is plain text")
+	if err != nil || escaped.Text != "/help is plain text" || escaped.Kind != MessageKindUser {
+		t.Fatalf("unexpected escaped message: %+v, %v", escaped, err)
+	}
+
+	got, err := s.GetChat(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("get chat: %v", err)
+	}
+	if got.Description != "Release v2" {
+		t.Fatalf("topic not set: %q", got.Description)
+	}
+	// Сами команды и ответы только для отправителя не сохраняются
+	texts := make([]string, 0, len(got.Messages))
+	for _, msg := range got.Messages {
+		texts = append(texts, msg.Text)
+	}
+	if len(texts) != 3 || texts[0] != poll.Text || texts[1] != "alice set the topic: Release v2" || texts[2] != escaped.Text {
+		t.Fatalf("unexpected messages: %q", texts)
+	}
+}
+
+func TestPrivateChatAccess(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "secret", Private: true})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if _, err := s.AddMessage(ctx, chat.ID, "hello"); err != nil {
+		t.Fatalf("owner add message: %v", err)
+	}
+	if _, err := s.GetChat(ctx, chat.ID); err != nil {
+		t.Fatalf("owner get chat: %v", err)
+	}
+
+	for name, c := range map[string]*Client{
+		"stranger":  New(s.baseURL, WithUserID("bob")),
+		"anonymous": New(s.baseURL),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var apiErr *APIError
+			if _, err := c.GetChat(ctx, chat.ID); !errors.Is(err, ForbiddenError) || !errors.As(err, &apiErr) || apiErr.Code != "not_chat_member" {
+				t.Fatalf("expected not_chat_member on get, got %v", err)
+			}
+			if _, err := c.AddMessage(ctx, chat.ID, "hi"); !errors.Is(err, ForbiddenError) {
+				t.Fatalf("expected ForbiddenError on add message, got %v", err)
+			}
+			if _, err := c.Subscribe(ctx, chat.ID, SubscribeOptions{}); !errors.Is(err, ForbiddenError) {
+				t.Fatalf("expected ForbiddenError on subscribe, got %v", err)
+			}
+			if err := c.DeleteChat(ctx, chat.ID); err == nil {
+				t.Fatal("expected delete to be rejected")
+			}
+		})
+	}
+
+	if err := s.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("owner delete chat: %v", err)
+	}
+
+	// Приватный чат без владельца был бы недоступен никому
+	if _, err := New(s.baseURL).CreateChat(ctx, ChatInput{Title: "orphan", Private: true}); !errors.Is(err, UnauthorizedError) {
+		t.Fatalf("expected UnauthorizedError for anonymous private chat, got %v", err)
+	}
+}
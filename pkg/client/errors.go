@@ -0,0 +1,119 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Ошибки по классам ответа сервера, проверяются через errors.Is
+var (
+	BadRequestError         = errors.New("bad request")
+	UnauthorizedError       = errors.New("unauthorized")
+	ForbiddenError          = errors.New("forbidden")
+	NotFoundError           = errors.New("not found")
+	ConflictError           = errors.New("conflict")
+	GoneError               = errors.New("gone")
+	PreconditionFailedError = errors.New("precondition failed")
+	TooManyRequestsError    = errors.New("too many requests")
+	ServerError             = errors.New("server error")
+	UnavailableError        = errors.New("service unavailable")
+)
+
+// APIError ответ сервера с неуспешным статусом
+type APIError struct {
+	StatusCode int
+	// Машиночитаемый код ошибки, если сервер его вернул
+	Code    string
+	Message string
+	// Ошибки отдельных полей для кода validation_failed
+	Fields []FieldError
+	// Через сколько можно повторить запрос, из заголовка Retry-After ответа 429
+	RetryAfter time.Duration
+}
+
+// FieldError ошибка проверки одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("chat api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("chat api: %d %s", e.StatusCode, e.Message)
+}
+
+// Is сопоставляет ошибку с классом по статусу ответа
+func (e *APIError) Is(target error) bool {
+	return statusError(e.StatusCode) == target
+}
+
+func statusError(status int) error {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return BadRequestError
+	case http.StatusUnauthorized:
+		return UnauthorizedError
+	case http.StatusForbidden:
+		return ForbiddenError
+	case http.StatusNotFound:
+		return NotFoundError
+	case http.StatusConflict:
+		return ConflictError
+	case http.StatusGone:
+		return GoneError
+	case http.StatusPreconditionFailed:
+		return PreconditionFailedError
+	case http.StatusTooManyRequests:
+		return TooManyRequestsError
+	case http.StatusServiceUnavailable:
+		return UnavailableError
+	}
+	if status >= 500 {
+		return ServerError
+	}
+	return nil
+}
+
+// errorBody поддерживает {"error": "..."} и problem+json (code, detail, title)
+type errorBody struct {
+	Error  string       `json:"error"`
+	Code   string       `json:"code"`
+	Detail string       `json:"detail"`
+	Title  string       `json:"title"`
+	Errors []FieldError `json:"errors"`
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil || len(data) == 0 {
+		return apiErr
+	}
+
+	var body errorBody
+	if err := json.Unmarshal(data, &body); err != nil {
+		apiErr.Message = string(data)
+		return apiErr
+	}
+	apiErr.Code = body.Code
+	apiErr.Fields = body.Errors
+	for _, msg := range []string{body.Detail, body.Error, body.Title} {
+		if msg != "" {
+			apiErr.Message = msg
+			break
+		}
+	}
+	return apiErr
+}
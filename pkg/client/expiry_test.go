@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chat-project/internal/domain"
+)
+
+func TestSelfDestructingMessage(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "secrets"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	sub, err := s.Subscribe(ctx, chat.ID, SubscribeOptions{})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+	waitFor(t, func() bool { return s.clients(chat.ID) == 1 })
+
+	before := time.Now().Truncate(time.Second)
+	msg, err := s.SendMessage(ctx, chat.ID, MessageInput{Text: "password", TTLSeconds: 60})
+	if err != nil {
+		t.Fatalf("send message: %v", err)
+	}
+	if msg.ExpiresAt == nil || msg.ExpiresAt.Before(before.Add(time.Minute)) || msg.ExpiresAt.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("unexpected ExpiresAt: %v", msg.ExpiresAt)
+	}
+	event := nextEvent(t, sub)
+	if event.Message == nil || event.Message.ExpiresAt == nil {
+		t.Fatalf("message event without ExpiresAt: %+v", event)
+	}
+	got, err := s.GetChat(ctx, chat.ID)
+	if err != nil || len(got.Messages) != 1 || got.Messages[0].ExpiresAt == nil {
+		t.Fatalf("get chat: %+v, %v", got, err)
+	}
+
+	// Событие публикуется так же, как его публикует ExpiryService после удаления
+	if err := s.listener.Publish(ctx, chat.ID, domain.NewMessagesExpiredEvent(chat.ID, []int{msg.ID})); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	event = nextEvent(t, sub)
+	if event.Type != EventMessageExpired || len(event.MessageIDs) != 1 || event.MessageIDs[0] != msg.ID {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// IncomingWebhook входящий вебхук чата
+type IncomingWebhook struct {
+	ID     int    `json:"Id"`
+	ChatID int    `json:"ChatId"`
+	Name   string `json:"Name"`
+	// Токен и путь для публикации, сервер возвращает их только при создании
+	Token     string    `json:"Token,omitempty"`
+	Path      string    `json:"Path,omitempty"`
+	CreatedBy string    `json:"CreatedBy"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
+
+// CreateIncomingWebhook создает входящий вебхук. Token в ответе нужно сохранить, повторно он не выдается
+func (c *Client) CreateIncomingWebhook(ctx context.Context, chatID int, name string) (*IncomingWebhook, error) {
+	in := struct {
+		Name string `json:"Name"`
+	}{Name: name}
+	var webhook IncomingWebhook
+	if err := c.do(ctx, http.MethodPost, chatPath(chatID)+"/incoming-webhooks", in, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListIncomingWebhooks возвращает входящие вебхуки чата
+func (c *Client) ListIncomingWebhooks(ctx context.Context, chatID int) ([]IncomingWebhook, error) {
+	var resp struct {
+		Webhooks []IncomingWebhook `json:"webhooks"`
+	}
+	if err := c.do(ctx, http.MethodGet, chatPath(chatID)+"/incoming-webhooks", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Webhooks, nil
+}
+
+// DeleteIncomingWebhook удаляет входящий вебхук, его токен перестает действовать
+func (c *Client) DeleteIncomingWebhook(ctx context.Context, chatID, webhookID int) error {
+	return c.do(ctx, http.MethodDelete, chatPath(chatID)+"/incoming-webhooks/"+strconv.Itoa(webhookID), nil, nil)
+}
+
+// PostIncomingMessage публикует сообщение по токену входящего вебхука
+func (c *Client) PostIncomingMessage(ctx context.Context, token, text string) (*Message, error) {
+	in := struct {
+		Text string `json:"text"`
+	}{Text: text}
+	var msg Message
+	if err := c.do(ctx, http.MethodPost, "/v1/hooks/"+url.PathEscape(token), in, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestIncomingWebhook(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "ci", Settings: &ChatSettings{SlowModeSeconds: 60}})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	_, err = New(s.baseURL, WithUserID("bob")).CreateIncomingWebhook(ctx, chat.ID, "ci")
+	if !errors.Is(err, ForbiddenError) {
+		t.Fatalf("expected forbidden for non-manager, got %v", err)
+	}
+
+	hook, err := s.CreateIncomingWebhook(ctx, chat.ID, "ci")
+	if err != nil {
+		t.Fatalf("create incoming webhook: %v", err)
+	}
+	if hook.Token == "" || hook.Path != "/v1/hooks/"+hook.Token {
+		t.Fatalf("unexpected incoming webhook: %+v", hook)
+	}
+	hooks, err := s.ListIncomingWebhooks(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("list incoming webhooks: %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].ID != hook.ID || hooks[0].Token != "" {
+		t.Fatalf("unexpected incoming webhooks: %+v", hooks)
+	}
+
+	// Медленный режим к ботам не применяется
+	for _, text := range []string{"build started", "build passed"} {
+		msg, err := New(s.baseURL).PostIncomingMessage(ctx, hook.Token, text)
+		if err != nil {
+			t.Fatalf("post %q: %v", text, err)
+		}
+		if msg.Kind != MessageKindBot || msg.AuthorID != "ci" || msg.Text != text || msg.ChatID != chat.ID {
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	}
+
+	// Slack-совместимое тело: text заменяется текстом blocks, вложения добавляются в конец
+	slack := `{"text":"fallback","blocks":[{"type":"header","text":{"type":"plain_text","text":"Deploy"}},` +
+		`{"type":"section","text":{"type":"mrkdwn","text":"*prod* is live"}},{"type":"divider"}],` +
+		`"attachments":[{"fallback":"v1.2.3 released"}]}`
+	msg := postRaw(t, s.baseURL+hook.Path, "application/json", slack)
+	if msg.Text != "Deploy\n*prod* is live\nv1.2.3 released" {
+		t.Fatalf("unexpected slack message text: %q", msg.Text)
+	}
+	form := url.Values{"payload": {`{"text":"from form"}`}}.Encode()
+	if msg := postRaw(t, s.baseURL+hook.Path, "application/x-www-form-urlencoded", form); msg.Text != "from form" {
+		t.Fatalf("unexpected form message text: %q", msg.Text)
+	}
+
+	_, err = s.PostIncomingMessage(ctx, hook.Token, "  ")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "validation_failed" {
+		t.Fatalf("expected validation_failed for empty text, got %v", err)
+	}
+
+	if err := s.DeleteIncomingWebhook(ctx, chat.ID, hook.ID); err != nil {
+		t.Fatalf("delete incoming webhook: %v", err)
+	}
+	_, err = s.PostIncomingMessage(ctx, hook.Token, "after delete")
+	if !errors.As(err, &apiErr) || apiErr.Code != "incoming_webhook_not_found" {
+		t.Fatalf("expected incoming_webhook_not_found, got %v", err)
+	}
+}
+
+func postRaw(t *testing.T, target, contentType, body string) Message {
+	t.Helper()
+	resp, err := http.Post(target, contentType, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	var msg Message
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatalf("decode message: %v", err)
+	}
+	return msg
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ScheduledMessage сообщение или напоминание, которое сервер отправит в SendAt
+type ScheduledMessage struct {
+	ID       int       `json:"Id"`
+	ChatID   int       `json:"ChatId"`
+	Kind     string    `json:"Kind"`
+	Text     string    `json:"Text"`
+	AuthorID string    `json:"AuthorId"`
+	SendAt   time.Time `json:"SendAt"`
+	// pending или failed. Отправленное сообщение пропадает из списка
+	Status    string    `json:"Status"`
+	Attempts  int       `json:"Attempts"`
+	LastError string    `json:"LastError,omitempty"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
+
+// Статусы запланированных сообщений
+const (
+	ScheduledPending = "pending"
+	ScheduledFailed  = "failed"
+)
+
+// ScheduledMessageUpdate изменение запланированного сообщения. nil поля не меняются
+type ScheduledMessageUpdate struct {
+	Text   *string    `json:"Text,omitempty"`
+	SendAt *time.Time `json:"SendAt,omitempty"`
+}
+
+// ScheduleMessage планирует сообщение на время sendAt. Нужен WithUserID
+func (c *Client) ScheduleMessage(ctx context.Context, chatID int, text string, sendAt time.Time) (*ScheduledMessage, error) {
+	in := struct {
+		Text   string    `json:"Text"`
+		SendAt time.Time `json:"SendAt"`
+	}{Text: text, SendAt: sendAt}
+	var msg ScheduledMessage
+	if err := c.do(ctx, http.MethodPost, chatPath(chatID)+"/messages", in, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ListScheduledMessages возвращает запланированные сообщения пользователя в чате, ближайшие первыми
+func (c *Client) ListScheduledMessages(ctx context.Context, chatID int) ([]ScheduledMessage, error) {
+	var resp struct {
+		Messages []ScheduledMessage `json:"messages"`
+	}
+	if err := c.do(ctx, http.MethodGet, chatPath(chatID)+"/scheduled-messages", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Messages, nil
+}
+
+// UpdateScheduledMessage меняет текст или время отправки
+func (c *Client) UpdateScheduledMessage(ctx context.Context, chatID, id int, update ScheduledMessageUpdate) (*ScheduledMessage, error) {
+	var msg ScheduledMessage
+	if err := c.do(ctx, http.MethodPatch, chatPath(chatID)+"/scheduled-messages/"+strconv.Itoa(id), update, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// CancelScheduledMessage отменяет сообщение, которое еще не отправлено
+func (c *Client) CancelScheduledMessage(ctx context.Context, chatID, id int) error {
+	return c.do(ctx, http.MethodDelete, chatPath(chatID)+"/scheduled-messages/"+strconv.Itoa(id), nil, nil)
+}
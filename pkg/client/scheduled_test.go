@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduledMessages(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "standup"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	sendAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	_, err = New(s.baseURL).ScheduleMessage(ctx, chat.ID, "anonymous", sendAt)
+	if !errors.Is(err, UnauthorizedError) {
+		t.Fatalf("expected unauthorized without user, got %v", err)
+	}
+	_, err = s.ScheduleMessage(ctx, chat.ID, "too late", time.Now().Add(-time.Minute))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_send_at" {
+		t.Fatalf("expected invalid_send_at, got %v", err)
+	}
+	_, err = s.ScheduleMessage(ctx, chat.ID, "/poll Lunch? | Pizza | Sushi", sendAt)
+	if !errors.As(err, &apiErr) || apiErr.Code != "scheduled_command" {
+		t.Fatalf("expected scheduled_command, got %v", err)
+	}
+
+	scheduled, err := s.ScheduleMessage(ctx, chat.ID, "good morning", sendAt)
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	if scheduled.ID == 0 || scheduled.Status != ScheduledPending || !scheduled.SendAt.Equal(sendAt) || scheduled.AuthorID != "alice" {
+		t.Fatalf("unexpected scheduled message: %+v", scheduled)
+	}
+	if _, err = s.AddMessage(ctx, chat.ID, "/remind 10m standup"); err != nil {
+		t.Fatalf("remind: %v", err)
+	}
+
+	messages, err := s.ListScheduledMessages(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Kind != MessageKindBot || messages[0].AuthorID != "remind" || messages[1].ID != scheduled.ID {
+		t.Fatalf("unexpected scheduled messages: %+v", messages)
+	}
+	if others, err := New(s.baseURL, WithUserID("bob")).ListScheduledMessages(ctx, chat.ID); err != nil || len(others) != 0 {
+		t.Fatalf("messages of other users must be hidden: %+v, %v", others, err)
+	}
+
+	text := "good morning, team"
+	updated, err := s.UpdateScheduledMessage(ctx, chat.ID, scheduled.ID, ScheduledMessageUpdate{Text: &text})
+	if err != nil || updated.Text != text || !updated.SendAt.Equal(sendAt) {
+		t.Fatalf("update: %+v, %v", updated, err)
+	}
+	if err = s.CancelScheduledMessage(ctx, chat.ID, messages[0].ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err = s.CancelScheduledMessage(ctx, chat.ID, messages[0].ID); !errors.Is(err, NotFoundError) {
+		t.Fatalf("expected not found after cancel, got %v", err)
+	}
+
+	// Повторная отправка после падения планировщика не создает второе сообщение
+	stored, err := s.scheduled.GetScheduledMessage(ctx, chat.ID, scheduled.ID, "alice")
+	if err != nil {
+		t.Fatalf("get stored: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = s.service.SendScheduled(ctx, stored); err != nil {
+			t.Fatalf("send scheduled: %v", err)
+		}
+	}
+	got, err := s.GetChat(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("get chat: %v", err)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Text != text || got.Messages[0].AuthorID != "alice" {
+		t.Fatalf("unexpected chat messages: %+v", got.Messages)
+	}
+}
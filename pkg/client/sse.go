@@ -0,0 +1,297 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReconnectDelay    = time.Second
+	defaultMaxReconnectDelay = 30 * time.Second
+	subscriptionBufferSize   = 16
+)
+
+type SubscribeOptions struct {
+	// Продолжить поток после события с этим ID: сервер дошлет пропущенные сообщения
+	LastEventID string
+	// Пауза перед переподключением, удваивается после каждой неудачной попытки до MaxReconnectDelay.
+	// Сервер может изменить ее полем retry.
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+	// Вызываются из горутины подписки при разрыве потока и после успешного переподключения
+	OnDisconnect func()
+	OnReconnect  func()
+}
+
+// Subscription SSE подписка на события чата с автоматическим переподключением.
+// При переподключении передается Last-Event-ID, поэтому сообщения, отправленные во время разрыва, не теряются.
+type Subscription struct {
+	client *Client
+	chatID int
+	opts   SubscribeOptions
+
+	events chan Event
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu          sync.Mutex
+	lastEventID string
+	err         error
+}
+
+// Subscribe подключается к потоку событий чата. Ошибка первого подключения возвращается сразу,
+// дальнейшие разрывы обрабатываются переподключением. Подписка завершается при отмене ctx,
+// вызове Close, удалении чата или ответе 4xx при переподключении, кроме 408 и 429.
+func (c *Client) Subscribe(ctx context.Context, chatID int, opts SubscribeOptions) (*Subscription, error) {
+	if opts.ReconnectDelay <= 0 {
+		opts.ReconnectDelay = defaultReconnectDelay
+	}
+	if opts.MaxReconnectDelay < opts.ReconnectDelay {
+		opts.MaxReconnectDelay = max(defaultMaxReconnectDelay, opts.ReconnectDelay)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Subscription{
+		client:      c,
+		chatID:      chatID,
+		opts:        opts,
+		events:      make(chan Event, subscriptionBufferSize),
+		cancel:      cancel,
+		done:        make(chan struct{}),
+		lastEventID: opts.LastEventID,
+	}
+
+	resp, err := s.connect(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go s.run(ctx, resp)
+	return s, nil
+}
+
+// Events закрывается после завершения подписки, причину возвращает Err
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err возвращает ошибку, завершившую подписку, или nil при штатном завершении
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// LastEventID возвращает ID последнего полученного события
+func (s *Subscription) LastEventID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastEventID
+}
+
+// Close останавливает подписку и ждет ее завершения
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *Subscription) connect(ctx context.Context) (*http.Response, error) {
+	req, err := s.client.newRequest(ctx, http.MethodGet, "/sse/sse?chatId="+strconv.Itoa(s.chatID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastEventID := s.LastEventID(); lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := s.client.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+func (s *Subscription) run(ctx context.Context, resp *http.Response) {
+	var err error
+	defer func() {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		close(s.events)
+		close(s.done)
+		s.cancel()
+	}()
+
+	for {
+		deleted := s.read(ctx, resp.Body)
+		resp.Body.Close()
+		if deleted || ctx.Err() != nil {
+			return
+		}
+		if s.opts.OnDisconnect != nil {
+			s.opts.OnDisconnect()
+		}
+
+		resp, err = s.reconnect(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				err = nil
+			}
+			return
+		}
+		if s.opts.OnReconnect != nil {
+			s.opts.OnReconnect()
+		}
+	}
+}
+
+// reconnect повторяет подключение с увеличивающейся паузой.
+// Ответ 4xx, например 404 после удаления чата или 403 после исключения из приватного чата,
+// не изменится при повторе и прекращает попытки. 408 и 429 повторяются.
+func (s *Subscription) reconnect(ctx context.Context) (*http.Response, error) {
+	delay := s.reconnectDelay()
+	for {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		resp, err := s.connect(ctx)
+		if err == nil {
+			return resp, nil
+		}
+		if permanent(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		delay = min(delay*2, s.opts.MaxReconnectDelay)
+	}
+}
+
+// permanent ответ сервера, который не изменится при повторе подключения
+func permanent(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	status := apiErr.StatusCode
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+func (s *Subscription) reconnectDelay() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.opts.ReconnectDelay
+}
+
+// read разбирает поток text/event-stream до разрыва соединения.
+// Возвращает true, если чат был удален и переподключаться не нужно.
+func (s *Subscription) read(ctx context.Context, body io.Reader) bool {
+	reader := bufio.NewReader(body)
+	var (
+		id, name string
+		hasID    bool
+		data     []string
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return false
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if len(data) == 0 && !hasID {
+				continue
+			}
+			event, ok := s.dispatch(id, hasID, name, strings.Join(data, "\n"))
+			id, name, hasID, data = "", "", false, nil
+			if !ok {
+				continue
+			}
+			select {
+			case s.events <- event:
+			case <-ctx.Done():
+				return false
+			}
+			if event.Type == EventChatDeleted {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id, hasID = value, true
+		case "event":
+			name = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+				s.mu.Lock()
+				s.opts.ReconnectDelay = time.Duration(ms) * time.Millisecond
+				s.mu.Unlock()
+			}
+		}
+	}
+}
+
+// dispatch запоминает ID события и декодирует данные по типу события
+func (s *Subscription) dispatch(id string, hasID bool, name, data string) (Event, bool) {
+	if hasID {
+		s.mu.Lock()
+		s.lastEventID = id
+		s.mu.Unlock()
+	}
+	if data == "" {
+		return Event{}, false
+	}
+	if name == "" {
+		name = EventMessage
+	}
+
+	event := Event{ID: id, Type: name, ChatID: s.chatID, Data: []byte(data)}
+	switch name {
+	case EventMessage:
+		var msg Message
+		if err := json.Unmarshal(event.Data, &msg); err == nil {
+			event.Message = &msg
+		}
+	case EventChatUpdated:
+		var envelope struct {
+			Chat *Chat `json:"chat"`
+		}
+		if err := json.Unmarshal(event.Data, &envelope); err == nil {
+			event.Chat = envelope.Chat
+		}
+	case EventMessageDeleted, EventMessageExpired:
+		var envelope struct {
+			MessageIDs []int `json:"messageIds"`
+		}
+		if err := json.Unmarshal(event.Data, &envelope); err == nil {
+			event.MessageIDs = envelope.MessageIDs
+		}
+	}
+	return event, true
+}
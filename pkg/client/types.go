@@ -0,0 +1,93 @@
+package client
+
+import "time"
+
+type ChatSettings struct {
+	SlowModeSeconds int  `json:"SlowModeSeconds"`
+	ReadOnly        bool `json:"ReadOnly"`
+	RetentionDays   int  `json:"RetentionDays"`
+}
+
+// ChatInput данные для создания чата
+type ChatInput struct {
+	Title       string        `json:"Title"`
+	Private     bool          `json:"Private,omitempty"`
+	Description string        `json:"Description,omitempty"`
+	AvatarURL   string        `json:"AvatarUrl,omitempty"`
+	Settings    *ChatSettings `json:"Settings,omitempty"`
+}
+
+type Chat struct {
+	ID          int          `json:"Id"`
+	Type        string       `json:"Type"`
+	Title       string       `json:"Title"`
+	Private     bool         `json:"Private"`
+	Description string       `json:"Description"`
+	AvatarURL   string       `json:"AvatarUrl"`
+	Settings    ChatSettings `json:"Settings"`
+	Version     int          `json:"Version"`
+	CreatedAt   time.Time    `json:"CreatedAt"`
+	// Удержание по требованию юристов, сообщения чата не удаляются по сроку хранения
+	LegalHold bool `json:"LegalHold"`
+	// Заполняется только в GetChat
+	Messages []Message `json:"messages,omitempty"`
+}
+
+type Message struct {
+	ID     int    `json:"Id"`
+	ChatID int    `json:"ChatId"`
+	Kind   string `json:"Kind"`
+	Text   string `json:"Text"`
+	// Автор сообщения, пустой у системных и анонимных сообщений
+	AuthorID string `json:"AuthorId,omitempty"`
+	// Идентификатор, переданный клиентом в MessageInput.ClientNonce
+	ClientNonce string `json:"ClientNonce,omitempty"`
+	// Ответ слэш-команды, который видит только отправитель. Такое сообщение не сохраняется, ID равен 0
+	Ephemeral bool      `json:"Ephemeral,omitempty"`
+	CreatedAt time.Time `json:"CreatedAt"`
+	// Когда самоуничтожающееся сообщение исчезнет, nil у обычных сообщений
+	ExpiresAt *time.Time `json:"ExpiresAt,omitempty"`
+}
+
+// MessageInput новое сообщение. ClientNonce позволяет сопоставить сообщение из ответа и из SSE
+// с черновиком на клиенте и безопасно повторить отправку
+type MessageInput struct {
+	Text        string `json:"Text"`
+	ClientNonce string `json:"ClientNonce,omitempty"`
+	// Время жизни сообщения в секундах, не больше недели. 0 - обычное сообщение
+	TTLSeconds int `json:"TtlSeconds,omitempty"`
+}
+
+// Типы сообщений
+const (
+	MessageKindUser   = "user"
+	MessageKindSystem = "system"
+	// Сообщение входящего вебхука, AuthorID - имя бота
+	MessageKindBot = "bot"
+)
+
+// Типы событий SSE потока
+const (
+	EventMessage     = "message"
+	EventChatUpdated = "chat.updated"
+	EventChatDeleted = "chat.deleted"
+	// Сообщения удалены по сроку хранения
+	EventMessageDeleted = "message.deleted"
+	// Истек TTL самоуничтожающихся сообщений, их нужно убрать с экрана
+	EventMessageExpired = "message.expired"
+)
+
+// Event событие из SSE потока чата.
+// Для EventMessage заполнено Message, для EventChatUpdated - Chat, для EventMessageDeleted и EventMessageExpired - MessageIDs.
+type Event struct {
+	// ID события, для сообщений совпадает с ID сообщения
+	ID      string
+	Type    string
+	ChatID  int
+	Message *Message
+	Chat    *Chat
+	// ID удаленных или истекших сообщений
+	MessageIDs []int
+	// Данные события как они пришли с сервера
+	Data []byte
+}
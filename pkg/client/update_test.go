@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// patchChat отправляет PATCH /v1/chats/{chatId} напрямую: в SDK нет метода с If-Match
+func patchChat(t *testing.T, s *testServer, chatID int, userID, ifMatch, body string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPatch, s.baseURL+chatPath(chatID), strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req.Header.Set(userIDHeader, userID)
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("patch chat: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		return resp, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+func TestUpdateChatIfMatch(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "general"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		ifMatch string
+		status  int
+		code    string
+		version int
+	}{
+		{name: "without If-Match", userID: "alice", status: http.StatusOK, version: 2},
+		{name: "matching version", userID: "alice", ifMatch: `"2"`, status: http.StatusOK, version: 3},
+		{name: "weak tag", userID: "alice", ifMatch: `W/"3"`, status: http.StatusOK, version: 4},
+		{name: "any version", userID: "alice", ifMatch: "*", status: http.StatusOK, version: 5},
+		{name: "stale version", userID: "alice", ifMatch: `"1"`, status: http.StatusPreconditionFailed, code: "chat_version_conflict"},
+		{name: "malformed tag", userID: "alice", ifMatch: "5", status: http.StatusBadRequest, code: "invalid_if_match"},
+		{name: "not a version", userID: "alice", ifMatch: `"v5"`, status: http.StatusBadRequest, code: "invalid_if_match"},
+		{name: "multiple tags", userID: "alice", ifMatch: `"4", "5"`, status: http.StatusBadRequest, code: "invalid_if_match"},
+		{name: "anonymous", status: http.StatusForbidden, code: "not_chat_manager"},
+		{name: "not a manager", userID: "bob", status: http.StatusForbidden, code: "not_chat_manager"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := patchChat(t, s, chat.ID, tt.userID, tt.ifMatch, `{"Title":"`+tt.name+`"}`)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d: %v", tt.status, resp.StatusCode, err)
+			}
+			if tt.code != "" {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.code {
+					t.Fatalf("expected code %s, got %v", tt.code, err)
+				}
+				return
+			}
+
+			var updated Chat
+			if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+				t.Fatalf("decode chat: %v", err)
+			}
+			if updated.Title != tt.name || updated.Version != tt.version {
+				t.Fatalf("unexpected chat: %+v", updated)
+			}
+			if etag := resp.Header.Get("ETag"); etag != `"`+strconv.Itoa(tt.version)+`"` {
+				t.Fatalf("unexpected ETag %q", etag)
+			}
+		})
+	}
+
+	got, err := s.GetChat(ctx, chat.ID)
+	if err != nil || got.Title != "any version" || got.Version != 5 {
+		t.Fatalf("rejected updates must not change the chat: %+v, %v", got, err)
+	}
+}
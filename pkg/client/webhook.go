@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки запроса вебхука
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+var (
+	InvalidSignatureError = errors.New("invalid webhook signature")
+	ExpiredSignatureError = errors.New("webhook signature timestamp is outside the tolerance")
+)
+
+// Webhook вебхук чата
+type Webhook struct {
+	ID     int      `json:"Id"`
+	ChatID int      `json:"ChatId"`
+	URL    string   `json:"Url"`
+	Events []string `json:"Events"`
+	// Секрет подписи, сервер возвращает его только при создании
+	Secret    string    `json:"Secret,omitempty"`
+	CreatedBy string    `json:"CreatedBy"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
+
+// WebhookInput данные для создания вебхука. Пустой Events - все события
+type WebhookInput struct {
+	URL    string   `json:"Url"`
+	Events []string `json:"Events,omitempty"`
+}
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery запись журнала доставок
+type WebhookDelivery struct {
+	ID             int        `json:"Id"`
+	WebhookID      int        `json:"WebhookId"`
+	Event          string     `json:"Event"`
+	Status         string     `json:"Status"`
+	Attempts       int        `json:"Attempts"`
+	NextAttemptAt  *time.Time `json:"NextAttemptAt,omitempty"`
+	LastStatusCode int        `json:"LastStatusCode"`
+	LastError      string     `json:"LastError"`
+	CreatedAt      time.Time  `json:"CreatedAt"`
+	DeliveredAt    *time.Time `json:"DeliveredAt,omitempty"`
+}
+
+// WebhookPayload тело запроса вебхука
+type WebhookPayload struct {
+	Type      string    `json:"type"`
+	ChatID    int       `json:"chatId"`
+	Message   *Message  `json:"message,omitempty"`
+	Chat      *Chat     `json:"chat,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateWebhook создает вебхук чата. Secret в ответе нужно сохранить для проверки подписи
+func (c *Client) CreateWebhook(ctx context.Context, chatID int, in WebhookInput) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.do(ctx, http.MethodPost, chatPath(chatID)+"/webhooks", in, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks возвращает вебхуки чата
+func (c *Client) ListWebhooks(ctx context.Context, chatID int) ([]Webhook, error) {
+	var resp struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+	if err := c.do(ctx, http.MethodGet, chatPath(chatID)+"/webhooks", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Webhooks, nil
+}
+
+// DeleteWebhook удаляет вебхук, еще не доставленные события отменяются
+func (c *Client) DeleteWebhook(ctx context.Context, chatID, webhookID int) error {
+	return c.do(ctx, http.MethodDelete, webhookPath(chatID, webhookID), nil, nil)
+}
+
+// ListWebhookDeliveries возвращает журнал доставок вебхука, новые первыми. status "" - все статусы
+func (c *Client) ListWebhookDeliveries(ctx context.Context, chatID, webhookID int, status string, limit, offset int) ([]WebhookDelivery, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	path := webhookPath(chatID, webhookID) + "/deliveries"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp struct {
+		Deliveries []WebhookDelivery `json:"deliveries"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Deliveries, nil
+}
+
+// RetryWebhookDelivery возвращает доставку в статусе dead в очередь
+func (c *Client) RetryWebhookDelivery(ctx context.Context, chatID, webhookID, deliveryID int) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	path := webhookPath(chatID, webhookID) + "/deliveries/" + strconv.Itoa(deliveryID) + "/retry"
+	if err := c.do(ctx, http.MethodPost, path, nil, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func webhookPath(chatID, webhookID int) string {
+	return chatPath(chatID) + "/webhooks/" + strconv.Itoa(webhookID)
+}
+
+// VerifyWebhookSignature проверяет заголовок X-Webhook-Signature для тела запроса body.
+// tolerance ограничивает возраст подписи, чтобы перехваченный запрос нельзя было повторить позже, 0 - не проверять
+func VerifyWebhookSignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return InvalidSignatureError
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return InvalidSignatureError
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return InvalidSignatureError
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(unix, 0))
+		if age > tolerance || age < -tolerance {
+			return ExpiredSignatureError
+		}
+	}
+	return nil
+}
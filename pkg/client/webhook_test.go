@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"chat-project/config"
+	"chat-project/internal/logger"
+	"chat-project/internal/webhook"
+)
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	s := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := make(chan webhookRequest, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- webhookRequest{header: r.Header.Clone(), body: body}
+	}))
+	t.Cleanup(receiver.Close)
+
+	dispatcher := webhook.NewDispatcher(s.webhooks, config.Webhook{
+		Workers: 1, PollInterval: 10 * time.Millisecond, BatchSize: 10, Timeout: time.Second, MaxAttempts: 3, AllowPrivateAddresses: true,
+	}, logger.Discard(), nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(ctx)
+	}()
+	defer func() { cancel(); <-done }()
+
+	chat, err := s.CreateChat(ctx, ChatInput{Title: "hooks"})
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+
+	_, err = s.CreateWebhook(ctx, chat.ID, WebhookInput{URL: receiver.URL, Events: []string{"message.sent"}})
+	var apiErr *APIError
+	if !errors.Is(err, BadRequestError) || !errors.As(err, &apiErr) || apiErr.Code != "invalid_webhook_event" {
+		t.Fatalf("expected invalid_webhook_event, got %v", err)
+	}
+	_, err = New(s.baseURL, WithUserID("bob")).CreateWebhook(ctx, chat.ID, WebhookInput{URL: receiver.URL})
+	if !errors.Is(err, ForbiddenError) {
+		t.Fatalf("expected forbidden for non-manager, got %v", err)
+	}
+
+	hook, err := s.CreateWebhook(ctx, chat.ID, WebhookInput{URL: receiver.URL, Events: []string{"message.created", "chat.deleted"}})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	if hook.Secret == "" || len(hook.Events) != 2 {
+		t.Fatalf("unexpected webhook: %+v", hook)
+	}
+	hooks, err := s.ListWebhooks(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("list webhooks: %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].ID != hook.ID || hooks[0].Secret != "" {
+		t.Fatalf("unexpected webhooks: %+v", hooks)
+	}
+
+	if _, err := s.AddMessage(ctx, chat.ID, "ping"); err != nil {
+		t.Fatalf("add message: %v", err)
+	}
+	var req webhookRequest
+	select {
+	case req = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	if err := VerifyWebhookSignature(hook.Secret, req.header.Get(WebhookSignatureHeader), req.body, time.Minute); err != nil {
+		t.Fatalf("verify signature: %v", err)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Type != "message.created" || payload.ChatID != chat.ID || payload.Message == nil || payload.Message.Text != "ping" {
+		t.Fatalf("unexpected payload: %s", req.body)
+	}
+
+	var deliveries []WebhookDelivery
+	waitFor(t, func() bool {
+		deliveries, err = s.ListWebhookDeliveries(ctx, chat.ID, hook.ID, DeliveryDelivered, 0, 0)
+		return err == nil && len(deliveries) == 1
+	})
+	if deliveries[0].Event != "message.created" || deliveries[0].Attempts != 1 || deliveries[0].DeliveredAt == nil {
+		t.Fatalf("unexpected delivery: %+v", deliveries[0])
+	}
+	if req.header.Get(WebhookDeliveryHeader) == "" || req.header.Get(WebhookEventHeader) != "message.created" {
+		t.Fatalf("unexpected headers: %v", req.header)
+	}
+	_, err = s.RetryWebhookDelivery(ctx, chat.ID, hook.ID, deliveries[0].ID)
+	if !errors.Is(err, ConflictError) || !errors.As(err, &apiErr) || apiErr.Code != "delivery_not_dead" {
+		t.Fatalf("expected delivery_not_dead, got %v", err)
+	}
+
+	// chat.deleted доходит после удаления чата вместе с вебхуком
+	if err := s.DeleteChat(ctx, chat.ID); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+	select {
+	case req = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("chat.deleted not delivered")
+	}
+	if req.header.Get(WebhookEventHeader) != "chat.deleted" {
+		t.Fatalf("expected chat.deleted, got %s", req.body)
+	}
+}